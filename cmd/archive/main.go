@@ -0,0 +1,130 @@
+// Command archive signs a private data archive returned by the
+// BackupPrivateData transaction with the signing key of the PIU, so that it
+// can be restored by the RestorePrivateData transaction.
+//
+//	archive -key signing_key.pem -file archive.json > signed_archive.json
+//
+// The key is a PEM encoded PKCS #8, PKCS #1 or SEC 1 private key matching the
+// signing key registered by the PIU.
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+)
+
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+
+	if block == nil {
+		return nil, errors.New("key is not PEM encoded")
+	}
+
+	var key any
+	var err error
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+
+	if !ok {
+		return nil, errors.New("unsupported private key type")
+	}
+
+	return signer, nil
+}
+
+// sign signs the SHA-256 digest the way the chaincode verifies it.
+func sign(key crypto.Signer, digest []byte) ([]byte, error) {
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest)
+	case *ecdsa.PrivateKey:
+		return ecdsa.SignASN1(rand.Reader, key, digest)
+	case ed25519.PrivateKey:
+		return ed25519.Sign(key, digest), nil
+	default:
+		return nil, errors.New("unsupported private key type")
+	}
+}
+
+func main() {
+	log.SetFlags(0)
+
+	keyFile := flag.String("key", "", "PEM encoded private signing key of the PIU")
+	file := flag.String("file", "", "archive returned by BackupPrivateData")
+
+	flag.Parse()
+
+	if *keyFile == "" || *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	keyData, err := os.ReadFile(*keyFile)
+	if err != nil {
+		log.Fatalf("Could not read %s: %v", *keyFile, err)
+	}
+
+	key, err := parsePrivateKey(keyData)
+	if err != nil {
+		log.Fatalf("Could not parse %s: %v", *keyFile, err)
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		log.Fatalf("Could not read %s: %v", *file, err)
+	}
+
+	var archive entities.PrivateDataArchive
+
+	if err := json.Unmarshal(data, &archive); err != nil {
+		log.Fatalf("Could not parse %s: %v", *file, err)
+	}
+
+	digest, err := archive.Digest()
+	if err != nil {
+		log.Fatalf("Could not compute digest of archive: %v", err)
+	}
+
+	if hex.EncodeToString(digest) != archive.SHA256 {
+		log.Fatalf("Archive does not match its digest")
+	}
+
+	archive.Signature, err = sign(key, digest)
+	if err != nil {
+		log.Fatalf("Could not sign archive: %v", err)
+	}
+
+	out, err := json.Marshal(archive)
+	if err != nil {
+		log.Fatalf("Could not encode archive: %v", err)
+	}
+
+	fmt.Println(string(out))
+}
@@ -0,0 +1,127 @@
+// Command collections generates the collections configuration of the
+// chaincode for a set of PIUs or validates an existing one.
+//
+//	collections generate -pius org1MSP,org2MSP > collections_config.json
+//	collections validate -registry pius.json -file collections_config.json
+//
+// The registry is the JSON array of PIUs returned by the GetAllPIUs transaction
+// invoked with {"includeRetired": true}, collections of retired PIUs keep
+// their records and stay in the configuration. PIUs of MSPs hosting several
+// PIUs are named <MSPID>_<unit> and those MSPs are listed by -multi-piu-msps.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/collections"
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: collections generate|validate [flags]")
+	os.Exit(2)
+}
+
+func readJSON(path string, v any) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Could not read %s: %v", path, err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		log.Fatalf("Could not parse %s: %v", path, err)
+	}
+}
+
+// splitList returns items of a comma separated list.
+func splitList(list string) []string {
+	var result []string
+
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	command := os.Args[1]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	defaults := collections.DefaultOptions()
+
+	pius := flags.String("pius", "", "comma separated ids of the PIUs")
+	registry := flags.String("registry", "", "JSON file with the registered PIUs including retired ones")
+	multiPIUMSPs := flags.String("multi-piu-msps", "", "comma separated MSP ids hosting several PIUs")
+	naming := flags.String("naming", string(defaults.Naming), "naming of PIU collections, explicit or implicit")
+	pairwise := flags.Bool("pairwise", defaults.Pairwise, "define a collection for every pair of PIUs")
+	blockToLive := flags.Uint64("btl", defaults.BlockToLive, "blocks to live of private data, 0 keeps them until purged")
+	requiredPeerCount := flags.Int("required-peers", defaults.RequiredPeerCount, "peers private data must be disseminated to")
+	maxPeerCount := flags.Int("max-peers", defaults.MaxPeerCount, "peers private data are disseminated to at most")
+	file := flags.String("file", "", "collections configuration to validate")
+
+	flags.Parse(os.Args[2:])
+
+	piuIds := splitList(*pius)
+
+	if *registry != "" {
+		var registered []entities.PIU
+		readJSON(*registry, &registered)
+
+		for _, piu := range registered {
+			piuIds = append(piuIds, piu.Id)
+		}
+	}
+
+	opts := collections.Options{
+		Naming:            collections.Naming(*naming),
+		Pairwise:          *pairwise,
+		BlockToLive:       *blockToLive,
+		RequiredPeerCount: *requiredPeerCount,
+		MaxPeerCount:      *maxPeerCount,
+		MultiPIUMSPs:      splitList(*multiPIUMSPs),
+	}
+
+	switch command {
+	case "generate":
+		definitions, err := collections.Generate(piuIds, opts)
+		if err != nil {
+			log.Fatalf("Could not generate collections: %v", err)
+		}
+
+		out, err := json.MarshalIndent(definitions, "", "  ")
+		if err != nil {
+			log.Fatalf("Could not encode collections: %v", err)
+		}
+
+		fmt.Println(string(out))
+	case "validate":
+		if *file == "" {
+			log.Fatalf("Missing collections configuration, use -file")
+		}
+
+		var definitions []collections.Definition
+		readJSON(*file, &definitions)
+
+		if err := collections.Validate(definitions, piuIds, opts); err != nil {
+			log.Fatalf("Invalid collections configuration:\n%v", err)
+		}
+
+		fmt.Println("Collections configuration is valid")
+	default:
+		usage()
+	}
+}
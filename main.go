@@ -1,26 +1,92 @@
+// Command tenacity-chaincode runs the PNR exchange chaincode. Settings of the
+// consortium, such as administrators, quotas and MSPs hosting several PIUs,
+// are stored on the ledger by InitLedger and changed by UpdateConfig, all
+// other transactions fail until the ledger is initialised. The environment
+// only sets what the peer needs before that and how it stores data:
+//
+//	TENACITY_ADMIN_MSPS            comma separated MSPs allowed to invoke
+//	                               InitLedger, required
+//	TENACITY_COLLECTIONS           explicit (default) or implicit collections
+//	TENACITY_PAIR_COLLECTION_PIUS  comma separated PIUs using pairwise
+//	                               collections
+//	TENACITY_KEY_ENDORSEMENT       true to require both parties to endorse
+//	                               changes of PNR metadata
+//
+// The values have to be the same on all peers. CHAINCODE_SERVER_ADDRESS runs
+// the chaincode as an external service, see serve.
 package main
 
 import (
 	"log"
 	"log/slog"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/nesfit/tenacity-chaincode/pkg/contract"
+	"github.com/nesfit/tenacity-chaincode/pkg/repository/privatedata"
 )
 
+// getEnvList returns items of a comma separated environment variable.
+func getEnvList(name string) []string {
+	var result []string
+
+	for _, item := range strings.Split(os.Getenv(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
 func main() {
 	var handler = slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{AddSource: true})
 	var logger = slog.New(handler)
 
 	slog.SetDefault(logger)
 
-	c := contract.NewSmartContract(&contract.LedgerUsecaseFactory{})
+	uf := &contract.LedgerUsecaseFactory{BootstrapAdminMSPs: getEnvList("TENACITY_ADMIN_MSPS")}
+
+	if len(uf.BootstrapAdminMSPs) == 0 {
+		log.Panicf("TENACITY_ADMIN_MSPS has to list MSPs allowed to initialise the ledger")
+	}
+
+	switch collections := os.Getenv("TENACITY_COLLECTIONS"); collections {
+	case "", "explicit":
+	case "implicit":
+		uf.CollectionNaming = privatedata.ImplicitOrgCollectionNaming
+		uf.PerMSPCollections = true
+	default:
+		log.Panicf("Unknown collections mode: %s", collections)
+	}
+
+	uf.PairCollectionPIUs = getEnvList("TENACITY_PAIR_COLLECTION_PIUS")
+
+	if keyEndorsement := os.Getenv("TENACITY_KEY_ENDORSEMENT"); keyEndorsement != "" {
+		enabled, err := strconv.ParseBool(keyEndorsement)
+		if err != nil {
+			log.Panicf("Invalid key endorsement setting: %v", err)
+		}
+		uf.KeyEndorsement = enabled
+	}
+
+	if err := uf.Validate(); err != nil {
+		log.Panicf("Invalid endorsement setting: %v", err)
+	}
+
+	c := contract.NewSmartContract(uf)
 	chaincode, err := contractapi.NewChaincode(&c)
 	if err != nil {
 		log.Panicf("Error creating chaincode: %v", err)
 	}
 
+	if address := os.Getenv("CHAINCODE_SERVER_ADDRESS"); address != "" {
+		serve(chaincode, address)
+		return
+	}
+
 	if err := chaincode.Start(); err != nil {
 		log.Panicf("Error starting  chaincode: %v", err)
 	}
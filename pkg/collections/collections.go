@@ -0,0 +1,240 @@
+// Package collections generates and validates the collections configuration
+// the chaincode has to be approved with for the private data repository.
+package collections
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+	"github.com/nesfit/tenacity-chaincode/pkg/repository/privatedata"
+)
+
+type Naming string
+
+const (
+	// NamingExplicit defines a collection <PIUID>Collection for every PIU.
+	NamingExplicit Naming = "explicit"
+	// NamingImplicit uses the implicit per-organisation collections, which
+	// are not part of the collections configuration.
+	NamingImplicit Naming = "implicit"
+)
+
+type Options struct {
+	// Naming of the collection of every PIU.
+	Naming Naming
+	// Pairwise defines a collection for every pair of PIUs, see
+	// privatedata.WithPairCollections.
+	Pairwise bool
+	// BlockToLive is the number of blocks after which private data are
+	// purged, 0 keeps them until the chaincode purges them.
+	BlockToLive       uint64
+	RequiredPeerCount int
+	MaxPeerCount      int
+	// MultiPIUMSPs are MSPs hosting several PIUs, whose ids are prefixed with
+	// the MSP id, see entities.ConsortiumConfig.MultiPIUMSPs.
+	MultiPIUMSPs []string
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Naming:       NamingExplicit,
+		MaxPeerCount: 1,
+	}
+}
+
+type EndorsementPolicy struct {
+	SignaturePolicy string `json:"signaturePolicy,omitempty"`
+}
+
+// Definition is an entry of the collections configuration.
+type Definition struct {
+	Name              string             `json:"name"`
+	Policy            string             `json:"policy"`
+	RequiredPeerCount int                `json:"requiredPeerCount"`
+	MaxPeerCount      int                `json:"maxPeerCount"`
+	BlockToLive       uint64             `json:"blockToLive"`
+	MemberOnlyRead    bool               `json:"memberOnlyRead"`
+	MemberOnlyWrite   bool               `json:"memberOnlyWrite"`
+	EndorsementPolicy *EndorsementPolicy `json:"endorsementPolicy,omitempty"`
+}
+
+// collectionNameRegexp is the format of collection names accepted by Fabric.
+var collectionNameRegexp = regexp.MustCompile(`^[A-Za-z0-9-]+([A-Za-z0-9_-]+)*$`)
+
+// memberPolicy returns the policy satisfied by members of the MSPs hosting
+// the PIUs.
+func memberPolicy(opts Options, piuIds ...string) string {
+	var members []string
+
+	for _, piuId := range piuIds {
+		member := fmt.Sprintf("'%s.member'", entities.GetPIUMSPId(piuId, opts.MultiPIUMSPs))
+
+		if !slices.Contains(members, member) {
+			members = append(members, member)
+		}
+	}
+
+	return fmt.Sprintf("OR(%s)", strings.Join(members, ", "))
+}
+
+// Generate returns the collection definitions for the PIUs ordered by name.
+// Collections are named after PIU ids, while policies name the MSPs hosting
+// the PIUs.
+//
+// Every PIU reads only its own collection. In the default mode the other PIU
+// of a PNR request writes into it, so writing is not restricted to members.
+// In the pairwise mode the collection of a PIU holds only its local records,
+// so only its members may write and endorse changes to it. Pair collections
+// are written by forwarding PIUs too, so writing is not restricted there.
+func Generate(piuIds []string, opts Options) ([]Definition, error) {
+	ids := slices.Clone(piuIds)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	if len(ids) == 0 {
+		return nil, errors.New("no PIUs")
+	}
+
+	result := []Definition{}
+
+	switch opts.Naming {
+	case NamingExplicit:
+		for _, id := range ids {
+			definition := Definition{
+				Name:              privatedata.ExplicitCollectionNaming(id),
+				Policy:            memberPolicy(opts, id),
+				RequiredPeerCount: opts.RequiredPeerCount,
+				MaxPeerCount:      opts.MaxPeerCount,
+				BlockToLive:       opts.BlockToLive,
+				MemberOnlyRead:    true,
+				MemberOnlyWrite:   opts.Pairwise,
+			}
+
+			if opts.Pairwise {
+				definition.EndorsementPolicy = &EndorsementPolicy{SignaturePolicy: memberPolicy(opts, id)}
+			}
+
+			result = append(result, definition)
+		}
+	case NamingImplicit:
+		if len(opts.MultiPIUMSPs) > 0 {
+			return nil, errors.New("implicit collections cannot be used with MSPs hosting several PIUs")
+		}
+	default:
+		return nil, fmt.Errorf("unknown naming: %s", opts.Naming)
+	}
+
+	if opts.Pairwise {
+		for i, id := range ids {
+			for _, otherId := range ids[i+1:] {
+				result = append(result, Definition{
+					Name:              privatedata.SortedPairCollectionNaming(id, otherId),
+					Policy:            memberPolicy(opts, id, otherId),
+					RequiredPeerCount: opts.RequiredPeerCount,
+					MaxPeerCount:      opts.MaxPeerCount,
+					BlockToLive:       opts.BlockToLive,
+					MemberOnlyRead:    true,
+					MemberOnlyWrite:   false,
+				})
+			}
+		}
+	}
+
+	for _, definition := range result {
+		if !collectionNameRegexp.MatchString(definition.Name) {
+			return nil, fmt.Errorf("invalid collection name: %s", definition.Name)
+		}
+	}
+
+	slices.SortFunc(result, func(a, b Definition) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return result, nil
+}
+
+// Validate compares the collection definitions with those generated for the
+// PIUs and returns all differences found.
+func Validate(definitions []Definition, piuIds []string, opts Options) error {
+	expected, err := Generate(piuIds, opts)
+
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	actual := make(map[string]Definition)
+
+	for _, definition := range definitions {
+		if _, ok := actual[definition.Name]; ok {
+			errs = append(errs, fmt.Errorf("collection %s: defined more than once", definition.Name))
+		}
+
+		actual[definition.Name] = definition
+	}
+
+	for _, want := range expected {
+		got, ok := actual[want.Name]
+
+		if !ok {
+			errs = append(errs, fmt.Errorf("collection %s: missing", want.Name))
+			continue
+		}
+
+		delete(actual, want.Name)
+
+		if got.Policy != want.Policy {
+			errs = append(errs, fmt.Errorf("collection %s: policy is %s, expected %s", want.Name, got.Policy, want.Policy))
+		}
+
+		if got.RequiredPeerCount != want.RequiredPeerCount {
+			errs = append(errs, fmt.Errorf("collection %s: requiredPeerCount is %d, expected %d", want.Name, got.RequiredPeerCount, want.RequiredPeerCount))
+		}
+
+		if got.MaxPeerCount != want.MaxPeerCount {
+			errs = append(errs, fmt.Errorf("collection %s: maxPeerCount is %d, expected %d", want.Name, got.MaxPeerCount, want.MaxPeerCount))
+		}
+
+		if got.BlockToLive != want.BlockToLive {
+			errs = append(errs, fmt.Errorf("collection %s: blockToLive is %d, expected %d", want.Name, got.BlockToLive, want.BlockToLive))
+		}
+
+		if got.MemberOnlyRead != want.MemberOnlyRead {
+			errs = append(errs, fmt.Errorf("collection %s: memberOnlyRead is %t, expected %t", want.Name, got.MemberOnlyRead, want.MemberOnlyRead))
+		}
+
+		if got.MemberOnlyWrite != want.MemberOnlyWrite {
+			errs = append(errs, fmt.Errorf("collection %s: memberOnlyWrite is %t, expected %t", want.Name, got.MemberOnlyWrite, want.MemberOnlyWrite))
+		}
+
+		if gotPolicy, wantPolicy := signaturePolicy(got), signaturePolicy(want); gotPolicy != wantPolicy {
+			errs = append(errs, fmt.Errorf("collection %s: endorsement policy is %q, expected %q", want.Name, gotPolicy, wantPolicy))
+		}
+	}
+
+	var unexpected []string
+
+	for name := range actual {
+		unexpected = append(unexpected, name)
+	}
+
+	slices.Sort(unexpected)
+
+	for _, name := range unexpected {
+		errs = append(errs, fmt.Errorf("collection %s: not used by any PIU", name))
+	}
+
+	return errors.Join(errs...)
+}
+
+func signaturePolicy(definition Definition) string {
+	if definition.EndorsementPolicy == nil {
+		return ""
+	}
+
+	return definition.EndorsementPolicy.SignaturePolicy
+}
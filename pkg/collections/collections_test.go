@@ -0,0 +1,120 @@
+package collections_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/collections"
+)
+
+func TestGenerateExplicit(t *testing.T) {
+	assert := assert.New(t)
+
+	expected := []collections.Definition{
+		{Name: "org1MSPCollection", Policy: "OR('org1MSP.member')", MaxPeerCount: 1, MemberOnlyRead: true},
+		{Name: "org2MSPCollection", Policy: "OR('org2MSP.member')", MaxPeerCount: 1, MemberOnlyRead: true},
+	}
+
+	actual, err := collections.Generate([]string{"org2MSP", "org1MSP", "org1MSP"}, collections.DefaultOptions())
+	assert.NoError(err)
+	assert.Equal(expected, actual)
+}
+
+func TestGeneratePairwise(t *testing.T) {
+	assert := assert.New(t)
+
+	opts := collections.DefaultOptions()
+	opts.Pairwise = true
+	opts.BlockToLive = 100
+
+	expected := []collections.Definition{
+		{Name: "org1MSPCollection", Policy: "OR('org1MSP.member')", MaxPeerCount: 1, BlockToLive: 100, MemberOnlyRead: true, MemberOnlyWrite: true, EndorsementPolicy: &collections.EndorsementPolicy{SignaturePolicy: "OR('org1MSP.member')"}},
+		{Name: "org1MSP_org2MSP", Policy: "OR('org1MSP.member', 'org2MSP.member')", MaxPeerCount: 1, BlockToLive: 100, MemberOnlyRead: true},
+		{Name: "org1MSP_org3MSP", Policy: "OR('org1MSP.member', 'org3MSP.member')", MaxPeerCount: 1, BlockToLive: 100, MemberOnlyRead: true},
+		{Name: "org2MSPCollection", Policy: "OR('org2MSP.member')", MaxPeerCount: 1, BlockToLive: 100, MemberOnlyRead: true, MemberOnlyWrite: true, EndorsementPolicy: &collections.EndorsementPolicy{SignaturePolicy: "OR('org2MSP.member')"}},
+		{Name: "org2MSP_org3MSP", Policy: "OR('org2MSP.member', 'org3MSP.member')", MaxPeerCount: 1, BlockToLive: 100, MemberOnlyRead: true},
+		{Name: "org3MSPCollection", Policy: "OR('org3MSP.member')", MaxPeerCount: 1, BlockToLive: 100, MemberOnlyRead: true, MemberOnlyWrite: true, EndorsementPolicy: &collections.EndorsementPolicy{SignaturePolicy: "OR('org3MSP.member')"}},
+	}
+
+	actual, err := collections.Generate([]string{"org3MSP", "org1MSP", "org2MSP"}, opts)
+	assert.NoError(err)
+	assert.Equal(expected, actual)
+}
+
+func TestGenerateMultiPIU(t *testing.T) {
+	assert := assert.New(t)
+
+	opts := collections.DefaultOptions()
+	opts.Pairwise = true
+	opts.MultiPIUMSPs = []string{"org1MSP"}
+
+	expected := []collections.Definition{
+		{Name: "org1MSP_customsCollection", Policy: "OR('org1MSP.member')", MaxPeerCount: 1, MemberOnlyRead: true, MemberOnlyWrite: true, EndorsementPolicy: &collections.EndorsementPolicy{SignaturePolicy: "OR('org1MSP.member')"}},
+		{Name: "org1MSP_customs_org1MSP_police", Policy: "OR('org1MSP.member')", MaxPeerCount: 1, MemberOnlyRead: true},
+		{Name: "org1MSP_customs_org2MSP", Policy: "OR('org1MSP.member', 'org2MSP.member')", MaxPeerCount: 1, MemberOnlyRead: true},
+		{Name: "org1MSP_policeCollection", Policy: "OR('org1MSP.member')", MaxPeerCount: 1, MemberOnlyRead: true, MemberOnlyWrite: true, EndorsementPolicy: &collections.EndorsementPolicy{SignaturePolicy: "OR('org1MSP.member')"}},
+		{Name: "org1MSP_police_org2MSP", Policy: "OR('org1MSP.member', 'org2MSP.member')", MaxPeerCount: 1, MemberOnlyRead: true},
+		{Name: "org2MSPCollection", Policy: "OR('org2MSP.member')", MaxPeerCount: 1, MemberOnlyRead: true, MemberOnlyWrite: true, EndorsementPolicy: &collections.EndorsementPolicy{SignaturePolicy: "OR('org2MSP.member')"}},
+	}
+
+	actual, err := collections.Generate([]string{"org1MSP_police", "org1MSP_customs", "org2MSP"}, opts)
+	assert.NoError(err)
+	assert.Equal(expected, actual)
+
+	opts.Naming = collections.NamingImplicit
+
+	_, err = collections.Generate([]string{"org1MSP_police", "org2MSP"}, opts)
+	assert.Error(err)
+}
+
+func TestGenerateImplicit(t *testing.T) {
+	assert := assert.New(t)
+
+	opts := collections.DefaultOptions()
+	opts.Naming = collections.NamingImplicit
+
+	actual, err := collections.Generate([]string{"org1MSP", "org2MSP"}, opts)
+	assert.NoError(err)
+	assert.Empty(actual)
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		mspIds []string
+		naming collections.Naming
+	}{
+		{name: "noPIUs", mspIds: nil, naming: collections.NamingExplicit},
+		{name: "unknownNaming", mspIds: []string{"org1MSP"}, naming: "other"},
+		{name: "invalidName", mspIds: []string{"org1.example.com"}, naming: collections.NamingExplicit},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := collections.DefaultOptions()
+			opts.Naming = tc.naming
+
+			_, err := collections.Generate(tc.mspIds, opts)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	opts := collections.DefaultOptions()
+	mspIds := []string{"org1MSP", "org2MSP"}
+
+	definitions, _ := collections.Generate(mspIds, opts)
+	assert.NoError(collections.Validate(definitions, mspIds, opts))
+
+	definitions[0].MemberOnlyRead = false
+	definitions[1].Name = "otherCollection"
+
+	err := collections.Validate(definitions, mspIds, opts)
+	assert.ErrorContains(err, "collection org1MSPCollection: memberOnlyRead is false, expected true")
+	assert.ErrorContains(err, "collection org2MSPCollection: missing")
+	assert.ErrorContains(err, "collection otherCollection: not used by any PIU")
+}
@@ -0,0 +1,116 @@
+package contract
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/swaggest/usecase/status"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+)
+
+// RoleAttribute is the client certificate attribute holding comma separated
+// roles of the client, e.g. pnr.role=analyst,supervisor.
+const RoleAttribute = "pnr.role"
+
+var (
+	readerRoles     = []entities.Role{entities.RoleAnalyst, entities.RoleSupervisor, entities.RoleDPO, entities.RoleAdmin}
+	officerRoles    = []entities.Role{entities.RoleAnalyst, entities.RoleSupervisor}
+	auditRoles      = []entities.Role{entities.RoleAnalyst, entities.RoleSupervisor, entities.RoleDPO}
+	supervisorRoles = []entities.Role{entities.RoleSupervisor}
+	adminRoles      = []entities.Role{entities.RoleAdmin}
+	legalRoles      = []entities.Role{entities.RoleDPO, entities.RoleAdmin}
+)
+
+// Permissions lists roles allowed to invoke each transaction. Transactions
+// missing from the list cannot be invoked by anyone.
+var Permissions = map[string][]entities.Role{
+	"InitLedger":                 adminRoles,
+	"UpdateConfig":               adminRoles,
+	"GetConfig":                  readerRoles,
+	"MigrateData":                adminRoles,
+	"BackupPrivateData":          adminRoles,
+	"RestorePrivateData":         adminRoles,
+	"ReconcilePrivateData":       adminRoles,
+	"SetPIUInfo":                 adminRoles,
+	"GetPIUHistory":              readerRoles,
+	"GetPIUs":                    readerRoles,
+	"GetAllPIUs":                 readerRoles,
+	"ApprovePIU":                 adminRoles,
+	"SuspendPIU":                 adminRoles,
+	"RetirePIU":                  adminRoles,
+	"ProposeAgreement":           legalRoles,
+	"SignAgreement":              legalRoles,
+	"RevokeAgreement":            legalRoles,
+	"GetAgreements":              readerRoles,
+	"GetQuotaStatus":             readerRoles,
+	"GetPNRs":                    auditRoles,
+	"ExportPNRMetadata":          auditRoles,
+	"NewPNRRequest":              officerRoles,
+	"SubmitPNRResponseAck":       officerRoles,
+	"SubmitPNRResponseNack":      officerRoles,
+	"ApprovePNRRequest":          supervisorRoles,
+	"ApprovePNRResponse":         supervisorRoles,
+	"ConfirmPNR":                 officerRoles,
+	"TerminatePNRRequest":        auditRoles,
+	"ForwardPNRRequest":          supervisorRoles,
+	"GetPNRThread":               auditRoles,
+	"RequestClarification":       officerRoles,
+	"ProvideClarification":       officerRoles,
+	"GetPNRClarifications":       auditRoles,
+	"VerifyPNRConsistency":       auditRoles,
+	"VerifyAllPNRConsistency":    auditRoles,
+	"VerifyPNRResponseSignature": auditRoles,
+}
+
+func GetClientRoles(ctx contractapi.TransactionContextInterface) ([]entities.Role, error) {
+	value, found, err := ctx.GetClientIdentity().GetAttributeValue(RoleAttribute)
+	if err != nil {
+		slog.Error(
+			"failed getting client's roles",
+			"error", err,
+		)
+		return nil, fmt.Errorf("failed getting client's roles: %v", err)
+	}
+
+	var roles []entities.Role
+
+	if !found {
+		return roles, nil
+	}
+
+	for _, role := range strings.Split(value, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, entities.Role(role))
+		}
+	}
+
+	return roles, nil
+}
+
+// Authorize fails unless the client has a role allowed to invoke the
+// transaction.
+func Authorize(ctx contractapi.TransactionContextInterface, transaction string) error {
+	roles, err := GetClientRoles(ctx)
+
+	if err != nil {
+		return status.Wrap(err, status.PermissionDenied)
+	}
+
+	for _, role := range roles {
+		if slices.Contains(Permissions[transaction], role) {
+			return nil
+		}
+	}
+
+	err = errors.New("client is not allowed to invoke " + transaction)
+	slog.Error(
+		err.Error(),
+		"roles", roles,
+	)
+	return status.Wrap(err, status.PermissionDenied)
+}
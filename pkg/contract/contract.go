@@ -3,37 +3,218 @@ package contract
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"regexp"
+	"slices"
+	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/swaggest/usecase/status"
 
 	"github.com/nesfit/tenacity-chaincode/pkg/entities"
 	"github.com/nesfit/tenacity-chaincode/pkg/repository/privatedata"
 	"github.com/nesfit/tenacity-chaincode/pkg/usecase"
 )
 
+// UsecaseFactory creates the usecase serving the transaction of given name.
 type UsecaseFactory interface {
-	New(ctx contractapi.TransactionContextInterface) (usecase.PNRExchangeUsecase, error)
+	New(ctx contractapi.TransactionContextInterface, transaction string) (usecase.PNRExchangeUsecase, error)
 }
 
 type LedgerUsecaseFactory struct {
+	// BootstrapAdminMSPs are the MSPs allowed to invoke InitLedger, which
+	// stores the consortium configuration used by all other transactions.
+	BootstrapAdminMSPs []string
+	// CollectionNaming overrides the default naming of private data
+	// collections when set.
+	CollectionNaming privatedata.CollectionNaming
+	// PerMSPCollections is set when CollectionNaming names collections of
+	// organisations, which cannot keep apart PIUs hosted by the same MSP.
+	PerMSPCollections bool
+	// PairCollectionPIUs enables pairwise collections named by
+	// privatedata.SortedPairCollectionNaming for the given PIUs when set.
+	PairCollectionPIUs []string
+	// KeyEndorsement requires both parties of a PNR request to endorse
+	// changes of its metadata when set. It can only be used together with
+	// PairCollectionPIUs, see privatedata.WithKeyEndorsement.
+	KeyEndorsement bool
 }
 
-func (uf *LedgerUsecaseFactory) New(ctx contractapi.TransactionContextInterface) (usecase.PNRExchangeUsecase, error) {
-	piuId, err := GetClientOrgId(ctx)
+// Validate checks that the options of the factory can be used together.
+func (uf *LedgerUsecaseFactory) Validate() error {
+	if uf.KeyEndorsement && len(uf.PairCollectionPIUs) == 0 {
+		return errors.New("key endorsement requires pairwise collections")
+	}
+
+	return nil
+}
+
+// loadConfig returns the consortium configuration stored on the ledger.
+// Before the ledger is initialised, only InitLedger can be invoked and it gets
+// the default configuration with the bootstrap administrators, so that all
+// peers endorse the same results regardless of their environment.
+func (uf *LedgerUsecaseFactory) loadConfig(ctx contractapi.TransactionContextInterface, mspId string, transaction string) (entities.ConsortiumConfig, error) {
+	config := entities.DefaultConsortiumConfig()
+
+	r := privatedata.NewPrivateDataRepository(ctx, mspId)
+
+	exists, err := r.ConfigExists()
+
+	if err != nil {
+		return config, err
+	}
+
+	if exists {
+		return r.GetConfig()
+	}
+
+	if transaction != "InitLedger" {
+		err := errors.New("consortium configuration is not initialised, InitLedger has to be invoked first")
+		slog.Error(
+			err.Error(),
+			"transaction", transaction,
+		)
+		return config, status.Wrap(err, status.FailedPrecondition)
+	}
+
+	config.AdminMSPs = uf.BootstrapAdminMSPs
+
+	return config, nil
+}
+
+func (uf *LedgerUsecaseFactory) New(ctx contractapi.TransactionContextInterface, transaction string) (usecase.PNRExchangeUsecase, error) {
+	err := uf.Validate()
+
+	if err != nil {
+		slog.Error(
+			"invalid usecase factory",
+			"error", err,
+		)
+		return nil, err
+	}
+
+	mspId, err := GetClientOrgId(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := uf.loadConfig(ctx, mspId, transaction)
+
+	if err != nil {
+		slog.Error(
+			"failed loading consortium configuration",
+			"error", err,
+		)
+		return nil, fmt.Errorf("failed loading consortium configuration: %w", err)
+	}
+
+	if uf.PerMSPCollections && len(config.MultiPIUMSPs) > 0 {
+		err := errors.New("collections of organisations cannot be used with MSPs hosting several PIUs")
+		slog.Error(
+			err.Error(),
+			"multiPIUMSPs", config.MultiPIUMSPs,
+		)
+		return nil, status.Wrap(err, status.FailedPrecondition)
+	}
+
+	piuId, err := GetClientPIUId(ctx, config.MultiPIUMSPs)
 
 	if err != nil {
 		return nil, err
 	}
 
-	r := privatedata.NewPrivateDataRepository(ctx, piuId)
+	clientId, err := ctx.GetClientIdentity().GetID()
+
+	if err != nil {
+		slog.Error(
+			"failed getting client's id",
+			"error", err,
+		)
+		return nil, fmt.Errorf("failed getting client's id: %v", err)
+	}
+
+	var repositoryOpts []privatedata.PrivateDataRepositoryOption
+
+	if uf.CollectionNaming != nil {
+		repositoryOpts = append(repositoryOpts, privatedata.WithCollectionNaming(uf.CollectionNaming))
+	}
+
+	if len(uf.PairCollectionPIUs) > 0 {
+		repositoryOpts = append(repositoryOpts, privatedata.WithPairCollections(privatedata.SortedPairCollectionNaming, uf.PairCollectionPIUs))
+	}
+
+	if uf.KeyEndorsement {
+		repositoryOpts = append(repositoryOpts, privatedata.WithKeyEndorsement(func(piuId string) string {
+			return entities.GetPIUMSPId(piuId, config.MultiPIUMSPs)
+		}))
+	}
+
+	r := privatedata.NewPrivateDataRepository(ctx, piuId, repositoryOpts...)
+
+	opts := []usecase.RMTUsecaseOption{
+		usecase.WithNotifier(NewEventNotifier(ctx)),
+		usecase.WithClock(NewTxClock(ctx)),
+		usecase.WithClientId(clientId),
+		usecase.WithMSPId(mspId),
+		usecase.WithTxId(ctx.GetStub().GetTxID()),
+		usecase.WithConfig(config),
+	}
 
-	u := usecase.NewRMTUsecase(piuId, r)
+	u := usecase.NewRMTUsecase(piuId, r, opts...)
 
 	return u, nil
 }
 
+// EventNotifier publishes notifications as chaincode events. Fabric keeps
+// only the last event set within a transaction.
+type EventNotifier struct {
+	ctx contractapi.TransactionContextInterface
+}
+
+func NewEventNotifier(ctx contractapi.TransactionContextInterface) *EventNotifier {
+	return &EventNotifier{ctx: ctx}
+}
+
+func (n *EventNotifier) Notify(name string, notification entities.Notification) error {
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		slog.Error(
+			"failed to marshal notification",
+			"name", name,
+			"error", err,
+		)
+		return err
+	}
+
+	return n.ctx.GetStub().SetEvent(name, payload)
+}
+
+// TxClock reports the timestamp of the current transaction, which is the
+// same on all endorsing peers.
+type TxClock struct {
+	ctx contractapi.TransactionContextInterface
+}
+
+func NewTxClock(ctx contractapi.TransactionContextInterface) *TxClock {
+	return &TxClock{ctx: ctx}
+}
+
+func (c *TxClock) Now() (time.Time, error) {
+	timestamp, err := c.ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		slog.Error(
+			"failed to get transaction timestamp",
+			"error", err,
+		)
+		return time.Time{}, err
+	}
+
+	return timestamp.AsTime(), nil
+}
+
 type SmartContract struct {
 	contractapi.Contract
 	uf UsecaseFactory
@@ -56,40 +237,100 @@ func GetClientOrgId(ctx contractapi.TransactionContextInterface) (string, error)
 	return clientOrgId, nil
 }
 
-func (s *SmartContract) SetPIUInfo(ctx contractapi.TransactionContextInterface, info string) error {
-	var input entities.PIUInfo
-	var output entities.SetPIUInfoOutput
+// PIUAttribute is the client certificate attribute naming the PIU of the
+// client within an MSP which hosts several PIUs.
+const PIUAttribute = "pnr.piu"
+
+var piuUnitRegexp = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+// GetClientPIUId returns the id of the client's PIU. An MSP hosts a single
+// PIU identified by the MSP id, unless it is listed in multiPIUMSPs. Clients
+// of such MSPs must name their PIU in the certificate attribute, and the PIU
+// is identified by both the MSP id and the attribute, so that a unit cannot
+// act as a PIU of another MSP.
+func GetClientPIUId(ctx contractapi.TransactionContextInterface, multiPIUMSPs []string) (string, error) {
+	mspId, err := GetClientOrgId(ctx)
 
-	u, err := s.uf.New(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if !slices.Contains(multiPIUMSPs, mspId) {
+		return mspId, nil
+	}
 
+	unit, found, err := ctx.GetClientIdentity().GetAttributeValue(PIUAttribute)
 	if err != nil {
 		slog.Error(
-			"failed to create usecase",
+			"failed getting client's PIU",
 			"error", err,
 		)
-		return err
+		return "", fmt.Errorf("failed getting client's PIU: %v", err)
+	}
+
+	if !found || !piuUnitRegexp.MatchString(unit) {
+		slog.Error(
+			"client's certificate does not name a valid PIU",
+			"mspId", mspId,
+			"piu", unit,
+		)
+		return "", fmt.Errorf("client's certificate does not name a valid PIU of %s", mspId)
+	}
+
+	return mspId + "_" + unit, nil
+}
+
+// newUsecase creates the usecase for given transaction after checking that
+// the client is allowed to invoke it.
+func (s *SmartContract) newUsecase(ctx contractapi.TransactionContextInterface, transaction string) (usecase.PNRExchangeUsecase, error) {
+	err := Authorize(ctx, transaction)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.uf.New(ctx, transaction)
+}
+
+// getDataKey returns the data key passed in transient data to encrypt PNR
+// data at rest, or nil if none was passed. The key must never be passed in
+// arguments, which are recorded on the ledger.
+func getDataKey(transient map[string][]byte) (*entities.DataKey, error) {
+	value, ok := transient[entities.DataKeyTransientKey]
+
+	if !ok {
+		return nil, nil
 	}
 
-	err = json.Unmarshal([]byte(info), &input)
+	var dataKey entities.DataKey
+
+	err := json.Unmarshal(value, &dataKey)
+
 	if err != nil {
 		slog.Error(
-			"failed to unmarshal input",
-			"input", info,
+			"failed to unmarshal data key",
 			"error", err,
 		)
-		return err
+		return nil, err
 	}
 
-	err = u.SetPIUInfo(context.TODO(), input, &output)
+	return &dataKey, nil
+}
+
+// SetPIUInfo updates information about the calling PIU, info is a JSON
+// Merge Patch (RFC 7396) of entities.PIUInfo.
+func (s *SmartContract) SetPIUInfo(ctx contractapi.TransactionContextInterface, info string) error {
+	_, err := s.SetPIUInfoWithVersion(ctx, info, 0)
 
 	return err
 }
 
-func (s *SmartContract) GetPIUs(ctx contractapi.TransactionContextInterface) ([]entities.PIU, error) {
-	var input entities.GetPIUsInput
-	var output []entities.PIU
+// SetPIUInfoWithVersion updates information about the calling PIU only if its
+// record has the expected version. Zero expected version skips the check.
+func (s *SmartContract) SetPIUInfoWithVersion(ctx contractapi.TransactionContextInterface, info string, expectedVersion int) (entities.SetPIUInfoOutput, error) {
+	var output entities.SetPIUInfoOutput
 
-	u, err := s.uf.New(ctx)
+	u, err := s.newUsecase(ctx, "SetPIUInfo")
 
 	if err != nil {
 		slog.Error(
@@ -99,16 +340,23 @@ func (s *SmartContract) GetPIUs(ctx contractapi.TransactionContextInterface) ([]
 		return output, err
 	}
 
-	err = u.GetPIUs(context.TODO(), input, &output)
+	patch := json.RawMessage(info)
+
+	input := entities.SetPIUInfoInput{
+		Patch:           &patch,
+		ExpectedVersion: expectedVersion,
+	}
+
+	err = u.SetPIUInfo(context.TODO(), input, &output)
 
 	return output, err
 }
 
-func (s *SmartContract) GetPNRs(ctx contractapi.TransactionContextInterface, filter string) ([]entities.PNR, error) {
-	var input entities.PNRFilter
-	var output []entities.PNR
+func (s *SmartContract) GetPIUHistory(ctx contractapi.TransactionContextInterface, request string) ([]entities.PIUHistoryEntry, error) {
+	var input entities.GetPIUHistoryInput
+	var output []entities.PIUHistoryEntry
 
-	u, err := s.uf.New(ctx)
+	u, err := s.newUsecase(ctx, "GetPIUHistory")
 
 	if err != nil {
 		slog.Error(
@@ -118,26 +366,26 @@ func (s *SmartContract) GetPNRs(ctx contractapi.TransactionContextInterface, fil
 		return output, err
 	}
 
-	err = json.Unmarshal([]byte(filter), &input)
+	err = json.Unmarshal([]byte(request), &input)
 	if err != nil {
 		slog.Error(
 			"failed to unmarshal input",
-			"input", filter,
+			"input", request,
 			"error", err,
 		)
 		return output, err
 	}
 
-	err = u.GetPNRs(context.TODO(), input, &output)
+	err = u.GetPIUHistory(context.TODO(), input, &output)
 
 	return output, err
 }
 
-func (s *SmartContract) NewPNRRequest(ctx contractapi.TransactionContextInterface, request string) (entities.NewPNRRequestOutput, error) {
-	var input entities.NewPNRRequestInput
-	var output entities.NewPNRRequestOutput
+func (s *SmartContract) GetPIUs(ctx contractapi.TransactionContextInterface) ([]entities.PIU, error) {
+	var input entities.GetPIUsInput
+	var output []entities.PIU
 
-	u, err := s.uf.New(ctx)
+	u, err := s.newUsecase(ctx, "GetPIUs")
 
 	if err != nil {
 		slog.Error(
@@ -147,46 +395,47 @@ func (s *SmartContract) NewPNRRequest(ctx contractapi.TransactionContextInterfac
 		return output, err
 	}
 
-	err = json.Unmarshal([]byte(request), &input)
+	err = u.GetPIUs(context.TODO(), input, &output)
+
+	return output, err
+}
+
+// GetAllPIUs lists registered PIUs like GetPIUs, request is a JSON document of
+// entities.GetPIUsInput which can include retired PIUs.
+func (s *SmartContract) GetAllPIUs(ctx contractapi.TransactionContextInterface, request string) ([]entities.PIU, error) {
+	var input entities.GetPIUsInput
+	var output []entities.PIU
+
+	u, err := s.newUsecase(ctx, "GetAllPIUs")
+
 	if err != nil {
 		slog.Error(
-			"failed to unmarshal input",
-			"input", request,
+			"failed to create usecase",
 			"error", err,
 		)
 		return output, err
 	}
 
-	transient, err := ctx.GetStub().GetTransient()
+	err = json.Unmarshal([]byte(request), &input)
 	if err != nil {
 		slog.Error(
-			"failed to get transient data",
+			"failed to unmarshal input",
+			"input", request,
 			"error", err,
 		)
 		return output, err
 	}
 
-	requestData, ok := transient[entities.RequestDataTransientKey]
-	if !ok {
-		slog.Error(
-			"missing transient data for key",
-			"key", entities.RequestDataTransientKey,
-		)
-		return output, err
-	}
-
-	input.RequestData = (*json.RawMessage)(&requestData)
-
-	err = u.NewPNRRequest(context.TODO(), input, &output)
+	err = u.GetPIUs(context.TODO(), input, &output)
 
 	return output, err
 }
 
-func (s *SmartContract) SubmitPNRResponseAck(ctx contractapi.TransactionContextInterface, response string) error {
-	var input entities.SubmitPNRResponseInput
-	var output entities.SubmitPNRResponseOutput
+func (s *SmartContract) ApprovePIU(ctx contractapi.TransactionContextInterface, change string) error {
+	var input entities.ChangePIUStatusInput
+	var output entities.ChangePIUStatusOutput
 
-	u, err := s.uf.New(ctx)
+	u, err := s.newUsecase(ctx, "ApprovePIU")
 
 	if err != nil {
 		slog.Error(
@@ -196,47 +445,55 @@ func (s *SmartContract) SubmitPNRResponseAck(ctx contractapi.TransactionContextI
 		return err
 	}
 
-	err = json.Unmarshal([]byte(response), &input)
+	err = json.Unmarshal([]byte(change), &input)
 	if err != nil {
 		slog.Error(
 			"failed to unmarshal input",
-			"input", response,
+			"input", change,
 			"error", err,
 		)
 		return err
 	}
 
-	transient, err := ctx.GetStub().GetTransient()
+	err = u.ApprovePIU(context.TODO(), input, &output)
+
+	return err
+}
+
+func (s *SmartContract) SuspendPIU(ctx contractapi.TransactionContextInterface, change string) error {
+	var input entities.ChangePIUStatusInput
+	var output entities.ChangePIUStatusOutput
+
+	u, err := s.newUsecase(ctx, "SuspendPIU")
+
 	if err != nil {
 		slog.Error(
-			"failed to get transient data",
+			"failed to create usecase",
 			"error", err,
 		)
 		return err
 	}
 
-	responseData, ok := transient[entities.ResponseDataTransientKey]
-	if !ok {
+	err = json.Unmarshal([]byte(change), &input)
+	if err != nil {
 		slog.Error(
-			"missing transient data for key",
-			"key", entities.ResponseDataTransientKey,
-			"data", transient,
+			"failed to unmarshal input",
+			"input", change,
+			"error", err,
 		)
 		return err
 	}
 
-	input.ResponseData = (*json.RawMessage)(&responseData)
-
-	err = u.SubmitPNRResponseAck(context.TODO(), input, &output)
+	err = u.SuspendPIU(context.TODO(), input, &output)
 
 	return err
 }
 
-func (s *SmartContract) SubmitPNRResponseNack(ctx contractapi.TransactionContextInterface, response string) error {
-	var input entities.SubmitPNRResponseInput
-	var output entities.SubmitPNRResponseOutput
+func (s *SmartContract) RetirePIU(ctx contractapi.TransactionContextInterface, change string) error {
+	var input entities.ChangePIUStatusInput
+	var output entities.ChangePIUStatusOutput
 
-	u, err := s.uf.New(ctx)
+	u, err := s.newUsecase(ctx, "RetirePIU")
 
 	if err != nil {
 		slog.Error(
@@ -246,46 +503,55 @@ func (s *SmartContract) SubmitPNRResponseNack(ctx contractapi.TransactionContext
 		return err
 	}
 
-	err = json.Unmarshal([]byte(response), &input)
+	err = json.Unmarshal([]byte(change), &input)
 	if err != nil {
 		slog.Error(
 			"failed to unmarshal input",
-			"input", response,
+			"input", change,
 			"error", err,
 		)
 		return err
 	}
 
-	transient, err := ctx.GetStub().GetTransient()
+	err = u.RetirePIU(context.TODO(), input, &output)
+
+	return err
+}
+
+func (s *SmartContract) ProposeAgreement(ctx contractapi.TransactionContextInterface, proposal string) (entities.ProposeAgreementOutput, error) {
+	var input entities.ProposeAgreementInput
+	var output entities.ProposeAgreementOutput
+
+	u, err := s.newUsecase(ctx, "ProposeAgreement")
+
 	if err != nil {
 		slog.Error(
-			"failed to get transient data",
+			"failed to create usecase",
 			"error", err,
 		)
-		return err
+		return output, err
 	}
 
-	responseData, ok := transient[entities.ResponseDataTransientKey]
-	if !ok {
+	err = json.Unmarshal([]byte(proposal), &input)
+	if err != nil {
 		slog.Error(
-			"missing transient data for key",
-			"key", entities.ResponseDataTransientKey,
+			"failed to unmarshal input",
+			"input", proposal,
+			"error", err,
 		)
-		return err
+		return output, err
 	}
 
-	input.ResponseData = (*json.RawMessage)(&responseData)
-
-	err = u.SubmitPNRResponseNack(context.TODO(), input, &output)
+	err = u.ProposeAgreement(context.TODO(), input, &output)
 
-	return err
+	return output, err
 }
 
-func (s *SmartContract) ConfirmPNR(ctx contractapi.TransactionContextInterface, confirmation string) error {
-	var input entities.ConfirmPNRInput
-	var output entities.ConfirmPNROutput
+func (s *SmartContract) SignAgreement(ctx contractapi.TransactionContextInterface, signature string) error {
+	var input entities.SignAgreementInput
+	var output entities.SignAgreementOutput
 
-	u, err := s.uf.New(ctx)
+	u, err := s.newUsecase(ctx, "SignAgreement")
 
 	if err != nil {
 		slog.Error(
@@ -295,26 +561,26 @@ func (s *SmartContract) ConfirmPNR(ctx contractapi.TransactionContextInterface,
 		return err
 	}
 
-	err = json.Unmarshal([]byte(confirmation), &input)
+	err = json.Unmarshal([]byte(signature), &input)
 	if err != nil {
 		slog.Error(
 			"failed to unmarshal input",
-			"input", confirmation,
+			"input", signature,
 			"error", err,
 		)
 		return err
 	}
 
-	err = u.ConfirmPNR(context.TODO(), input, &output)
+	err = u.SignAgreement(context.TODO(), input, &output)
 
 	return err
 }
 
-func (s *SmartContract) TerminatePNRRequest(ctx contractapi.TransactionContextInterface, purge string) error {
-	var input entities.TerminatePNRRequestInput
-	var output entities.TerminatePNRRequestOutput
+func (s *SmartContract) RevokeAgreement(ctx contractapi.TransactionContextInterface, revocation string) error {
+	var input entities.RevokeAgreementInput
+	var output entities.RevokeAgreementOutput
 
-	u, err := s.uf.New(ctx)
+	u, err := s.newUsecase(ctx, "RevokeAgreement")
 
 	if err != nil {
 		slog.Error(
@@ -324,17 +590,869 @@ func (s *SmartContract) TerminatePNRRequest(ctx contractapi.TransactionContextIn
 		return err
 	}
 
-	err = json.Unmarshal([]byte(purge), &input)
+	err = json.Unmarshal([]byte(revocation), &input)
 	if err != nil {
 		slog.Error(
 			"failed to unmarshal input",
-			"input", purge,
+			"input", revocation,
 			"error", err,
 		)
 		return err
 	}
 
-	err = u.TerminatePNRRequest(context.TODO(), input, &output)
+	err = u.RevokeAgreement(context.TODO(), input, &output)
 
 	return err
 }
+
+func (s *SmartContract) GetAgreements(ctx contractapi.TransactionContextInterface) ([]entities.Agreement, error) {
+	var input entities.GetAgreementsInput
+	var output []entities.Agreement
+
+	u, err := s.newUsecase(ctx, "GetAgreements")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return output, err
+	}
+
+	err = u.GetAgreements(context.TODO(), input, &output)
+
+	return output, err
+}
+
+func (s *SmartContract) GetQuotaStatus(ctx contractapi.TransactionContextInterface) (entities.QuotaStatus, error) {
+	var input entities.GetQuotaStatusInput
+	var output entities.QuotaStatus
+
+	u, err := s.newUsecase(ctx, "GetQuotaStatus")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return output, err
+	}
+
+	err = u.GetQuotaStatus(context.TODO(), input, &output)
+
+	return output, err
+}
+
+// InitLedger stores the first consortium configuration on the ledger, config
+// is a JSON document of entities.ConsortiumConfig.
+func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface, config string) (entities.UpdateConfigOutput, error) {
+	var output entities.UpdateConfigOutput
+
+	u, err := s.newUsecase(ctx, "InitLedger")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return output, err
+	}
+
+	document := json.RawMessage(config)
+
+	input := entities.UpdateConfigInput{
+		Config: &document,
+	}
+
+	err = u.InitLedger(context.TODO(), input, &output)
+
+	return output, err
+}
+
+// UpdateConfig replaces the consortium configuration only if the stored one
+// has the expected version. Zero expected version skips the check.
+func (s *SmartContract) UpdateConfig(ctx contractapi.TransactionContextInterface, config string, expectedVersion int) (entities.UpdateConfigOutput, error) {
+	var output entities.UpdateConfigOutput
+
+	u, err := s.newUsecase(ctx, "UpdateConfig")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return output, err
+	}
+
+	document := json.RawMessage(config)
+
+	input := entities.UpdateConfigInput{
+		Config:          &document,
+		ExpectedVersion: expectedVersion,
+	}
+
+	err = u.UpdateConfig(context.TODO(), input, &output)
+
+	return output, err
+}
+
+func (s *SmartContract) GetConfig(ctx contractapi.TransactionContextInterface) (entities.ConsortiumConfig, error) {
+	var input entities.GetConfigInput
+	var output entities.ConsortiumConfig
+
+	u, err := s.newUsecase(ctx, "GetConfig")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return output, err
+	}
+
+	err = u.GetConfig(context.TODO(), input, &output)
+
+	return output, err
+}
+
+// MigrateData rewrites a batch of at most pageSize records stored with an
+// older model version, starting at the bookmark returned by the previous
+// batch. Every PIU has to migrate records of its own collections.
+func (s *SmartContract) MigrateData(ctx contractapi.TransactionContextInterface, bookmark string, pageSize int) (entities.MigrateDataOutput, error) {
+	var output entities.MigrateDataOutput
+
+	u, err := s.newUsecase(ctx, "MigrateData")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return output, err
+	}
+
+	input := entities.MigrateDataInput{
+		Bookmark: bookmark,
+		PageSize: pageSize,
+	}
+
+	err = u.MigrateData(context.TODO(), input, &output)
+
+	return output, err
+}
+
+// BackupPrivateData returns an archive of PNR records kept in the private
+// data collection of the calling PIU, which has to sign it before restoring.
+func (s *SmartContract) BackupPrivateData(ctx contractapi.TransactionContextInterface) (entities.PrivateDataArchive, error) {
+	var input entities.BackupPrivateDataInput
+	var output entities.PrivateDataArchive
+
+	u, err := s.newUsecase(ctx, "BackupPrivateData")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return output, err
+	}
+
+	err = u.BackupPrivateData(context.TODO(), input, &output)
+
+	return output, err
+}
+
+// RestorePrivateData restores lost records of the calling PIU from a signed
+// archive passed in transient data, so that it is not stored on the ledger.
+func (s *SmartContract) RestorePrivateData(ctx contractapi.TransactionContextInterface) (entities.RestorePrivateDataOutput, error) {
+	var input entities.RestorePrivateDataInput
+	var output entities.RestorePrivateDataOutput
+
+	u, err := s.newUsecase(ctx, "RestorePrivateData")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return output, err
+	}
+
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		slog.Error(
+			"failed to get transient data",
+			"error", err,
+		)
+		return output, err
+	}
+
+	archive, ok := transient[entities.ArchiveTransientKey]
+	if !ok {
+		slog.Error(
+			"missing transient data for key",
+			"key", entities.ArchiveTransientKey,
+		)
+		return output, fmt.Errorf("missing transient data for key %s", entities.ArchiveTransientKey)
+	}
+
+	input.Archive = (*json.RawMessage)(&archive)
+
+	err = u.RestorePrivateData(context.TODO(), input, &output)
+
+	return output, err
+}
+
+// ReconcilePrivateData restores records the calling PIU shares with another
+// PIU into the collection of the other one.
+func (s *SmartContract) ReconcilePrivateData(ctx contractapi.TransactionContextInterface, request string) (entities.RestorePrivateDataOutput, error) {
+	var input entities.ReconcilePrivateDataInput
+	var output entities.RestorePrivateDataOutput
+
+	u, err := s.newUsecase(ctx, "ReconcilePrivateData")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return output, err
+	}
+
+	err = json.Unmarshal([]byte(request), &input)
+	if err != nil {
+		slog.Error(
+			"failed to unmarshal input",
+			"input", request,
+			"error", err,
+		)
+		return output, err
+	}
+
+	err = u.ReconcilePrivateData(context.TODO(), input, &output)
+
+	return output, err
+}
+
+func (s *SmartContract) GetPNRs(ctx contractapi.TransactionContextInterface, filter string) ([]entities.PNR, error) {
+	var input entities.PNRFilter
+	var output []entities.PNR
+
+	u, err := s.newUsecase(ctx, "GetPNRs")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return output, err
+	}
+
+	err = json.Unmarshal([]byte(filter), &input)
+	if err != nil {
+		slog.Error(
+			"failed to unmarshal input",
+			"input", filter,
+			"error", err,
+		)
+		return output, err
+	}
+
+	err = u.GetPNRs(context.TODO(), input, &output)
+
+	return output, err
+}
+
+// ExportPNRMetadata exports a page of metadata of PNR requests, request is a
+// JSON document of entities.ExportPNRMetadataInput.
+func (s *SmartContract) ExportPNRMetadata(ctx contractapi.TransactionContextInterface, request string) (entities.ExportPNRMetadataOutput, error) {
+	var input entities.ExportPNRMetadataInput
+	var output entities.ExportPNRMetadataOutput
+
+	u, err := s.newUsecase(ctx, "ExportPNRMetadata")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return output, err
+	}
+
+	err = json.Unmarshal([]byte(request), &input)
+	if err != nil {
+		slog.Error(
+			"failed to unmarshal input",
+			"input", request,
+			"error", err,
+		)
+		return output, err
+	}
+
+	err = u.ExportPNRMetadata(context.TODO(), input, &output)
+
+	return output, err
+}
+
+func (s *SmartContract) NewPNRRequest(ctx contractapi.TransactionContextInterface, request string) (entities.NewPNRRequestOutput, error) {
+	var input entities.NewPNRRequestInput
+	var output entities.NewPNRRequestOutput
+
+	u, err := s.newUsecase(ctx, "NewPNRRequest")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return output, err
+	}
+
+	err = json.Unmarshal([]byte(request), &input)
+	if err != nil {
+		slog.Error(
+			"failed to unmarshal input",
+			"input", request,
+			"error", err,
+		)
+		return output, err
+	}
+
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		slog.Error(
+			"failed to get transient data",
+			"error", err,
+		)
+		return output, err
+	}
+
+	requestData, ok := transient[entities.RequestDataTransientKey]
+	if !ok {
+		slog.Error(
+			"missing transient data for key",
+			"key", entities.RequestDataTransientKey,
+		)
+		return output, err
+	}
+
+	input.RequestData = (*json.RawMessage)(&requestData)
+
+	input.DataKey, err = getDataKey(transient)
+	if err != nil {
+		return output, err
+	}
+
+	err = u.NewPNRRequest(context.TODO(), input, &output)
+
+	return output, err
+}
+
+func (s *SmartContract) SubmitPNRResponseAck(ctx contractapi.TransactionContextInterface, response string) error {
+	var input entities.SubmitPNRResponseInput
+	var output entities.SubmitPNRResponseOutput
+
+	u, err := s.newUsecase(ctx, "SubmitPNRResponseAck")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return err
+	}
+
+	err = json.Unmarshal([]byte(response), &input)
+	if err != nil {
+		slog.Error(
+			"failed to unmarshal input",
+			"input", response,
+			"error", err,
+		)
+		return err
+	}
+
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		slog.Error(
+			"failed to get transient data",
+			"error", err,
+		)
+		return err
+	}
+
+	responseData, ok := transient[entities.ResponseDataTransientKey]
+	if !ok {
+		slog.Error(
+			"missing transient data for key",
+			"key", entities.ResponseDataTransientKey,
+			"data", transient,
+		)
+		return err
+	}
+
+	input.ResponseData = (*json.RawMessage)(&responseData)
+
+	input.DataKey, err = getDataKey(transient)
+	if err != nil {
+		return err
+	}
+
+	err = u.SubmitPNRResponseAck(context.TODO(), input, &output)
+
+	return err
+}
+
+func (s *SmartContract) SubmitPNRResponseNack(ctx contractapi.TransactionContextInterface, response string) error {
+	var input entities.SubmitPNRResponseInput
+	var output entities.SubmitPNRResponseOutput
+
+	u, err := s.newUsecase(ctx, "SubmitPNRResponseNack")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return err
+	}
+
+	err = json.Unmarshal([]byte(response), &input)
+	if err != nil {
+		slog.Error(
+			"failed to unmarshal input",
+			"input", response,
+			"error", err,
+		)
+		return err
+	}
+
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		slog.Error(
+			"failed to get transient data",
+			"error", err,
+		)
+		return err
+	}
+
+	responseData, ok := transient[entities.ResponseDataTransientKey]
+	if !ok {
+		slog.Error(
+			"missing transient data for key",
+			"key", entities.ResponseDataTransientKey,
+		)
+		return err
+	}
+
+	input.ResponseData = (*json.RawMessage)(&responseData)
+
+	input.DataKey, err = getDataKey(transient)
+	if err != nil {
+		return err
+	}
+
+	err = u.SubmitPNRResponseNack(context.TODO(), input, &output)
+
+	return err
+}
+
+func (s *SmartContract) ApprovePNRRequest(ctx contractapi.TransactionContextInterface, approval string) error {
+	var input entities.ApprovePNRDraftInput
+	var output entities.ApprovePNRDraftOutput
+
+	u, err := s.newUsecase(ctx, "ApprovePNRRequest")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return err
+	}
+
+	err = json.Unmarshal([]byte(approval), &input)
+	if err != nil {
+		slog.Error(
+			"failed to unmarshal input",
+			"input", approval,
+			"error", err,
+		)
+		return err
+	}
+
+	err = u.ApprovePNRRequest(context.TODO(), input, &output)
+
+	return err
+}
+
+func (s *SmartContract) ApprovePNRResponse(ctx contractapi.TransactionContextInterface, approval string) error {
+	var input entities.ApprovePNRDraftInput
+	var output entities.ApprovePNRDraftOutput
+
+	u, err := s.newUsecase(ctx, "ApprovePNRResponse")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return err
+	}
+
+	err = json.Unmarshal([]byte(approval), &input)
+	if err != nil {
+		slog.Error(
+			"failed to unmarshal input",
+			"input", approval,
+			"error", err,
+		)
+		return err
+	}
+
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		slog.Error(
+			"failed to get transient data",
+			"error", err,
+		)
+		return err
+	}
+
+	input.DataKey, err = getDataKey(transient)
+	if err != nil {
+		return err
+	}
+
+	err = u.ApprovePNRResponse(context.TODO(), input, &output)
+
+	return err
+}
+
+func (s *SmartContract) ConfirmPNR(ctx contractapi.TransactionContextInterface, confirmation string) error {
+	var input entities.ConfirmPNRInput
+	var output entities.ConfirmPNROutput
+
+	u, err := s.newUsecase(ctx, "ConfirmPNR")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return err
+	}
+
+	err = json.Unmarshal([]byte(confirmation), &input)
+	if err != nil {
+		slog.Error(
+			"failed to unmarshal input",
+			"input", confirmation,
+			"error", err,
+		)
+		return err
+	}
+
+	err = u.ConfirmPNR(context.TODO(), input, &output)
+
+	return err
+}
+
+func (s *SmartContract) TerminatePNRRequest(ctx contractapi.TransactionContextInterface, purge string) error {
+	var input entities.TerminatePNRRequestInput
+	var output entities.TerminatePNRRequestOutput
+
+	u, err := s.newUsecase(ctx, "TerminatePNRRequest")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return err
+	}
+
+	err = json.Unmarshal([]byte(purge), &input)
+	if err != nil {
+		slog.Error(
+			"failed to unmarshal input",
+			"input", purge,
+			"error", err,
+		)
+		return err
+	}
+
+	err = u.TerminatePNRRequest(context.TODO(), input, &output)
+
+	return err
+}
+
+func (s *SmartContract) ForwardPNRRequest(ctx contractapi.TransactionContextInterface, forward string) (entities.ForwardPNRRequestOutput, error) {
+	var input entities.ForwardPNRRequestInput
+	var output entities.ForwardPNRRequestOutput
+
+	u, err := s.newUsecase(ctx, "ForwardPNRRequest")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return output, err
+	}
+
+	err = json.Unmarshal([]byte(forward), &input)
+	if err != nil {
+		slog.Error(
+			"failed to unmarshal input",
+			"input", forward,
+			"error", err,
+		)
+		return output, err
+	}
+
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		slog.Error(
+			"failed to get transient data",
+			"error", err,
+		)
+		return output, err
+	}
+
+	if requestData, ok := transient[entities.RequestDataTransientKey]; ok {
+		input.RequestData = (*json.RawMessage)(&requestData)
+	}
+
+	input.DataKey, err = getDataKey(transient)
+	if err != nil {
+		return output, err
+	}
+
+	err = u.ForwardPNRRequest(context.TODO(), input, &output)
+
+	return output, err
+}
+
+func (s *SmartContract) GetPNRThread(ctx contractapi.TransactionContextInterface, thread string) ([]entities.PNR, error) {
+	var input entities.GetPNRThreadInput
+	var output []entities.PNR
+
+	u, err := s.newUsecase(ctx, "GetPNRThread")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return output, err
+	}
+
+	err = json.Unmarshal([]byte(thread), &input)
+	if err != nil {
+		slog.Error(
+			"failed to unmarshal input",
+			"input", thread,
+			"error", err,
+		)
+		return output, err
+	}
+
+	err = u.GetPNRThread(context.TODO(), input, &output)
+
+	return output, err
+}
+
+func (s *SmartContract) getClarificationInput(ctx contractapi.TransactionContextInterface, clarification string) (entities.ClarificationInput, error) {
+	var input entities.ClarificationInput
+
+	err := json.Unmarshal([]byte(clarification), &input)
+	if err != nil {
+		slog.Error(
+			"failed to unmarshal input",
+			"input", clarification,
+			"error", err,
+		)
+		return input, err
+	}
+
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		slog.Error(
+			"failed to get transient data",
+			"error", err,
+		)
+		return input, err
+	}
+
+	message, ok := transient[entities.ClarificationDataTransientKey]
+	if !ok {
+		slog.Error(
+			"missing transient data for key",
+			"key", entities.ClarificationDataTransientKey,
+		)
+		return input, fmt.Errorf("missing transient data for key %s", entities.ClarificationDataTransientKey)
+	}
+
+	input.Message = (*json.RawMessage)(&message)
+
+	return input, nil
+}
+
+func (s *SmartContract) RequestClarification(ctx contractapi.TransactionContextInterface, clarification string) error {
+	var output entities.ClarificationOutput
+
+	u, err := s.newUsecase(ctx, "RequestClarification")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return err
+	}
+
+	input, err := s.getClarificationInput(ctx, clarification)
+	if err != nil {
+		return err
+	}
+
+	err = u.RequestClarification(context.TODO(), input, &output)
+
+	return err
+}
+
+func (s *SmartContract) ProvideClarification(ctx contractapi.TransactionContextInterface, clarification string) error {
+	var output entities.ClarificationOutput
+
+	u, err := s.newUsecase(ctx, "ProvideClarification")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return err
+	}
+
+	input, err := s.getClarificationInput(ctx, clarification)
+	if err != nil {
+		return err
+	}
+
+	err = u.ProvideClarification(context.TODO(), input, &output)
+
+	return err
+}
+
+func (s *SmartContract) GetPNRClarifications(ctx contractapi.TransactionContextInterface, request string) ([]entities.ClarificationMessage, error) {
+	var input entities.GetPNRClarificationsInput
+	var output []entities.ClarificationMessage
+
+	u, err := s.newUsecase(ctx, "GetPNRClarifications")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return output, err
+	}
+
+	err = json.Unmarshal([]byte(request), &input)
+	if err != nil {
+		slog.Error(
+			"failed to unmarshal input",
+			"input", request,
+			"error", err,
+		)
+		return output, err
+	}
+
+	err = u.GetPNRClarifications(context.TODO(), input, &output)
+
+	return output, err
+}
+
+// VerifyPNRConsistency compares copies of records of the PNR request kept by
+// both its parties.
+func (s *SmartContract) VerifyPNRConsistency(ctx contractapi.TransactionContextInterface, request string) (entities.PNRConsistency, error) {
+	var input entities.VerifyPNRConsistencyInput
+	var output entities.PNRConsistency
+
+	u, err := s.newUsecase(ctx, "VerifyPNRConsistency")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return output, err
+	}
+
+	err = json.Unmarshal([]byte(request), &input)
+	if err != nil {
+		slog.Error(
+			"failed to unmarshal input",
+			"input", request,
+			"error", err,
+		)
+		return output, err
+	}
+
+	err = u.VerifyPNRConsistency(context.TODO(), input, &output)
+
+	return output, err
+}
+
+// VerifyPNRResponseSignature checks the signature of the PNR response against
+// the signing certificate registered by the responding PIU.
+func (s *SmartContract) VerifyPNRResponseSignature(ctx contractapi.TransactionContextInterface, request string) (entities.PNRResponseSignature, error) {
+	var input entities.VerifyPNRResponseSignatureInput
+	var output entities.PNRResponseSignature
+
+	u, err := s.newUsecase(ctx, "VerifyPNRResponseSignature")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return output, err
+	}
+
+	err = json.Unmarshal([]byte(request), &input)
+	if err != nil {
+		slog.Error(
+			"failed to unmarshal input",
+			"input", request,
+			"error", err,
+		)
+		return output, err
+	}
+
+	err = u.VerifyPNRResponseSignature(context.TODO(), input, &output)
+
+	return output, err
+}
+
+// VerifyAllPNRConsistency lists PNR requests of the calling PIU whose copies
+// differ.
+func (s *SmartContract) VerifyAllPNRConsistency(ctx contractapi.TransactionContextInterface) ([]entities.PNRConsistency, error) {
+	var input entities.VerifyAllPNRConsistencyInput
+	var output []entities.PNRConsistency
+
+	u, err := s.newUsecase(ctx, "VerifyAllPNRConsistency")
+
+	if err != nil {
+		slog.Error(
+			"failed to create usecase",
+			"error", err,
+		)
+		return output, err
+	}
+
+	err = u.VerifyAllPNRConsistency(context.TODO(), input, &output)
+
+	return output, err
+}
@@ -5,10 +5,12 @@ import (
 	"testing"
 
 	"github.com/google/uuid"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	"github.com/swaggest/usecase/status"
 	"github.com/nesfit/shimtest/pkg/shimtest"
 
 	"github.com/nesfit/tenacity-chaincode/pkg/contract"
@@ -21,6 +23,9 @@ import (
 
 var thisPIUId = testdata.PIUs[0].Id
 var peerPIUId = testdata.PIUs[1].Id
+var adminMSPId = "consortiumAdminMSP"
+var agreementId = "agreement"
+var purpose = "terrorism"
 
 var requestData json.RawMessage = lo.Must(json.Marshal("test request data"))
 var responseData json.RawMessage = lo.Must(json.Marshal("test request data"))
@@ -29,10 +34,15 @@ type testUsecaseFactory struct {
 	r repository.Repository
 }
 
-func (uf *testUsecaseFactory) New(ctx contractapi.TransactionContextInterface) (usecase.PNRExchangeUsecase, error) {
+func (uf *testUsecaseFactory) New(ctx contractapi.TransactionContextInterface, transaction string) (usecase.PNRExchangeUsecase, error) {
 	piuId, _ := contract.GetClientOrgId(ctx)
 
-	u := usecase.NewRMTUsecase(piuId, uf.r)
+	config := entities.DefaultConsortiumConfig()
+	config.AdminMSPs = []string{adminMSPId}
+
+	clientId, _ := ctx.GetClientIdentity().GetID()
+
+	u := usecase.NewRMTUsecase(piuId, uf.r, usecase.WithConfig(config), usecase.WithClientId(clientId))
 
 	return u, nil
 }
@@ -48,17 +58,57 @@ func setTransient(ctx contractapi.TransactionContextInterface, data map[string][
 	return err
 }
 
+// roleClientIdentity adds the role and PIU attributes to the mock client
+// identity.
+type roleClientIdentity struct {
+	*shimtest.MockClientIdentity
+	roles string
+	piu   string
+}
+
+func (ci *roleClientIdentity) GetAttributeValue(attrName string) (string, bool, error) {
+	value := map[string]string{
+		contract.RoleAttribute: ci.roles,
+		contract.PIUAttribute:  ci.piu,
+	}[attrName]
+
+	return value, value != "", nil
+}
+
+type roleTransactionContext struct {
+	*shimtest.MockTransactionContext
+	identity *roleClientIdentity
+}
+
+func (ctx *roleTransactionContext) GetClientIdentity() cid.ClientIdentity {
+	return ctx.identity
+}
+
+func newRoleTransactionContext(mspID string, roles string) *roleTransactionContext {
+	return &roleTransactionContext{
+		MockTransactionContext: shimtest.NewMockTransactionContext("tenacity", "org1", mspID),
+		identity: &roleClientIdentity{
+			MockClientIdentity: shimtest.NewMockClientIdentity("org1", mspID),
+			roles:              roles,
+		},
+	}
+}
+
+const allRoles = "analyst,supervisor,dpo,admin"
+
 type ContractTestSuite struct {
 	suite.Suite
 	c              contract.SmartContract
-	thisPIUContext *shimtest.MockTransactionContext
-	peerPIUContext *shimtest.MockTransactionContext
+	thisPIUContext *roleTransactionContext
+	peerPIUContext *roleTransactionContext
+	adminContext   *roleTransactionContext
 }
 
 func (suite *ContractTestSuite) SetupTest() {
 	suite.c = contract.NewSmartContract(&testUsecaseFactory{r: inmemory.NewInMemoryRepository()})
-	suite.thisPIUContext = shimtest.NewMockTransactionContext("tenacity", "org1", thisPIUId)
-	suite.peerPIUContext = shimtest.NewMockTransactionContext("tenacity", "org1", peerPIUId)
+	suite.thisPIUContext = newRoleTransactionContext(thisPIUId, allRoles)
+	suite.peerPIUContext = newRoleTransactionContext(peerPIUId, allRoles)
+	suite.adminContext = newRoleTransactionContext(adminMSPId, "admin")
 }
 
 func (suite *ContractTestSuite) initPIUPair() {
@@ -79,6 +129,21 @@ func (suite *ContractTestSuite) initPIUPair() {
 
 	infoJSON, _ = json.Marshal(peerPIUInfo)
 	suite.c.SetPIUInfo(suite.peerPIUContext, string(infoJSON))
+
+	for _, id := range []string{thisPIUId, peerPIUId} {
+		suite.c.ApprovePIU(suite.adminContext, string(lo.Must(json.Marshal(entities.ChangePIUStatusInput{Id: id}))))
+	}
+
+	proposal := entities.ProposeAgreementInput{
+		Id:                agreementId,
+		CounterpartyPIU:   peerPIUId,
+		Purposes:          []string{purpose},
+		ValidFrom:         testdata.EarliestTimestamp,
+		ProposalTimestamp: testdata.EarliestTimestamp,
+	}
+
+	suite.c.ProposeAgreement(suite.thisPIUContext, string(lo.Must(json.Marshal(proposal))))
+	suite.c.SignAgreement(suite.peerPIUContext, string(lo.Must(json.Marshal(entities.SignAgreementInput{Id: agreementId}))))
 }
 
 func TestContractSuite(t *testing.T) {
@@ -132,6 +197,84 @@ func (suite *ContractTestSuite) TestGetPIUs() {
 	assert.ElementsMatch(expected, actual)
 }
 
+func (suite *ContractTestSuite) TestPIUGovernance() {
+	assert := assert.New(suite.T())
+
+	info := entities.PIUInfo{
+		Name:       "foo",
+		AdminEmail: "hello@piu.org",
+	}
+
+	suite.c.SetPIUInfo(suite.thisPIUContext, string(lo.Must(json.Marshal(info))))
+
+	change := string(lo.Must(json.Marshal(entities.ChangePIUStatusInput{Id: thisPIUId})))
+
+	err := suite.c.ApprovePIU(suite.thisPIUContext, change)
+	assert.Error(err)
+
+	err = suite.c.ApprovePIU(suite.adminContext, change)
+	assert.NoError(err)
+
+	err = suite.c.SuspendPIU(suite.adminContext, change)
+	assert.NoError(err)
+
+	actual, _ := suite.c.GetPIUs(suite.thisPIUContext)
+	assert.Len(actual, 1)
+	assert.Equal(entities.PIUStatusSuspended, actual[0].Status)
+
+	err = suite.c.RetirePIU(suite.adminContext, change)
+	assert.NoError(err)
+
+	actual, _ = suite.c.GetPIUs(suite.thisPIUContext)
+	assert.Empty(actual)
+
+	actual, _ = suite.c.GetAllPIUs(suite.thisPIUContext, `{"includeRetired": true}`)
+	assert.Len(actual, 1)
+	assert.Equal(entities.PIUStatusRetired, actual[0].Status)
+}
+
+func (suite *ContractTestSuite) TestConfig() {
+	assert := assert.New(suite.T())
+
+	config := `{"adminMSPs": ["` + adminMSPId + `"], "payloadProfiles": ["PNRGOV/21.1"]}`
+
+	_, err := suite.c.InitLedger(suite.thisPIUContext, config)
+	assert.Error(err)
+
+	output, err := suite.c.InitLedger(suite.adminContext, config)
+	assert.NoError(err)
+	assert.Equal(1, output.Version)
+
+	output, err = suite.c.UpdateConfig(suite.adminContext, config, 2)
+	assert.Error(err)
+
+	output, err = suite.c.UpdateConfig(suite.adminContext, config, 1)
+	assert.NoError(err)
+	assert.Equal(2, output.Version)
+
+	actual, err := suite.c.GetConfig(suite.thisPIUContext)
+	assert.NoError(err)
+	assert.Equal(2, actual.Version)
+	assert.Equal([]string{"PNRGOV/21.1"}, actual.PayloadProfiles)
+}
+
+func (suite *ContractTestSuite) TestAgreement() {
+	assert := assert.New(suite.T())
+
+	suite.initPIUPair()
+
+	actual, err := suite.c.GetAgreements(suite.peerPIUContext)
+	assert.NoError(err)
+	assert.Len(actual, 1)
+	assert.Equal(entities.AgreementStateActive, actual[0].State)
+
+	err = suite.c.RevokeAgreement(suite.peerPIUContext, string(lo.Must(json.Marshal(entities.RevokeAgreementInput{Id: agreementId}))))
+	assert.NoError(err)
+
+	actual, _ = suite.c.GetAgreements(suite.thisPIUContext)
+	assert.Equal(entities.AgreementStateRevoked, actual[0].State)
+}
+
 func (suite *ContractTestSuite) TestNewPNRRequest() {
 	assert := assert.New(suite.T())
 
@@ -140,6 +283,7 @@ func (suite *ContractTestSuite) TestNewPNRRequest() {
 	request := entities.NewPNRRequestInput{
 		RespondingPIU:    peerPIUId,
 		RequestTimestamp: testdata.MiddleTimestamp,
+		Purpose:          purpose,
 	}
 
 	transient := map[string][]byte{
@@ -160,6 +304,10 @@ func (suite *ContractTestSuite) TestNewPNRRequest() {
 			RequestingPIU:    thisPIUId,
 			RespondingPIU:    request.RespondingPIU,
 			RequestTimestamp: request.RequestTimestamp,
+			Priority:         entities.RequestPriorityRoutine,
+			Deadline:         entities.DefaultConsortiumConfig().GetDeadline(entities.RequestPriorityRoutine, request.RequestTimestamp),
+			Purpose:          purpose,
+			AgreementId:      agreementId,
 			State:            entities.RequestStatePending,
 			RequestData:      string(requestData),
 			PNRHashes:        []string{},
@@ -178,6 +326,7 @@ func (suite *ContractTestSuite) TestSubmitPNRResponseAck() {
 	request := entities.NewPNRRequestInput{
 		RespondingPIU:    peerPIUId,
 		RequestTimestamp: testdata.MiddleTimestamp,
+		Purpose:          purpose,
 	}
 
 	transient := map[string][]byte{
@@ -222,6 +371,10 @@ func (suite *ContractTestSuite) TestSubmitPNRResponseAck() {
 			RequestingPIU:     thisPIUId,
 			RespondingPIU:     request.RespondingPIU,
 			RequestTimestamp:  request.RequestTimestamp,
+			Priority:          entities.RequestPriorityRoutine,
+			Deadline:          entities.DefaultConsortiumConfig().GetDeadline(entities.RequestPriorityRoutine, request.RequestTimestamp),
+			Purpose:           purpose,
+			AgreementId:       agreementId,
 			ResponseTimestamp: response.ResponseTimestamp,
 			State:             entities.RequestStateAck,
 			RequestData:       string(requestData),
@@ -242,6 +395,7 @@ func (suite *ContractTestSuite) TestSubmitPNRResponseNack() {
 	request := entities.NewPNRRequestInput{
 		RespondingPIU:    peerPIUId,
 		RequestTimestamp: testdata.MiddleTimestamp,
+		Purpose:          purpose,
 	}
 
 	transient := map[string][]byte{
@@ -286,6 +440,10 @@ func (suite *ContractTestSuite) TestSubmitPNRResponseNack() {
 			RequestingPIU:     thisPIUId,
 			RespondingPIU:     request.RespondingPIU,
 			RequestTimestamp:  request.RequestTimestamp,
+			Priority:          entities.RequestPriorityRoutine,
+			Deadline:          entities.DefaultConsortiumConfig().GetDeadline(entities.RequestPriorityRoutine, request.RequestTimestamp),
+			Purpose:           purpose,
+			AgreementId:       agreementId,
 			ResponseTimestamp: response.ResponseTimestamp,
 			State:             entities.RequestStateNack,
 			RequestData:       string(requestData),
@@ -306,6 +464,7 @@ func (suite *ContractTestSuite) TestConfirmPNR() {
 	request := entities.NewPNRRequestInput{
 		RespondingPIU:    peerPIUId,
 		RequestTimestamp: testdata.MiddleTimestamp,
+		Purpose:          purpose,
 	}
 
 	transient := map[string][]byte{
@@ -334,6 +493,10 @@ func (suite *ContractTestSuite) TestConfirmPNR() {
 			RequestingPIU:    thisPIUId,
 			RespondingPIU:    request.RespondingPIU,
 			RequestTimestamp: request.RequestTimestamp,
+			Priority:         entities.RequestPriorityRoutine,
+			Deadline:         entities.DefaultConsortiumConfig().GetDeadline(entities.RequestPriorityRoutine, request.RequestTimestamp),
+			Purpose:          purpose,
+			AgreementId:      agreementId,
 			State:            entities.RequestStatePendingConfirmed,
 			RequestData:      string(requestData),
 			PNRHashes:        []string{},
@@ -352,6 +515,7 @@ func (suite *ContractTestSuite) TestTerminatePNRRequest() {
 	request := entities.NewPNRRequestInput{
 		RespondingPIU:    peerPIUId,
 		RequestTimestamp: testdata.MiddleTimestamp,
+		Purpose:          purpose,
 	}
 
 	transient := map[string][]byte{
@@ -402,6 +566,10 @@ func (suite *ContractTestSuite) TestTerminatePNRRequest() {
 			RequestingPIU:     thisPIUId,
 			RespondingPIU:     request.RespondingPIU,
 			RequestTimestamp:  request.RequestTimestamp,
+			Priority:          entities.RequestPriorityRoutine,
+			Deadline:          entities.DefaultConsortiumConfig().GetDeadline(entities.RequestPriorityRoutine, request.RequestTimestamp),
+			Purpose:           purpose,
+			AgreementId:       agreementId,
 			ResponseTimestamp: response.ResponseTimestamp,
 			State:             entities.RequestStateTerminated,
 			PNRHashes:         []string{},
@@ -411,3 +579,179 @@ func (suite *ContractTestSuite) TestTerminatePNRRequest() {
 	actual, _ := suite.c.GetPNRs(suite.thisPIUContext, string(lo.Must(json.Marshal(entities.PNRFilter{}))))
 	assert.ElementsMatch(expected, actual)
 }
+
+func (suite *ContractTestSuite) TestClarification() {
+	assert := assert.New(suite.T())
+
+	suite.initPIUPair()
+
+	request := entities.NewPNRRequestInput{
+		RespondingPIU:    peerPIUId,
+		RequestTimestamp: testdata.MiddleTimestamp,
+		Purpose:          purpose,
+	}
+
+	err := setTransient(suite.thisPIUContext, map[string][]byte{
+		entities.RequestDataTransientKey: requestData,
+	})
+	assert.NoError(err)
+
+	requestResponse, _ := suite.c.NewPNRRequest(suite.thisPIUContext, string(lo.Must(json.Marshal(request))))
+
+	suite.c.ConfirmPNR(suite.peerPIUContext, string(lo.Must(json.Marshal(entities.ConfirmPNRInput{Id: requestResponse.Id}))))
+
+	clarification := entities.ClarificationInput{
+		Id:        requestResponse.Id,
+		Timestamp: testdata.LatestTimestamp,
+	}
+
+	question := json.RawMessage(lo.Must(json.Marshal("which flight?")))
+
+	err = setTransient(suite.peerPIUContext, map[string][]byte{
+		entities.ClarificationDataTransientKey: question,
+	})
+	assert.NoError(err)
+
+	err = suite.c.RequestClarification(suite.peerPIUContext, string(lo.Must(json.Marshal(clarification))))
+	assert.NoError(err)
+
+	expected := []entities.ClarificationMessage{
+		{
+			Id:        requestResponse.Id,
+			Sequence:  1,
+			Author:    peerPIUId,
+			Timestamp: clarification.Timestamp,
+			Message:   string(question),
+		},
+	}
+
+	actual, err := suite.c.GetPNRClarifications(suite.thisPIUContext, string(lo.Must(json.Marshal(entities.GetPNRClarificationsInput{Id: requestResponse.Id}))))
+	assert.NoError(err)
+	assert.Equal(expected, actual)
+}
+
+func (suite *ContractTestSuite) TestRoles() {
+	assert := assert.New(suite.T())
+
+	suite.initPIUPair()
+
+	testCases := map[string]struct {
+		Roles   string
+		Allowed bool
+	}{
+		"none":       {Roles: "", Allowed: false},
+		"dpo":        {Roles: "dpo", Allowed: false},
+		"admin":      {Roles: "admin", Allowed: false},
+		"unknown":    {Roles: "superuser", Allowed: false},
+		"analyst":    {Roles: "analyst", Allowed: true},
+		"supervisor": {Roles: "dpo, supervisor", Allowed: true},
+	}
+
+	for name, testCase := range testCases {
+		ctx := newRoleTransactionContext(thisPIUId, testCase.Roles)
+
+		request := string(lo.Must(json.Marshal(entities.NewPNRRequestInput{
+			Id:               name,
+			RespondingPIU:    peerPIUId,
+			RequestTimestamp: testdata.MiddleTimestamp,
+			Purpose:          purpose,
+		})))
+
+		err := setTransient(ctx, map[string][]byte{
+			entities.RequestDataTransientKey: requestData,
+		})
+		assert.NoError(err)
+
+		_, err = suite.c.NewPNRRequest(ctx, request)
+		if testCase.Allowed {
+			assert.NoError(err, name)
+		} else {
+			assert.ErrorIs(err, status.PermissionDenied, name)
+		}
+	}
+
+	dpoContext := newRoleTransactionContext(thisPIUId, "dpo")
+
+	_, err := suite.c.GetPNRs(dpoContext, string(lo.Must(json.Marshal(entities.PNRFilter{}))))
+	assert.NoError(err)
+
+	err = suite.c.SetPIUInfo(dpoContext, string(lo.Must(json.Marshal(entities.PIUInfo{Name: "foo"}))))
+	assert.ErrorIs(err, status.PermissionDenied)
+}
+
+func TestGetClientPIUId(t *testing.T) {
+	testCases := map[string]struct {
+		MSPId    string
+		PIU      string
+		Expected string
+		Error    bool
+	}{
+		"singlePIU":           {MSPId: "org1MSP", Expected: "org1MSP"},
+		"singlePIUAttribute":  {MSPId: "org1MSP", PIU: "unit", Expected: "org1MSP"},
+		"multiPIU":            {MSPId: "org2MSP", PIU: "unit", Expected: "org2MSP_unit"},
+		"multiPIUMissing":     {MSPId: "org2MSP", Error: true},
+		"multiPIUInvalidName": {MSPId: "org2MSP", PIU: "unit_b", Error: true},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			ctx := newRoleTransactionContext(testCase.MSPId, allRoles)
+			ctx.identity.piu = testCase.PIU
+
+			actual, err := contract.GetClientPIUId(ctx, []string{"org2MSP"})
+			if testCase.Error {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+				assert.Equal(testCase.Expected, actual)
+			}
+		})
+	}
+}
+
+func TestGetPIUMSPId(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("org1MSP", entities.GetPIUMSPId("org1MSP", []string{"org2MSP"}))
+	assert.Equal("org2MSP", entities.GetPIUMSPId("org2MSP_unit", []string{"org2MSP"}))
+	assert.Equal("org1MSP_unit", entities.GetPIUMSPId("org1MSP_unit", []string{"org2MSP"}))
+}
+
+func TestLedgerUsecaseFactoryBootstrap(t *testing.T) {
+	assert := assert.New(t)
+
+	uf := &contract.LedgerUsecaseFactory{BootstrapAdminMSPs: []string{adminMSPId}}
+	c := contract.NewSmartContract(uf)
+	ctx := newRoleTransactionContext(adminMSPId, "admin")
+	stub := ctx.GetStub().(*shimtest.MockStub)
+
+	_, err := uf.New(ctx, "GetPIUs")
+	assert.ErrorIs(err, status.FailedPrecondition)
+
+	txId := uuid.NewString()
+	stub.MockTransactionStart(txId)
+	_, err = c.InitLedger(ctx, `{"adminMSPs": ["`+adminMSPId+`"], "quota": {"perPIU": 3}}`)
+	stub.MockTransactionEnd(txId)
+	assert.NoError(err)
+
+	config, err := c.GetConfig(ctx)
+	assert.NoError(err)
+	assert.Equal(3, config.Quota.PerPIU)
+}
+
+func TestLedgerUsecaseFactoryKeyEndorsement(t *testing.T) {
+	assert := assert.New(t)
+
+	uf := &contract.LedgerUsecaseFactory{KeyEndorsement: true}
+
+	assert.Error(uf.Validate())
+
+	_, err := uf.New(newRoleTransactionContext(thisPIUId, allRoles), "InitLedger")
+	assert.Error(err)
+
+	uf.PairCollectionPIUs = []string{thisPIUId, peerPIUId}
+
+	assert.NoError(uf.Validate())
+}
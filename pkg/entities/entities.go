@@ -1,59 +1,468 @@
 package entities
 
 import (
+	"cmp"
+	"crypto/sha256"
 	"encoding/json"
+	"slices"
+	"strings"
 	"time"
 )
 
+type PIUContact struct {
+	Name  string `json:"name" required:"false" description:"Name of the contact point"`
+	Role  string `json:"role" required:"false" description:"Role of the contact point, e.g. operations or security"`
+	Email string `json:"email" required:"false" description:"Email of the contact point"`
+	Phone string `json:"phone" required:"false" description:"Phone number of the contact point"`
+}
+
 type PIUInfo struct {
-	Name       string `json:"name" required:"false" description:"Name of PIU"`
-	AdminEmail string `json:"adminEmail" required:"false" description:"Email of Administrator"`
+	Name               string       `json:"name" required:"false" description:"Name of PIU"`
+	AdminEmail         string       `json:"adminEmail" required:"false" description:"Email of Administrator"`
+	Country            string       `json:"country" required:"false" description:"ISO 3166-1 alpha-2 code of the PIU's country"`
+	Contacts           []PIUContact `json:"contacts" required:"false" description:"Operational contact points"`
+	SupportedProfiles  []string     `json:"supportedProfiles" required:"false" description:"Payload profiles and versions the PIU accepts"`
+	EncryptionKey      string       `json:"encryptionKey" required:"false" description:"PEM encoded public key used to encrypt data for the PIU"`
+	SigningKey         string       `json:"signingKey" required:"false" description:"PEM encoded public key used to verify signatures of the PIU"`
+	SigningCertificate string       `json:"signingCertificate" required:"false" description:"PEM encoded certificate, or issuing CA certificate, used to verify signatures of PNR responses of the PIU"`
+	RequestApproval    bool         `json:"requestApproval" required:"false" description:"Outgoing PNR requests need approval of a second officer"`
+	ResponseApproval   bool         `json:"responseApproval" required:"false" description:"Outgoing PNR responses need approval of a second officer"`
+}
+
+type PIUStatus string
+
+const (
+	PIUStatusPending   PIUStatus = "Pending"
+	PIUStatusActive    PIUStatus = "Active"
+	PIUStatusSuspended PIUStatus = "Suspended"
+	PIUStatusRetired   PIUStatus = "Retired"
+)
+
+// GetPIUStatus returns the status of a PIU, treating PIUs registered before
+// governance was introduced as active.
+func GetPIUStatus(status PIUStatus) PIUStatus {
+	if status == "" {
+		return PIUStatusActive
+	}
+	return status
 }
 
 type PIU struct {
-	Id         string `json:"id" required:"true" description:"ID is a unique uuid string that identifies a PIU."`
-	Name       string `json:"name" required:"false" description:"Name of PIU"`
-	AdminEmail string `json:"adminEmail" required:"false" description:"Email of Administrator"`
+	Id                 string       `json:"id" required:"true" description:"ID is a unique uuid string that identifies a PIU."`
+	Name               string       `json:"name" required:"false" description:"Name of PIU"`
+	AdminEmail         string       `json:"adminEmail" required:"false" description:"Email of Administrator"`
+	Status             PIUStatus    `json:"status" required:"false" enum:"Pending,Active,Suspended,Retired" description:"Status of PIU in the consortium"`
+	Country            string       `json:"country" required:"false" description:"ISO 3166-1 alpha-2 code of the PIU's country"`
+	Contacts           []PIUContact `json:"contacts" required:"false" description:"Operational contact points"`
+	SupportedProfiles  []string     `json:"supportedProfiles" required:"false" description:"Payload profiles and versions the PIU accepts"`
+	EncryptionKey      string       `json:"encryptionKey" required:"false" description:"PEM encoded public key used to encrypt data for the PIU"`
+	SigningKey         string       `json:"signingKey" required:"false" description:"PEM encoded public key used to verify signatures of the PIU"`
+	SigningCertificate string       `json:"signingCertificate" required:"false" description:"PEM encoded certificate, or issuing CA certificate, used to verify signatures of PNR responses of the PIU"`
+	RequestApproval    bool         `json:"requestApproval" required:"false" description:"Outgoing PNR requests need approval of a second officer"`
+	ResponseApproval   bool         `json:"responseApproval" required:"false" description:"Outgoing PNR responses need approval of a second officer"`
+	Version            int          `json:"version" required:"false" description:"Version of the PIU record, incremented on every change"`
 }
 
 func NewPIUFromPIUInfo(id string, info PIUInfo) PIU {
 	return PIU{
-		Id:         id,
-		Name:       info.Name,
-		AdminEmail: info.AdminEmail,
+		Id:                 id,
+		Name:               info.Name,
+		AdminEmail:         info.AdminEmail,
+		Status:             PIUStatusPending,
+		Country:            info.Country,
+		Contacts:           info.Contacts,
+		SupportedProfiles:  info.SupportedProfiles,
+		EncryptionKey:      info.EncryptionKey,
+		SigningKey:         info.SigningKey,
+		SigningCertificate: info.SigningCertificate,
+		RequestApproval:    info.RequestApproval,
+		ResponseApproval:   info.ResponseApproval,
+		Version:            1,
 	}
 }
 
-func UpdatePIUFromPIUInfo(piu PIU, info PIUInfo) PIU {
-	if info.Name != "" {
-		piu.Name = info.Name
-	}
-	if info.AdminEmail != "" {
-		piu.AdminEmail = info.AdminEmail
+func PIUInfoFromPIU(piu PIU) PIUInfo {
+	return PIUInfo{
+		Name:               piu.Name,
+		AdminEmail:         piu.AdminEmail,
+		Country:            piu.Country,
+		Contacts:           piu.Contacts,
+		SupportedProfiles:  piu.SupportedProfiles,
+		EncryptionKey:      piu.EncryptionKey,
+		SigningKey:         piu.SigningKey,
+		SigningCertificate: piu.SigningCertificate,
+		RequestApproval:    piu.RequestApproval,
+		ResponseApproval:   piu.ResponseApproval,
 	}
+}
+
+// UpdatePIUFromPIUInfo replaces the profile of a PIU with the given one,
+// including empty fields.
+func UpdatePIUFromPIUInfo(piu PIU, info PIUInfo) PIU {
+	piu.Name = info.Name
+	piu.AdminEmail = info.AdminEmail
+	piu.Country = info.Country
+	piu.Contacts = info.Contacts
+	piu.SupportedProfiles = info.SupportedProfiles
+	piu.EncryptionKey = info.EncryptionKey
+	piu.SigningKey = info.SigningKey
+	piu.SigningCertificate = info.SigningCertificate
+	piu.RequestApproval = info.RequestApproval
+	piu.ResponseApproval = info.ResponseApproval
 	return piu
 }
 
+// SupportsProfile reports whether the PIU accepts payloads of given profile.
+// PIUs which do not publish their profiles accept any payload.
+func SupportsProfile(piu PIU, profile string) bool {
+	return profile == "" || len(piu.SupportedProfiles) == 0 || slices.Contains(piu.SupportedProfiles, profile)
+}
+
+type SetPIUInfoInput struct {
+	Patch           *json.RawMessage `json:"patch" required:"true" description:"JSON Merge Patch (RFC 7396) of PIU information"`
+	ExpectedVersion int              `json:"expectedVersion" required:"false" description:"Update only if the PIU record has this version"`
+}
+
 type SetPIUInfoOutput struct {
+	Version int `json:"version" required:"true" description:"Version of the updated PIU record"`
+}
+
+type GetPIUHistoryInput struct {
+	Id string `query:"id" required:"true" description:"Id of PIU"`
+}
+
+type PIUHistoryEntry struct {
+	TxId      string    `json:"txId" required:"true" description:"Id of transaction which changed the PIU record"`
+	Timestamp time.Time `json:"timestamp" required:"true" description:"Timestamp of the transaction"`
+	IsDelete  bool      `json:"isDelete" required:"true" description:"The PIU record was deleted by the transaction"`
+	PIU       PIU       `json:"piu" required:"false" description:"Value of the PIU record after the transaction"`
 }
 
 type GetPIUsInput struct {
+	IncludeRetired bool `query:"includeRetired" required:"false" description:"Include offboarded PIUs"`
+}
+
+type ChangePIUStatusInput struct {
+	Id string `query:"id" required:"true" description:"Id of PIU"`
+}
+
+type ChangePIUStatusOutput struct {
 }
 
 type RequestState string
 
 const (
-	RequestStatePending          RequestState = "Pending"
-	RequestStatePendingConfirmed RequestState = "PendingConfirmed"
-	RequestStateAck              RequestState = "Ack"
-	RequestStateAckConfirmed     RequestState = "AckConfirmed"
-	RequestStateNack             RequestState = "Nack"
-	RequestStateNackConfirmed    RequestState = "NackConfirmed"
-	RequestStateTerminated       RequestState = "Terminated"
+	RequestStatePending                RequestState = "Pending"
+	RequestStatePendingConfirmed       RequestState = "PendingConfirmed"
+	RequestStateAck                    RequestState = "Ack"
+	RequestStateAckConfirmed           RequestState = "AckConfirmed"
+	RequestStateNack                   RequestState = "Nack"
+	RequestStateNackConfirmed          RequestState = "NackConfirmed"
+	RequestStateTerminated             RequestState = "Terminated"
+	RequestStateClarificationRequested RequestState = "ClarificationRequested"
+	RequestStateForwarded              RequestState = "Forwarded"
+	RequestStateDraft                  RequestState = "Draft"
+	RequestStateAckDraft               RequestState = "AckDraft"
+)
+
+type RequestPriority string
+
+const (
+	RequestPriorityRoutine   RequestPriority = "Routine"
+	RequestPriorityUrgent    RequestPriority = "Urgent"
+	RequestPriorityImmediate RequestPriority = "Immediate"
+)
+
+// GetPriority returns the priority of a request, treating requests created
+// before priorities were introduced as routine.
+func GetPriority(priority RequestPriority) RequestPriority {
+	if priority == "" {
+		return RequestPriorityRoutine
+	}
+	return priority
+}
+
+func IsValidPriority(priority RequestPriority) bool {
+	switch priority {
+	case RequestPriorityRoutine, RequestPriorityUrgent, RequestPriorityImmediate:
+		return true
+	default:
+		return false
+	}
+}
+
+func priorityRank(priority RequestPriority) int {
+	switch GetPriority(priority) {
+	case RequestPriorityImmediate:
+		return 2
+	case RequestPriorityUrgent:
+		return 1
+	default:
+		return 0
+	}
+}
+
+type ConsortiumConfig struct {
+	Version           int                               `json:"version" required:"false" description:"Version of the configuration stored on the ledger, incremented on every change"`
+	PriorityDeadlines map[RequestPriority]time.Duration `json:"priorityDeadlines" required:"false" description:"Default time to respond to a request of given priority"`
+	AdminMSPs         []string                          `json:"adminMSPs" required:"false" description:"Ids of MSPs acting as consortium administrators"`
+	MultiPIUMSPs      []string                          `json:"multiPIUMSPs" required:"false" description:"Ids of MSPs hosting several PIUs, which are identified by a client certificate attribute"`
+	Quota             RequestQuota                      `json:"quota" required:"false" description:"Limits on the number of PNR requests made by PIUs"`
+	Retention         time.Duration                     `json:"retention" required:"false" description:"Time after creation of PNR records after which PIUs have to delete them, zero for no limit"`
+	PayloadProfiles   []string                          `json:"payloadProfiles" required:"false" description:"Payload profiles allowed in the consortium, any when empty"`
+	Hashing           HashSettings                      `json:"hashing" required:"false" description:"Selection of PNR records in response data which are hashed"`
+	AtRestEncryption  bool                              `json:"atRestEncryption" required:"false" description:"New PNR requests must encrypt their data at rest with a per-request data key"`
+}
+
+const (
+	DefaultHashRecordPath       = "passengerDatasets.#.passenger_obj"
+	DefaultHashCreationTimePath = "pnr_obj.iata_pnrgov_notif_rq_obj.created_on"
+)
+
+// HashSettings select PNR records in response data, whose hashes are kept
+// after the data are purged. Paths use the GJSON syntax.
+type HashSettings struct {
+	RecordPath       string `json:"recordPath" required:"false" description:"Path of PNR records in response data"`
+	CreationTimePath string `json:"creationTimePath" required:"false" description:"Path of the creation time within a PNR record"`
+}
+
+func (h HashSettings) GetRecordPath() string {
+	return cmp.Or(h.RecordPath, DefaultHashRecordPath)
+}
+
+func (h HashSettings) GetCreationTimePath() string {
+	return cmp.Or(h.CreationTimePath, DefaultHashCreationTimePath)
+}
+
+// IsAllowedProfile reports whether the payload profile may be used in the
+// consortium.
+func (c ConsortiumConfig) IsAllowedProfile(profile string) bool {
+	return profile == "" || len(c.PayloadProfiles) == 0 || slices.Contains(c.PayloadProfiles, profile)
+}
+
+type UpdateConfigInput struct {
+	Config          *json.RawMessage `json:"config" required:"true" description:"Consortium configuration replacing the current one"`
+	ExpectedVersion int              `json:"expectedVersion" required:"false" description:"Update only if the configuration has this version"`
+}
+
+type UpdateConfigOutput struct {
+	Version int `json:"version" required:"true" description:"Version of the stored configuration"`
+}
+
+type GetConfigInput struct{}
+
+type MigrateDataInput struct {
+	Bookmark string `json:"bookmark" required:"false" description:"Bookmark returned by the previous batch, empty to start from the beginning"`
+	PageSize int    `json:"pageSize" required:"false" description:"Maximum number of records rewritten in the batch"`
+}
+
+type MigrateDataOutput struct {
+	Migrated int    `json:"migrated" required:"true" description:"Number of records rewritten to the current model version"`
+	Bookmark string `json:"bookmark" required:"false" description:"Bookmark of the next batch, empty when all records are migrated"`
+}
+
+func DefaultConsortiumConfig() ConsortiumConfig {
+	return ConsortiumConfig{
+		PriorityDeadlines: map[RequestPriority]time.Duration{
+			RequestPriorityRoutine:   7 * 24 * time.Hour,
+			RequestPriorityUrgent:    24 * time.Hour,
+			RequestPriorityImmediate: 4 * time.Hour,
+		},
+		Quota: RequestQuota{
+			Window: 24 * time.Hour,
+		},
+		Hashing: HashSettings{
+			RecordPath:       DefaultHashRecordPath,
+			CreationTimePath: DefaultHashCreationTimePath,
+		},
+	}
+}
+
+// GetDeadline returns the deadline of a request of given priority made at
+// given time, or zero time if the configuration sets no deadline for it.
+func (c ConsortiumConfig) GetDeadline(priority RequestPriority, timestamp time.Time) time.Time {
+	deadline, ok := c.PriorityDeadlines[GetPriority(priority)]
+	if !ok || deadline <= 0 {
+		return time.Time{}
+	}
+	return timestamp.Add(deadline)
+}
+
+// GetPurgeDeadline returns the time by which a PNR record created at given
+// time has to be purged, or zero time if the configuration sets no retention.
+func (c ConsortiumConfig) GetPurgeDeadline(creationTimestamp time.Time) time.Time {
+	if c.Retention <= 0 {
+		return time.Time{}
+	}
+	return creationTimestamp.Add(c.Retention)
+}
+
+// GetPIUMSPId returns the MSP id of the organisation hosting the PIU. PIUs of
+// MSPs hosting several PIUs are named <MSPID>_<unit>, other PIUs are named by
+// their MSP id.
+func GetPIUMSPId(piuId string, multiPIUMSPs []string) string {
+	for _, mspId := range multiPIUMSPs {
+		if strings.HasPrefix(piuId, mspId+"_") {
+			return mspId
+		}
+	}
+
+	return piuId
+}
+
+// Role of a PIU officer, taken from the client certificate.
+type Role string
+
+const (
+	RoleAnalyst    Role = "analyst"
+	RoleSupervisor Role = "supervisor"
+	RoleDPO        Role = "dpo"
+	RoleAdmin      Role = "admin"
 )
 
+// QuotaBucketDuration is the granularity of quota counters. The rolling
+// quota window is approximated by the buckets it overlaps.
+const QuotaBucketDuration = time.Hour
+
+// RequestQuota limits the number of PNR requests a PIU may make within a
+// rolling window. Zero limit means the number of requests is not limited.
+type RequestQuota struct {
+	Window     time.Duration             `json:"window" required:"false" description:"Length of the rolling quota window"`
+	PerPIU     int                       `json:"perPIU" required:"false" description:"Default maximum number of requests made by a PIU within the window"`
+	PerPair    int                       `json:"perPair" required:"false" description:"Default maximum number of requests made by a PIU to a single counterpart within the window"`
+	PIULimits  map[string]int            `json:"piuLimits" required:"false" description:"Overrides of perPIU keyed by requesting PIU"`
+	PairLimits map[string]map[string]int `json:"pairLimits" required:"false" description:"Overrides of perPair keyed by requesting and responding PIU"`
+}
+
+func (q RequestQuota) GetPIULimit(piuId string) int {
+	if limit, ok := q.PIULimits[piuId]; ok {
+		return limit
+	}
+	return q.PerPIU
+}
+
+func (q RequestQuota) GetPairLimit(requestingPIU string, respondingPIU string) int {
+	if limit, ok := q.PairLimits[requestingPIU][respondingPIU]; ok {
+		return limit
+	}
+	return q.PerPair
+}
+
+// GetWindow returns the length of the quota window, which defaults to 24
+// hours.
+func (q RequestQuota) GetWindow() time.Duration {
+	if q.Window <= 0 {
+		return 24 * time.Hour
+	}
+	return q.Window
+}
+
+// GetBuckets returns the start times of quota buckets overlapping the window
+// that ends at given time, the most recent first.
+func (q RequestQuota) GetBuckets(timestamp time.Time) []time.Time {
+	window := q.GetWindow()
+	current := GetQuotaBucket(timestamp)
+	count := int((window + QuotaBucketDuration - 1) / QuotaBucketDuration)
+
+	buckets := make([]time.Time, 0, count)
+	for i := range count {
+		buckets = append(buckets, current.Add(-time.Duration(i)*QuotaBucketDuration))
+	}
+
+	return buckets
+}
+
+func GetQuotaBucket(timestamp time.Time) time.Time {
+	return timestamp.UTC().Truncate(QuotaBucketDuration)
+}
+
+// QuotaCounter holds the number of PNR requests made by a PIU within a
+// single bucket. Counters with empty Counterpart count requests made to any
+// PIU.
+type QuotaCounter struct {
+	PIU         string    `json:"piu"`
+	Counterpart string    `json:"counterpart"`
+	Bucket      time.Time `json:"bucket"`
+	Count       int       `json:"count"`
+}
+
+type GetQuotaStatusInput struct{}
+
+// QuotaUsage describes the remaining budget of PIU's requests. Remaining is
+// meaningful only when the quota is limited.
+type QuotaUsage struct {
+	Counterpart string `json:"counterpart,omitempty" required:"false" description:"Responding PIU the quota applies to, empty for requests to any PIU"`
+	Limited     bool   `json:"limited" description:"Whether the number of requests is limited"`
+	Limit       int    `json:"limit" description:"Maximum number of requests within the window"`
+	Used        int    `json:"used" description:"Number of requests made within the window"`
+	Remaining   int    `json:"remaining" description:"Number of requests that can still be made within the window"`
+}
+
+type QuotaStatus struct {
+	Window time.Duration `json:"window" description:"Length of the rolling quota window"`
+	Total  QuotaUsage    `json:"total" description:"Quota of requests made to any PIU"`
+	Pairs  []QuotaUsage  `json:"pairs" description:"Quotas of requests made to individual PIUs"`
+}
+
 const RequestDataTransientKey string = "requestData"
 const ResponseDataTransientKey string = "responseData"
+const ClarificationDataTransientKey string = "clarificationData"
+const ArchiveTransientKey string = "archive"
+
+const DataKeyTransientKey string = "dataKey"
+
+const EncryptedPayloadFormat string = "tenacity-e2e/v1"
+
+type EncryptionAlgorithm string
+
+const (
+	// EncryptionAlgorithmECDH derives the AES-256-GCM content key with
+	// HKDF-SHA256 from the shared secret of an ephemeral key and the
+	// X25519 or NIST curve key of the recipient, using the payload format as
+	// HKDF info.
+	EncryptionAlgorithmECDH EncryptionAlgorithm = "ECDH-ES+A256GCM"
+	// EncryptionAlgorithmRSAOAEP wraps a random AES-256-GCM content key with
+	// RSA-OAEP using SHA-256.
+	EncryptionAlgorithmRSAOAEP EncryptionAlgorithm = "RSA-OAEP-256+A256GCM"
+)
+
+// EncryptedPayload is request or response data encrypted to the encryption
+// key of the receiving PIU. Hashes of PNRs included in an encrypted response
+// are provided by the responder, who vouches for them by signing the
+// payload.
+type EncryptedPayload struct {
+	Format            string              `json:"format" required:"true" description:"Format of the payload, tenacity-e2e/v1"`
+	Algorithm         EncryptionAlgorithm `json:"algorithm" required:"true" enum:"ECDH-ES+A256GCM,RSA-OAEP-256+A256GCM" description:"Key agreement or key wrapping and content encryption algorithm"`
+	KeyId             string              `json:"keyId" required:"true" description:"Hex encoded SHA-256 hash of the DER encoded encryption key of the recipient"`
+	EphemeralKey      []byte              `json:"ephemeralKey,omitempty" required:"false" description:"Ephemeral public key of the sender for ECDH-ES"`
+	EncryptedKey      []byte              `json:"encryptedKey,omitempty" required:"false" description:"Content key wrapped with RSA-OAEP"`
+	Nonce             []byte              `json:"nonce" required:"true" description:"AES-GCM nonce"`
+	Ciphertext        []byte              `json:"ciphertext" required:"true" description:"Encrypted data followed by the AES-GCM tag"`
+	PNRHashes         []string            `json:"pnrHashes,omitempty" required:"false" description:"Hex encoded SHA-256 hashes of the JCS canonical PNRs included in a response"`
+	CreationTimestamp time.Time           `json:"creationTimestamp" required:"false" description:"Creation timestamp of the oldest PNR included in a response"`
+}
+
+const SealedDataFormat string = "tenacity-at-rest/v1"
+
+// SealedData is request or response data encrypted at rest with AES-256-GCM
+// under the data key of the PNR request, with the name of the data field as
+// additional data. The nonce is derived from the key and the data, so that
+// all endorsers seal the data equally.
+type SealedData struct {
+	Format     string `json:"format" required:"true" description:"Format of the data, tenacity-at-rest/v1"`
+	DataKeyId  string `json:"dataKeyId" required:"true" description:"Id of the data key the data are encrypted with"`
+	Nonce      []byte `json:"nonce" required:"true" description:"AES-GCM nonce"`
+	Ciphertext []byte `json:"ciphertext" required:"true" description:"Encrypted data followed by the AES-GCM tag"`
+}
+
+// DataKey is the per-request key PNR data are encrypted with at rest. The key
+// is kept only wrapped to the encryption keys of the parties of the request
+// and is destroyed together with the data, so that copies of the data which
+// survive elsewhere cannot be decrypted.
+type DataKey struct {
+	Key         []byte                      `json:"key" required:"true" description:"AES-256 key"`
+	WrappedKeys map[string]EncryptedPayload `json:"wrappedKeys" required:"false" description:"Key encrypted to the encryption key of every party of the PNR request, by id of the party"`
+}
 
 func GetConfirmedState(state RequestState) RequestState {
 	switch state {
@@ -70,7 +479,7 @@ func GetConfirmedState(state RequestState) RequestState {
 
 func HasData(state RequestState) bool {
 	switch state {
-	case RequestStateAckConfirmed, RequestStateNackConfirmed, RequestStateTerminated:
+	case RequestStateAckConfirmed, RequestStateNackConfirmed, RequestStateTerminated, RequestStateForwarded:
 		return false
 	default:
 		return true
@@ -126,35 +535,312 @@ func IsMatchingPNR(filter PNRFilter, pnr PNR) bool {
 			}
 		}
 
+		if filter.ParentId != "" {
+			if pnr.ParentId != filter.ParentId {
+				return false
+			}
+		}
+
+		if filter.AgreementId != "" {
+			if pnr.AgreementId != filter.AgreementId {
+				return false
+			}
+		}
+
+		if filter.Priority != "" {
+			if GetPriority(pnr.Priority) != filter.Priority {
+				return false
+			}
+		}
+
 		return true
 	}
 }
 
 type PNR struct {
-	Id                string       `json:"id" required:"true" format:"uuid" description:"Id of PNR request"`
-	RequestingPIU     string       `json:"requestingPIU" required:"true" description:"Id of requesting PIU"`
-	RespondingPIU     string       `json:"respondingPIU" required:"true" description:"Id of responding PIU"`
-	RequestTimestamp  time.Time    `json:"requestTimestamp" required:"false" description:"Timestamp of request"`
-	ResponseTimestamp time.Time    `json:"responseTimestamp" required:"false" description:"Timestamp of response"`
-	State             RequestState `json:"state" required:"true" enum:"Pending,PendingConfirmed,Ack,AckConfirmed,Nack,NackConfirmed,Terminated" description:"State of the PNR request"`
-	RequestData       string       `json:"requestData" required:"true" description:"PNR request data"`
-	ResponseData      string       `json:"responseData" required:"true" description:"PNR response data"`
-	PNRHashes         []string     `json:"pnrHashes" required:"true" description:"Hashes of PNRs included in response"`
+	Id                      string                      `json:"id" required:"true" format:"uuid" description:"Id of PNR request"`
+	RequestingPIU           string                      `json:"requestingPIU" required:"true" description:"Id of requesting PIU"`
+	RespondingPIU           string                      `json:"respondingPIU" required:"true" description:"Id of responding PIU"`
+	RequestTimestamp        time.Time                   `json:"requestTimestamp" required:"false" description:"Timestamp of request"`
+	ResponseTimestamp       time.Time                   `json:"responseTimestamp" required:"false" description:"Timestamp of response"`
+	State                   RequestState                `json:"state" required:"true" enum:"Pending,PendingConfirmed,Ack,AckConfirmed,Nack,NackConfirmed,Terminated,ClarificationRequested,Forwarded,Draft,AckDraft" description:"State of the PNR request"`
+	RequestData             string                      `json:"requestData" required:"true" description:"PNR request data"`
+	ResponseData            string                      `json:"responseData" required:"true" description:"PNR response data"`
+	PNRHashes               []string                    `json:"pnrHashes" required:"true" description:"Hashes of PNRs included in response"`
+	RequestKeyId            string                      `json:"requestKeyId,omitempty" required:"false" description:"Id of the encryption key the request data is encrypted to"`
+	ResponseKeyId           string                      `json:"responseKeyId,omitempty" required:"false" description:"Id of the encryption key the response data is encrypted to"`
+	DataKeyId               string                      `json:"dataKeyId,omitempty" required:"false" description:"Id of the data key the request and response data are encrypted with at rest"`
+	ResponseDigest          string                      `json:"responseDigest,omitempty" required:"false" description:"Hex encoded SHA-256 hash of the canonical response data"`
+	ResponseSignature       []byte                      `json:"responseSignature,omitempty" required:"false" description:"Detached signature of the response digest by the responding PIU"`
+	ResponseCertificate     string                      `json:"responseCertificate,omitempty" required:"false" description:"PEM encoded certificate the response was signed with"`
+	ResponseSignedAt        time.Time                   `json:"responseSignedAt" required:"false" description:"Transaction time at which the response signature was verified, later verifications use the same time"`
+	ParentId                string                      `json:"parentId" required:"false" description:"Id of the PNR request this request follows up on"`
+	AllowOnBehalfForwarding bool                        `json:"allowOnBehalfForwarding" required:"false" description:"Responder may forward the request to another PIU on behalf of the requester"`
+	Priority                RequestPriority             `json:"priority" required:"false" enum:"Routine,Urgent,Immediate" description:"Priority of the PNR request"`
+	Deadline                time.Time                   `json:"deadline" required:"false" description:"Time by which the response is expected"`
+	PayloadProfile          string                      `json:"payloadProfile" required:"false" description:"Payload profile of request and response data"`
+	Purpose                 string                      `json:"purpose" required:"false" description:"Purpose or offence category the PNR request is made for"`
+	AgreementId             string                      `json:"agreementId" required:"false" description:"Id of the agreement covering the PNR request"`
+	DraftedBy               string                      `json:"draftedBy,omitempty" required:"false" description:"Id of the officer who drafted the request or response awaiting approval"`
+	DataKeys                map[string]EncryptedPayload `json:"dataKeys,omitempty" required:"false" description:"Data key wrapped to the encryption keys of the parties, destroyed together with the data"`
+}
+
+func WithoutData(pnr PNR) PNR {
+	pnr.RequestData = ""
+	pnr.ResponseData = ""
+	pnr.DataKeys = nil
+	return pnr
+}
+
+// PNRMetadata are the fields of a PNR request other than request and
+// response data.
+type PNRMetadata struct {
+	Id                      string          `json:"id" required:"true" format:"uuid" description:"Id of PNR request"`
+	RequestingPIU           string          `json:"requestingPIU" required:"true" description:"Id of requesting PIU"`
+	RespondingPIU           string          `json:"respondingPIU" required:"true" description:"Id of responding PIU"`
+	RequestTimestamp        time.Time       `json:"requestTimestamp" required:"false" description:"Timestamp of request"`
+	ResponseTimestamp       time.Time       `json:"responseTimestamp" required:"false" description:"Timestamp of response"`
+	State                   RequestState    `json:"state" required:"true" enum:"Pending,PendingConfirmed,Ack,AckConfirmed,Nack,NackConfirmed,Terminated,ClarificationRequested,Forwarded,Draft,AckDraft" description:"State of the PNR request"`
+	PNRHashes               []string        `json:"pnrHashes" required:"true" description:"Hashes of PNRs included in response"`
+	RequestKeyId            string          `json:"requestKeyId,omitempty" required:"false" description:"Id of the encryption key the request data is encrypted to"`
+	ResponseKeyId           string          `json:"responseKeyId,omitempty" required:"false" description:"Id of the encryption key the response data is encrypted to"`
+	DataKeyId               string          `json:"dataKeyId,omitempty" required:"false" description:"Id of the data key the request and response data are encrypted with at rest"`
+	ResponseDigest          string          `json:"responseDigest,omitempty" required:"false" description:"Hex encoded SHA-256 hash of the canonical response data"`
+	ResponseSignature       []byte          `json:"responseSignature,omitempty" required:"false" description:"Detached signature of the response digest by the responding PIU"`
+	ResponseCertificate     string          `json:"responseCertificate,omitempty" required:"false" description:"PEM encoded certificate the response was signed with"`
+	ResponseSignedAt        time.Time       `json:"responseSignedAt" required:"false" description:"Transaction time at which the response signature was verified, later verifications use the same time"`
+	ParentId                string          `json:"parentId" required:"false" description:"Id of the PNR request this request follows up on"`
+	AllowOnBehalfForwarding bool            `json:"allowOnBehalfForwarding" required:"false" description:"Responder may forward the request to another PIU on behalf of the requester"`
+	Priority                RequestPriority `json:"priority" required:"false" enum:"Routine,Urgent,Immediate" description:"Priority of the PNR request"`
+	Deadline                time.Time       `json:"deadline" required:"false" description:"Time by which the response is expected"`
+	PayloadProfile          string          `json:"payloadProfile" required:"false" description:"Payload profile of request and response data"`
+	Purpose                 string          `json:"purpose" required:"false" description:"Purpose or offence category the PNR request is made for"`
+	AgreementId             string          `json:"agreementId" required:"false" description:"Id of the agreement covering the PNR request"`
+	DraftedBy               string          `json:"draftedBy,omitempty" required:"false" description:"Id of the officer who drafted the request or response awaiting approval"`
+}
+
+func ToPNRMetadata(pnr PNR) PNRMetadata {
+	return PNRMetadata{
+		Id:                      pnr.Id,
+		RequestingPIU:           pnr.RequestingPIU,
+		RespondingPIU:           pnr.RespondingPIU,
+		RequestTimestamp:        pnr.RequestTimestamp,
+		ResponseTimestamp:       pnr.ResponseTimestamp,
+		State:                   pnr.State,
+		PNRHashes:               pnr.PNRHashes,
+		RequestKeyId:            pnr.RequestKeyId,
+		ResponseKeyId:           pnr.ResponseKeyId,
+		DataKeyId:               pnr.DataKeyId,
+		ResponseDigest:          pnr.ResponseDigest,
+		ResponseSignature:       pnr.ResponseSignature,
+		ResponseCertificate:     pnr.ResponseCertificate,
+		ResponseSignedAt:        pnr.ResponseSignedAt,
+		ParentId:                pnr.ParentId,
+		AllowOnBehalfForwarding: pnr.AllowOnBehalfForwarding,
+		Priority:                pnr.Priority,
+		Deadline:                pnr.Deadline,
+		PayloadProfile:          pnr.PayloadProfile,
+		Purpose:                 pnr.Purpose,
+		AgreementId:             pnr.AgreementId,
+		DraftedBy:               pnr.DraftedBy,
+	}
 }
 
 type PNRFilter struct {
-	Start         time.Time    `query:"start" required:"false" description:"Start of time period"`
-	End           time.Time    `query:"end" required:"false" description:"End of time period"`
-	State         RequestState `query:"state" required:"false" enum:"Pending,PendingConfirmed,Ack,AckConfirmed,Nack,NackConfirmed,Terminated" description:"State of the PNR request"`
-	RequestingPIU string       `query:"requestingPIU" required:"false" description:"Id of requesting PIU"`
-	RespondingPIU string       `query:"respondingPIU" required:"false" description:"Id of responding PIU"`
+	Start          time.Time       `query:"start" required:"false" description:"Start of time period"`
+	End            time.Time       `query:"end" required:"false" description:"End of time period"`
+	State          RequestState    `query:"state" required:"false" enum:"Pending,PendingConfirmed,Ack,AckConfirmed,Nack,NackConfirmed,Terminated,ClarificationRequested,Forwarded,Draft,AckDraft" description:"State of the PNR request"`
+	RequestingPIU  string          `query:"requestingPIU" required:"false" description:"Id of requesting PIU"`
+	RespondingPIU  string          `query:"respondingPIU" required:"false" description:"Id of responding PIU"`
+	ParentId       string          `query:"parentId" required:"false" description:"Id of the parent PNR request"`
+	Priority       RequestPriority `query:"priority" required:"false" enum:"Routine,Urgent,Immediate" description:"Priority of the PNR request"`
+	AgreementId    string          `query:"agreementId" required:"false" description:"Id of the agreement covering the PNR request"`
+	SortBy         PNRSortField    `query:"sortBy" required:"false" enum:"requestTimestamp,responseTimestamp,priority,deadline" description:"Field to sort PNR requests by"`
+	SortDescending bool            `query:"sortDescending" required:"false" description:"Sort PNR requests in descending order"`
+}
+
+type ExportFormat string
+
+const (
+	ExportFormatJSONLines ExportFormat = "jsonl"
+	ExportFormatCSV       ExportFormat = "csv"
+)
+
+type ExportPNRMetadataInput struct {
+	Filter   PNRFilter    `json:"filter" required:"false" description:"Filter of exported PNR requests, same as of GetPNRs"`
+	Format   ExportFormat `json:"format" required:"false" enum:"jsonl,csv" description:"Format of the export, JSON Lines by default"`
+	Bookmark string       `json:"bookmark" required:"false" description:"Bookmark returned with the previous page, empty for the first page"`
+	PageSize int          `json:"pageSize" required:"false" description:"Maximum number of records in the page"`
+}
+
+// ExportManifest describes a page of an export. Concatenated pages form the
+// whole export, the hash of every page covers all of them.
+type ExportManifest struct {
+	PIU         string       `json:"piu" required:"true" description:"Id of the exporting PIU"`
+	TxId        string       `json:"txId" required:"true" description:"Id of transaction which exported the first page"`
+	Format      ExportFormat `json:"format" required:"true" enum:"jsonl,csv" description:"Format of the export"`
+	Filter      PNRFilter    `json:"filter" required:"true" description:"Filter of exported PNR requests"`
+	Timestamp   time.Time    `json:"timestamp" required:"true" description:"Time the page was exported at"`
+	Offset      int          `json:"offset" required:"true" description:"Number of records exported in previous pages"`
+	RecordCount int          `json:"recordCount" required:"true" description:"Number of records in the page"`
+	TotalCount  int          `json:"totalCount" required:"true" description:"Number of records matching the filter"`
+	SHA256      string       `json:"sha256" required:"true" description:"Hex encoded SHA-256 over the whole export"`
+	Complete    bool         `json:"complete" required:"true" description:"The page is the last one of the export"`
+}
+
+type ExportPNRMetadataOutput struct {
+	Data     string         `json:"data" required:"true" description:"Exported records"`
+	Manifest ExportManifest `json:"manifest" required:"true" description:"Manifest of the page"`
+	Bookmark string         `json:"bookmark" required:"false" description:"Bookmark of the next page, empty for the last one"`
+}
+
+// ArchiveRecord is a key of a private data collection with its value.
+type ArchiveRecord struct {
+	Key   string `json:"key" required:"true" description:"Composite key of the record"`
+	Value []byte `json:"value" required:"true" description:"Base64 encoded stored value of the record"`
+}
+
+// PrivateDataArchive is a backup of PNR records kept in the private data
+// collection of a PIU. The PIU signs the digest of its records with the key
+// registered as its signing key before the archive can be restored.
+type PrivateDataArchive struct {
+	PIU        string          `json:"piu" required:"true" description:"Id of the PIU whose records are archived"`
+	Collection string          `json:"collection" required:"true" description:"Name of the archived private data collection"`
+	Timestamp  time.Time       `json:"timestamp" required:"true" description:"Time the archive was created at"`
+	Records    []ArchiveRecord `json:"records" required:"true" description:"Archived records"`
+	SHA256     string          `json:"sha256" required:"true" description:"Hex encoded digest of the records"`
+	Signature  []byte          `json:"signature" required:"false" description:"Base64 encoded signature of the digest by the PIU"`
+}
+
+// Digest returns SHA-256 over the JSON encoded records of the archive.
+func (a PrivateDataArchive) Digest() ([]byte, error) {
+	records, err := json.Marshal(a.Records)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(records)
+
+	return sum[:], nil
+}
+
+type BackupPrivateDataInput struct{}
+
+type RestorePrivateDataInput struct {
+	Archive *json.RawMessage `json:"archive" required:"true" description:"Signed archive of private data"`
+}
+
+type ReconcilePrivateDataInput struct {
+	PIU string `json:"piu" required:"true" description:"Id of the PIU whose copies of shared records are restored"`
+}
+
+// RestorePrivateDataOutput reports the outcome of restoring records, keys
+// are written as the object type followed by attributes separated by colons.
+type RestorePrivateDataOutput struct {
+	Restored   int      `json:"restored" required:"true" description:"Number of restored records"`
+	Present    int      `json:"present" required:"true" description:"Number of records which were not lost"`
+	Purged     []string `json:"purged" required:"false" description:"Keys of records without a hash on the ledger, which were purged or never stored"`
+	Mismatched []string `json:"mismatched" required:"false" description:"Keys of records which do not match their hash on the ledger"`
+}
+
+// RecordCopy is the hash of a copy of a record in a private data collection.
+type RecordCopy struct {
+	Collection string `json:"collection" required:"true" description:"Name of the private data collection"`
+	SHA256     string `json:"sha256" required:"false" description:"Hex encoded hash of the copy on the ledger, empty when the copy is missing"`
+}
+
+// KeyConsistency compares copies of a record, keys are written as the object
+// type followed by attributes separated by colons.
+type KeyConsistency struct {
+	Key        string       `json:"key" required:"true" description:"Key of the record"`
+	Copies     []RecordCopy `json:"copies" required:"true" description:"Copies of the record"`
+	Consistent bool         `json:"consistent" required:"true" description:"All copies of the record are equal"`
+}
+
+type PNRConsistency struct {
+	Id         string           `json:"id" required:"true" description:"Id of PNR request"`
+	Consistent bool             `json:"consistent" required:"true" description:"All copies of all records of the PNR request are equal"`
+	Keys       []KeyConsistency `json:"keys" required:"true" description:"Comparison of copies of records of the PNR request"`
+}
+
+type VerifyPNRConsistencyInput struct {
+	Id string `json:"id" required:"true" description:"Id of PNR request"`
+}
+
+type VerifyAllPNRConsistencyInput struct{}
+
+type VerifyPNRResponseSignatureInput struct {
+	Id string `json:"id" required:"true" description:"Id of PNR request"`
+}
+
+// PNRResponseSignature is the outcome of verifying the signature of a PNR
+// response against the signing certificate registered by the responder.
+type PNRResponseSignature struct {
+	Id           string `json:"id" required:"true" description:"Id of PNR request"`
+	Signed       bool   `json:"signed" required:"true" description:"The response carries a signature"`
+	Valid        bool   `json:"valid" required:"true" description:"The signature and certificate of the response are valid"`
+	DataVerified bool   `json:"dataVerified" required:"true" description:"The response data is still stored and matches the signed digest"`
+	Signer       string `json:"signer" required:"false" description:"Subject of the certificate the response was signed with"`
+	Reason       string `json:"reason" required:"false" description:"Reason the signature is not valid"`
+}
+
+type PNRSortField string
+
+const (
+	PNRSortByRequestTimestamp  PNRSortField = "requestTimestamp"
+	PNRSortByResponseTimestamp PNRSortField = "responseTimestamp"
+	PNRSortByPriority          PNRSortField = "priority"
+	PNRSortByDeadline          PNRSortField = "deadline"
+)
+
+func IsValidPNRSortField(field PNRSortField) bool {
+	switch field {
+	case "", PNRSortByRequestTimestamp, PNRSortByResponseTimestamp, PNRSortByPriority, PNRSortByDeadline:
+		return true
+	default:
+		return false
+	}
+}
+
+func comparePNRs(field PNRSortField, a PNR, b PNR) int {
+	switch field {
+	case PNRSortByRequestTimestamp:
+		return a.RequestTimestamp.Compare(b.RequestTimestamp)
+	case PNRSortByResponseTimestamp:
+		return a.ResponseTimestamp.Compare(b.ResponseTimestamp)
+	case PNRSortByPriority:
+		return cmp.Compare(priorityRank(a.Priority), priorityRank(b.Priority))
+	case PNRSortByDeadline:
+		return a.Deadline.Compare(b.Deadline)
+	default:
+		return 0
+	}
+}
+
+// SortPNRs sorts PNR requests in place by given field. Requests with equal
+// values are ordered by their id so the result does not depend on the order
+// returned by the repository.
+func SortPNRs(pnrs []PNR, field PNRSortField, descending bool) {
+	slices.SortFunc(pnrs, func(a PNR, b PNR) int {
+		if descending {
+			a, b = b, a
+		}
+		return cmp.Or(comparePNRs(field, a, b), cmp.Compare(a.Id, b.Id))
+	})
 }
 
 type NewPNRRequestInput struct {
-	Id               string           `json:"id" required:"true" format:"uuid" description:"Id of PNR request"`
-	RespondingPIU    string           `query:"respondingPIU" required:"true" description:"Id of responding PIU"`
-	RequestTimestamp time.Time        `json:"requestTimestamp" required:"true" description:"Timestamp of request"`
-	RequestData      *json.RawMessage `json:"requestData"`
+	Id                      string           `json:"id" required:"true" format:"uuid" description:"Id of PNR request"`
+	RespondingPIU           string           `query:"respondingPIU" required:"true" description:"Id of responding PIU"`
+	RequestTimestamp        time.Time        `json:"requestTimestamp" required:"true" description:"Timestamp of request"`
+	RequestData             *json.RawMessage `json:"requestData"`
+	ParentId                string           `json:"parentId" required:"false" format:"uuid" description:"Id of the PNR request this request follows up on"`
+	AllowOnBehalfForwarding bool             `json:"allowOnBehalfForwarding" required:"false" description:"Responder may forward the request to another PIU on behalf of the requester"`
+	Priority                RequestPriority  `json:"priority" required:"false" enum:"Routine,Urgent,Immediate" description:"Priority of the PNR request, Routine if not set"`
+	PayloadProfile          string           `json:"payloadProfile" required:"false" description:"Payload profile of request and response data, must be supported by responding PIU"`
+	Purpose                 string           `json:"purpose" required:"true" description:"Purpose or offence category the PNR request is made for, must be allowed by an active agreement"`
+	DataKey                 *DataKey         `json:"-"`
 }
 
 type NewPNRRequestOutput struct {
@@ -162,9 +848,12 @@ type NewPNRRequestOutput struct {
 }
 
 type SubmitPNRResponseInput struct {
-	Id                string           `query:"id" required:"true" format:"uuid"`
-	ResponseTimestamp time.Time        `json:"responseTimestamp" required:"true" description:"Timestamp of response"`
-	ResponseData      *json.RawMessage `json:"responseData"`
+	Id                  string           `query:"id" required:"true" format:"uuid"`
+	ResponseTimestamp   time.Time        `json:"responseTimestamp" required:"true" description:"Timestamp of response"`
+	ResponseData        *json.RawMessage `json:"responseData"`
+	ResponseSignature   []byte           `json:"responseSignature" required:"false" description:"Detached signature of the SHA-256 hash of the JCS canonical response data"`
+	ResponseCertificate string           `json:"responseCertificate" required:"false" description:"PEM encoded certificate the response was signed with"`
+	DataKey             *DataKey         `json:"-"`
 }
 
 type SubmitPNRResponseOutput struct {
@@ -177,6 +866,15 @@ type ConfirmPNRInput struct {
 type ConfirmPNROutput struct {
 }
 
+type ApprovePNRDraftInput struct {
+	Id             string   `query:"id" required:"true" format:"uuid"`
+	ResponseDigest string   `json:"responseDigest" required:"false" description:"Hex encoded SHA-256 hash of the canonical form of the reviewed response data, required to approve a response"`
+	DataKey        *DataKey `json:"-"`
+}
+
+type ApprovePNRDraftOutput struct {
+}
+
 type TerminatePNRRequestInput struct {
 	Id string `query:"id" required:"true" format:"uuid"`
 }
@@ -184,7 +882,147 @@ type TerminatePNRRequestInput struct {
 type TerminatePNRRequestOutput struct {
 }
 
+type ClarificationInput struct {
+	Id        string           `query:"id" required:"true" format:"uuid"`
+	Timestamp time.Time        `json:"timestamp" required:"true" description:"Timestamp of the message"`
+	Message   *json.RawMessage `json:"message"`
+}
+
+type ClarificationOutput struct {
+}
+
+type GetPNRClarificationsInput struct {
+	Id string `query:"id" required:"true" format:"uuid"`
+}
+
+type ClarificationMessage struct {
+	Id        string    `json:"id" required:"true" format:"uuid" description:"Id of PNR request"`
+	Sequence  int       `json:"sequence" required:"true" description:"Order of the message within the PNR request"`
+	Author    string    `json:"author" required:"true" description:"Id of PIU which wrote the message"`
+	Timestamp time.Time `json:"timestamp" required:"true" description:"Timestamp of the message"`
+	Message   string    `json:"message" required:"true" description:"Clarification message data"`
+}
+
+type ForwardPNRRequestInput struct {
+	Id                      string           `query:"id" required:"true" format:"uuid" description:"Id of the forwarded PNR request"`
+	NewId                   string           `json:"newId" required:"true" format:"uuid" description:"Id of the new PNR request"`
+	RespondingPIU           string           `json:"respondingPIU" required:"true" description:"Id of PIU the request is forwarded to"`
+	ForwardTimestamp        time.Time        `json:"forwardTimestamp" required:"true" description:"Timestamp of forwarding"`
+	OnBehalfOfRequester     bool             `json:"onBehalfOfRequester" required:"false" description:"Create the new request on behalf of the original requester"`
+	AllowOnBehalfForwarding bool             `json:"allowOnBehalfForwarding" required:"false" description:"Allow the new responder to forward the request on behalf of the forwarding PIU, the consent of the original requester is kept when forwarding on its behalf"`
+	RequestData             *json.RawMessage `json:"requestData" required:"false" description:"Request data encrypted to the PIU the request is forwarded to, required when the forwarded request data is encrypted"`
+	DataKey                 *DataKey         `json:"-"`
+}
+
+type ForwardPNRRequestOutput struct {
+	Id string `json:"id" required:"true" format:"uuid" description:"Id of the new PNR request"`
+}
+
+const PNRForwardedEventName string = "PNRForwarded"
+
+type Notification struct {
+	Recipients []string     `json:"recipients" required:"true" description:"Ids of PIUs the notification is addressed to"`
+	Id         string       `json:"id" required:"true" format:"uuid" description:"Id of PNR request"`
+	ParentId   string       `json:"parentId" required:"false" format:"uuid" description:"Id of the parent PNR request"`
+	State      RequestState `json:"state" required:"true" description:"State of the PNR request"`
+	Sender     string       `json:"sender" required:"true" description:"Id of PIU which caused the notification"`
+}
+
+type GetPNRThreadInput struct {
+	Id string `query:"id" required:"true" format:"uuid"`
+}
+
+type AgreementState string
+
+const (
+	AgreementStateProposed AgreementState = "Proposed"
+	AgreementStateActive   AgreementState = "Active"
+	AgreementStateRevoked  AgreementState = "Revoked"
+)
+
+type Agreement struct {
+	Id                string         `json:"id" required:"true" format:"uuid" description:"Id of the agreement"`
+	ProposingPIU      string         `json:"proposingPIU" required:"true" description:"Id of PIU which proposed the agreement"`
+	CounterpartyPIU   string         `json:"counterpartyPIU" required:"true" description:"Id of the other PIU of the agreement"`
+	Purposes          []string       `json:"purposes" required:"true" description:"Purposes or offence categories PNR requests may be made for"`
+	ValidFrom         time.Time      `json:"validFrom" required:"true" description:"Start of the validity period"`
+	ValidTo           time.Time      `json:"validTo" required:"false" description:"End of the validity period, unlimited if not set"`
+	MaxRequests       int            `json:"maxRequests" required:"false" description:"Maximum number of PNR requests covered by the agreement, unlimited if not set"`
+	State             AgreementState `json:"state" required:"true" enum:"Proposed,Active,Revoked" description:"State of the agreement"`
+	SignedBy          []string       `json:"signedBy" required:"true" description:"Ids of PIUs which signed the agreement"`
+	ProposalTimestamp time.Time      `json:"proposalTimestamp" required:"true" description:"Timestamp of the proposal"`
+}
+
+// AgreementUsage holds the number of PNR requests sent under an agreement.
+type AgreementUsage struct {
+	AgreementId string `json:"agreementId" required:"true" description:"Id of the agreement"`
+	Count       int    `json:"count" required:"true" description:"Number of PNR requests sent under the agreement"`
+}
+
+func IsAgreementParty(agreement Agreement, piuId string) bool {
+	return agreement.ProposingPIU == piuId || agreement.CounterpartyPIU == piuId
+}
+
+// IsCoveringAgreement reports whether an agreement allows a PNR request
+// between the PIUs for given purpose at given time. Request volume is not
+// considered.
+func IsCoveringAgreement(agreement Agreement, requestingPIU string, respondingPIU string, purpose string, timestamp time.Time) bool {
+	if agreement.State != AgreementStateActive {
+		return false
+	}
+
+	if !IsAgreementParty(agreement, requestingPIU) || !IsAgreementParty(agreement, respondingPIU) || requestingPIU == respondingPIU {
+		return false
+	}
+
+	if !slices.Contains(agreement.Purposes, purpose) {
+		return false
+	}
+
+	if timestamp.Before(agreement.ValidFrom) {
+		return false
+	}
+
+	if (agreement.ValidTo != time.Time{}) && timestamp.After(agreement.ValidTo) {
+		return false
+	}
+
+	return true
+}
+
+type ProposeAgreementInput struct {
+	Id                string    `json:"id" required:"true" format:"uuid" description:"Id of the agreement"`
+	CounterpartyPIU   string    `json:"counterpartyPIU" required:"true" description:"Id of the other PIU of the agreement"`
+	Purposes          []string  `json:"purposes" required:"true" description:"Purposes or offence categories PNR requests may be made for"`
+	ValidFrom         time.Time `json:"validFrom" required:"true" description:"Start of the validity period"`
+	ValidTo           time.Time `json:"validTo" required:"false" description:"End of the validity period, unlimited if not set"`
+	MaxRequests       int       `json:"maxRequests" required:"false" description:"Maximum number of PNR requests covered by the agreement, unlimited if not set"`
+	ProposalTimestamp time.Time `json:"proposalTimestamp" required:"true" description:"Timestamp of the proposal"`
+}
+
+type ProposeAgreementOutput struct {
+	Id string `json:"id" required:"true" format:"uuid" description:"Id of the agreement"`
+}
+
+type SignAgreementInput struct {
+	Id string `query:"id" required:"true" format:"uuid"`
+}
+
+type SignAgreementOutput struct {
+}
+
+type RevokeAgreementInput struct {
+	Id string `query:"id" required:"true" format:"uuid"`
+}
+
+type RevokeAgreementOutput struct {
+}
+
+type GetAgreementsInput struct {
+}
+
 type GCMetadata struct {
 	Id                string    `json:"id" required:"true" format:"uuid" description:"Id of PNR request"`
 	CreationTimestamp time.Time `json:"creationTimestamp" required:"true" description:"Creation timestamp of the PNR record"`
+	PurgeDeadline     time.Time `json:"purgeDeadline" required:"false" description:"Time by which the PNR record has to be purged according to the retention of the consortium, zero for no limit"`
 }
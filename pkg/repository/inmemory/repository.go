@@ -2,6 +2,7 @@ package inmemory
 
 import (
 	"errors"
+	"time"
 
 	"github.com/nesfit/tenacity-chaincode/pkg/entities"
 )
@@ -10,6 +11,18 @@ type InMemoryRepository struct {
 	pius        map[string]entities.PIU
 	pnrs        map[string]entities.PNR
 	gcMetadatas map[string]entities.GCMetadata
+	msgs        map[string][]entities.ClarificationMessage
+	piuHistory  map[string][]entities.PIUHistoryEntry
+	agreements  map[string]entities.Agreement
+	usages      map[string]entities.AgreementUsage
+	quotas      map[quotaKey]entities.QuotaCounter
+	config      *entities.ConsortiumConfig
+}
+
+type quotaKey struct {
+	piuId       string
+	counterpart string
+	bucket      time.Time
 }
 
 func NewInMemoryRepository() *InMemoryRepository {
@@ -17,6 +30,11 @@ func NewInMemoryRepository() *InMemoryRepository {
 		pius:        make(map[string]entities.PIU),
 		pnrs:        make(map[string]entities.PNR),
 		gcMetadatas: make(map[string]entities.GCMetadata),
+		msgs:        make(map[string][]entities.ClarificationMessage),
+		piuHistory:  make(map[string][]entities.PIUHistoryEntry),
+		agreements:  make(map[string]entities.Agreement),
+		usages:      make(map[string]entities.AgreementUsage),
+		quotas:      make(map[quotaKey]entities.QuotaCounter),
 	}
 }
 
@@ -46,6 +64,14 @@ func (r *InMemoryRepository) GetPIUs() ([]entities.PIU, error) {
 	return result, nil
 }
 
+func (r *InMemoryRepository) GetPIUHistory(id string) ([]entities.PIUHistoryEntry, error) {
+	result := make([]entities.PIUHistoryEntry, len(r.piuHistory[id]))
+
+	copy(result, r.piuHistory[id])
+
+	return result, nil
+}
+
 func (r *InMemoryRepository) InsertPIU(id string, piu entities.PIU) error {
 	exists, _ := r.PIUExists(id)
 
@@ -54,6 +80,7 @@ func (r *InMemoryRepository) InsertPIU(id string, piu entities.PIU) error {
 	}
 
 	r.pius[id] = piu
+	r.piuHistory[id] = append(r.piuHistory[id], entities.PIUHistoryEntry{PIU: piu})
 
 	return nil
 }
@@ -66,6 +93,111 @@ func (r *InMemoryRepository) UpdatePIU(id string, piu entities.PIU) error {
 	}
 
 	r.pius[id] = piu
+	r.piuHistory[id] = append(r.piuHistory[id], entities.PIUHistoryEntry{PIU: piu})
+
+	return nil
+}
+
+func (r *InMemoryRepository) AgreementExists(id string) (bool, error) {
+	_, ok := r.agreements[id]
+
+	return ok, nil
+}
+
+func (r *InMemoryRepository) GetAgreement(id string) (entities.Agreement, error) {
+	entity, ok := r.agreements[id]
+
+	if !ok {
+		return entities.Agreement{}, errors.New("agreement not found")
+	}
+
+	return entity, nil
+}
+
+func (r *InMemoryRepository) GetAgreements() ([]entities.Agreement, error) {
+	result := make([]entities.Agreement, 0, len(r.agreements))
+
+	for _, entity := range r.agreements {
+		result = append(result, entity)
+	}
+
+	return result, nil
+}
+
+func (r *InMemoryRepository) InsertAgreement(id string, agreement entities.Agreement) error {
+	exists, _ := r.AgreementExists(id)
+
+	if exists {
+		return errors.New("agreement already exists")
+	}
+
+	r.agreements[id] = agreement
+
+	return nil
+}
+
+func (r *InMemoryRepository) UpdateAgreement(id string, agreement entities.Agreement) error {
+	exists, _ := r.AgreementExists(id)
+
+	if !exists {
+		return errors.New("agreement does not exist")
+	}
+
+	r.agreements[id] = agreement
+
+	return nil
+}
+
+func (r *InMemoryRepository) GetAgreementUsage(id string) (entities.AgreementUsage, error) {
+	entity, ok := r.usages[id]
+
+	if !ok {
+		return entities.AgreementUsage{AgreementId: id}, nil
+	}
+
+	return entity, nil
+}
+
+func (r *InMemoryRepository) PutAgreementUsage(usage entities.AgreementUsage) error {
+	r.usages[usage.AgreementId] = usage
+
+	return nil
+}
+
+func (r *InMemoryRepository) ConfigExists() (bool, error) {
+	return r.config != nil, nil
+}
+
+func (r *InMemoryRepository) GetConfig() (entities.ConsortiumConfig, error) {
+	if r.config == nil {
+		return entities.ConsortiumConfig{}, errors.New("config not found")
+	}
+
+	return *r.config, nil
+}
+
+func (r *InMemoryRepository) PutConfig(config entities.ConsortiumConfig) error {
+	r.config = &config
+
+	return nil
+}
+
+func (r *InMemoryRepository) GetQuotaCounter(piuId string, counterpart string, bucket time.Time) (entities.QuotaCounter, error) {
+	bucket = entities.GetQuotaBucket(bucket)
+
+	entity, ok := r.quotas[quotaKey{piuId, counterpart, bucket}]
+
+	if !ok {
+		return entities.QuotaCounter{PIU: piuId, Counterpart: counterpart, Bucket: bucket}, nil
+	}
+
+	return entity, nil
+}
+
+func (r *InMemoryRepository) PutQuotaCounter(counter entities.QuotaCounter) error {
+	counter.Bucket = entities.GetQuotaBucket(counter.Bucket)
+
+	r.quotas[quotaKey{counter.PIU, counter.Counterpart, counter.Bucket}] = counter
 
 	return nil
 }
@@ -110,6 +242,20 @@ func (r *InMemoryRepository) InsertPNR(id string, pnr entities.PNR) error {
 	return nil
 }
 
+func (r *InMemoryRepository) InsertLocalPNR(id string, pnr entities.PNR) error {
+	return r.InsertPNR(id, pnr)
+}
+
+func (r *InMemoryRepository) InsertForwardedPNR(pnr entities.PNR, gc entities.GCMetadata) error {
+	err := r.InsertPNR(pnr.Id, pnr)
+
+	if err != nil {
+		return err
+	}
+
+	return r.InsertGCMetadata(pnr, gc)
+}
+
 func (r *InMemoryRepository) UpdatePNR(id string, pnr entities.PNR) error {
 	exists, _ := r.PNRExists(id)
 
@@ -133,8 +279,7 @@ func (r *InMemoryRepository) PurgePNRData(id string) error {
 		return err
 	}
 
-	pnr.RequestData = ""
-	pnr.ResponseData = ""
+	pnr = entities.WithoutData(pnr)
 
 	r.UpdatePNR(id, pnr)
 
@@ -211,5 +356,61 @@ func (r *InMemoryRepository) GetGCMetadatas() ([]entities.GCMetadata, error) {
 	return result, nil
 }
 
+func (r *InMemoryRepository) InsertPNRClarification(pnr entities.PNR, msg entities.ClarificationMessage) error {
+	for _, existing := range r.msgs[pnr.Id] {
+		if existing.Sequence == msg.Sequence {
+			return errors.New("clarification already exists")
+		}
+	}
+
+	r.msgs[pnr.Id] = append(r.msgs[pnr.Id], msg)
+
+	return nil
+}
+
+func (r *InMemoryRepository) GetPNRClarifications(id string) ([]entities.ClarificationMessage, error) {
+	result := make([]entities.ClarificationMessage, 0, len(r.msgs[id]))
+
+	result = append(result, r.msgs[id]...)
+
+	return result, nil
+}
+
+func (r *InMemoryRepository) PurgePNRClarifications(id string) error {
+	delete(r.msgs, id)
+
+	return nil
+}
+
+func (r *InMemoryRepository) PurgeLocalPNRClarifications(id string) error {
+	return r.PurgePNRClarifications(id)
+}
+
+// MigrateData does nothing, the repository keeps entities instead of stored
+// models.
+func (r *InMemoryRepository) MigrateData(bookmark string, limit int) (entities.MigrateDataOutput, error) {
+	return entities.MigrateDataOutput{}, nil
+}
+
+// BackupLocalRecords returns an empty archive, the repository keeps no
+// private data.
+func (r *InMemoryRepository) BackupLocalRecords() (entities.PrivateDataArchive, error) {
+	return entities.PrivateDataArchive{Records: []entities.ArchiveRecord{}}, nil
+}
+
+func (r *InMemoryRepository) RestoreLocalRecords(records []entities.ArchiveRecord) (entities.RestorePrivateDataOutput, error) {
+	return entities.RestorePrivateDataOutput{}, nil
+}
+
+func (r *InMemoryRepository) RestoreSharedRecords(piuId string) (entities.RestorePrivateDataOutput, error) {
+	return entities.RestorePrivateDataOutput{}, nil
+}
+
+// ComparePNRCopies returns no comparisons, the repository keeps a single
+// copy of every record.
+func (r *InMemoryRepository) ComparePNRCopies(pnr entities.PNR) ([]entities.KeyConsistency, error) {
+	return []entities.KeyConsistency{}, nil
+}
+
 func (r *InMemoryRepository) Close() {
 }
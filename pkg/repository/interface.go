@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/nesfit/tenacity-chaincode/pkg/entities"
 )
 
@@ -8,12 +10,27 @@ type Repository interface {
 	PIUExists(id string) (bool, error)
 	GetPIU(id string) (entities.PIU, error)
 	GetPIUs() ([]entities.PIU, error)
+	GetPIUHistory(id string) ([]entities.PIUHistoryEntry, error)
 	InsertPIU(id string, piu entities.PIU) error
 	UpdatePIU(id string, piu entities.PIU) error
+	AgreementExists(id string) (bool, error)
+	GetAgreement(id string) (entities.Agreement, error)
+	GetAgreements() ([]entities.Agreement, error)
+	InsertAgreement(id string, agreement entities.Agreement) error
+	UpdateAgreement(id string, agreement entities.Agreement) error
+	GetAgreementUsage(id string) (entities.AgreementUsage, error)
+	PutAgreementUsage(usage entities.AgreementUsage) error
+	ConfigExists() (bool, error)
+	GetConfig() (entities.ConsortiumConfig, error)
+	PutConfig(config entities.ConsortiumConfig) error
+	GetQuotaCounter(piuId string, counterpart string, bucket time.Time) (entities.QuotaCounter, error)
+	PutQuotaCounter(counter entities.QuotaCounter) error
 	PNRExists(id string) (bool, error)
 	GetPNR(id string) (entities.PNR, error)
 	GetPNRs(filter entities.PNRFilter) ([]entities.PNR, error)
 	InsertPNR(id string, pnr entities.PNR) error
+	InsertLocalPNR(id string, pnr entities.PNR) error
+	InsertForwardedPNR(pnr entities.PNR, gc entities.GCMetadata) error
 	UpdatePNR(id string, pnr entities.PNR) error
 	UpdateLocalPNR(id string, pnr entities.PNR) error
 	PurgePNRData(id string) error
@@ -25,6 +42,15 @@ type Repository interface {
 	DeleteLocalGCMetadata(id string) error
 	GetGCMetadata(id string) (entities.GCMetadata, error)
 	GetGCMetadatas() ([]entities.GCMetadata, error)
+	InsertPNRClarification(pnr entities.PNR, msg entities.ClarificationMessage) error
+	GetPNRClarifications(id string) ([]entities.ClarificationMessage, error)
+	PurgePNRClarifications(id string) error
+	PurgeLocalPNRClarifications(id string) error
+	MigrateData(bookmark string, limit int) (entities.MigrateDataOutput, error)
+	BackupLocalRecords() (entities.PrivateDataArchive, error)
+	RestoreLocalRecords(records []entities.ArchiveRecord) (entities.RestorePrivateDataOutput, error)
+	RestoreSharedRecords(piuId string) (entities.RestorePrivateDataOutput, error)
+	ComparePNRCopies(pnr entities.PNR) ([]entities.KeyConsistency, error)
 	Close()
 }
 
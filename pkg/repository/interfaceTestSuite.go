@@ -198,6 +198,174 @@ func (s *RepositoryTestSuite) TestUpdatePIUDoesNotExist() {
 	assert.Error(err)
 }
 
+func (s *RepositoryTestSuite) TestGetAgreementEmpty() {
+	assert := assert.New(s.T())
+
+	_, err := s.r.GetAgreement(testdata.Agreements[0].Id)
+	assert.Error(err)
+
+	actual, err := s.r.GetAgreements()
+	assert.NoError(err)
+	assert.Empty(actual)
+}
+
+func (s *RepositoryTestSuite) TestInsertAgreement() {
+	assert := assert.New(s.T())
+
+	s.txm.Start()
+	for _, agreement := range testdata.Agreements {
+		err := s.r.InsertAgreement(agreement.Id, agreement)
+		assert.NoError(err)
+	}
+	s.txm.End()
+
+	exists, _ := s.r.AgreementExists(testdata.Agreements[1].Id)
+	assert.True(exists)
+
+	actual, err := s.r.GetAgreement(testdata.Agreements[1].Id)
+	assert.NoError(err)
+	assert.Equal(testdata.Agreements[1], actual)
+
+	all, _ := s.r.GetAgreements()
+	assert.ElementsMatch(testdata.Agreements, all)
+}
+
+func (s *RepositoryTestSuite) TestInsertAgreementAlreadyExists() {
+	assert := assert.New(s.T())
+
+	inserted := testdata.Agreements[0]
+
+	s.txm.Start()
+	s.r.InsertAgreement(inserted.Id, inserted)
+	s.txm.End()
+
+	s.txm.Start()
+	err := s.r.InsertAgreement(inserted.Id, inserted)
+	s.txm.End()
+	assert.Error(err)
+}
+
+func (s *RepositoryTestSuite) TestUpdateAgreement() {
+	assert := assert.New(s.T())
+
+	updated := testdata.Agreements[0]
+	updated.State = entities.AgreementStateRevoked
+
+	s.txm.Start()
+	s.r.InsertAgreement(testdata.Agreements[0].Id, testdata.Agreements[0])
+	s.txm.End()
+
+	s.txm.Start()
+	err := s.r.UpdateAgreement(updated.Id, updated)
+	s.txm.End()
+	assert.NoError(err)
+
+	actual, _ := s.r.GetAgreement(updated.Id)
+	assert.Equal(updated, actual)
+
+	s.txm.Start()
+	err = s.r.UpdateAgreement("missing", updated)
+	s.txm.End()
+	assert.Error(err)
+}
+
+func (s *RepositoryTestSuite) TestConfig() {
+	assert := assert.New(s.T())
+
+	exists, err := s.r.ConfigExists()
+	assert.NoError(err)
+	assert.False(exists)
+
+	_, err = s.r.GetConfig()
+	assert.Error(err)
+
+	expected := entities.DefaultConsortiumConfig()
+	expected.Version = 1
+	expected.AdminMSPs = []string{testdata.PIUs[0].Id}
+
+	s.txm.Start()
+	err = s.r.PutConfig(expected)
+	s.txm.End()
+	assert.NoError(err)
+
+	exists, err = s.r.ConfigExists()
+	assert.NoError(err)
+	assert.True(exists)
+
+	actual, err := s.r.GetConfig()
+	assert.NoError(err)
+	assert.Equal(expected, actual)
+}
+
+func (s *RepositoryTestSuite) TestMigrateDataCurrent() {
+	assert := assert.New(s.T())
+
+	s.txm.Start()
+	err := s.r.InsertPIU(testdata.PIUs[0].Id, testdata.PIUs[0])
+	s.txm.End()
+	assert.NoError(err)
+
+	s.txm.Start()
+	err = s.r.InsertPNR(testdata.PNRs[0].Id, testdata.PNRs[0])
+	s.txm.End()
+	assert.NoError(err)
+
+	s.txm.Start()
+	actual, err := s.r.MigrateData("", 1)
+	s.txm.End()
+	assert.NoError(err)
+	assert.Equal(entities.MigrateDataOutput{}, actual)
+}
+
+func (s *RepositoryTestSuite) TestAgreementUsage() {
+	assert := assert.New(s.T())
+
+	id := testdata.Agreements[0].Id
+
+	actual, err := s.r.GetAgreementUsage(id)
+	assert.NoError(err)
+	assert.Equal(entities.AgreementUsage{AgreementId: id}, actual)
+
+	s.txm.Start()
+	err = s.r.PutAgreementUsage(entities.AgreementUsage{AgreementId: id, Count: 2})
+	s.txm.End()
+	assert.NoError(err)
+
+	actual, err = s.r.GetAgreementUsage(id)
+	assert.NoError(err)
+	assert.Equal(entities.AgreementUsage{AgreementId: id, Count: 2}, actual)
+
+	other, _ := s.r.GetAgreementUsage(testdata.Agreements[1].Id)
+	assert.Zero(other.Count)
+}
+
+func (s *RepositoryTestSuite) TestQuotaCounter() {
+	assert := assert.New(s.T())
+
+	piuId := testdata.PIUs[0].Id
+	counterpart := testdata.PIUs[1].Id
+	bucket := entities.GetQuotaBucket(testdata.MiddleTimestamp)
+
+	actual, err := s.r.GetQuotaCounter(piuId, counterpart, testdata.MiddleTimestamp)
+	assert.NoError(err)
+	assert.Equal(entities.QuotaCounter{PIU: piuId, Counterpart: counterpart, Bucket: bucket}, actual)
+
+	s.txm.Start()
+	err = s.r.PutQuotaCounter(entities.QuotaCounter{PIU: piuId, Counterpart: counterpart, Bucket: testdata.MiddleTimestamp, Count: 3})
+	s.txm.End()
+	assert.NoError(err)
+
+	actual, err = s.r.GetQuotaCounter(piuId, counterpart, testdata.MiddleTimestamp)
+	assert.NoError(err)
+	assert.Equal(entities.QuotaCounter{PIU: piuId, Counterpart: counterpart, Bucket: bucket, Count: 3}, actual)
+
+	total, _ := s.r.GetQuotaCounter(piuId, "", testdata.MiddleTimestamp)
+	assert.Zero(total.Count)
+
+	next, _ := s.r.GetQuotaCounter(piuId, counterpart, bucket.Add(entities.QuotaBucketDuration))
+	assert.Zero(next.Count)
+}
+
 func (s *RepositoryTestSuite) TestPNRExistsEmpty() {
 	assert := assert.New(s.T())
 
@@ -341,6 +509,30 @@ func (s *RepositoryTestSuite) TestGetPNRsNonEmpty() {
 				return v.RespondingPIU == testdata.PNRs[1].RespondingPIU
 			}),
 		},
+		"parentId": {
+			Filter: entities.PNRFilter{
+				ParentId: testdata.PNRs[0].Id,
+			},
+			Expected: lo.Filter(testdata.PNRs, func(v entities.PNR, i int) bool {
+				return v.ParentId == testdata.PNRs[0].Id
+			}),
+		},
+		"priority": {
+			Filter: entities.PNRFilter{
+				Priority: entities.RequestPriorityUrgent,
+			},
+			Expected: lo.Filter(testdata.PNRs, func(v entities.PNR, i int) bool {
+				return v.Priority == entities.RequestPriorityUrgent
+			}),
+		},
+		"routinePriority": {
+			Filter: entities.PNRFilter{
+				Priority: entities.RequestPriorityRoutine,
+			},
+			Expected: lo.Filter(testdata.PNRs, func(v entities.PNR, i int) bool {
+				return v.Priority == "" || v.Priority == entities.RequestPriorityRoutine
+			}),
+		},
 		"exact": {
 			Filter: entities.PNRFilter{
 				Start:         testdata.PNRs[1].RequestTimestamp.Add(-1 * time.Microsecond),
@@ -414,6 +606,65 @@ func (s *RepositoryTestSuite) TestInsertPNRAlreadyExists() {
 	assert.ElementsMatch(expected, actual)
 }
 
+func (s *RepositoryTestSuite) TestInsertLocalPNR() {
+	assert := assert.New(s.T())
+
+	insertedPNR := testdata.PNRs[1]
+	insertedPNR.State = entities.RequestStateDraft
+	insertedPNR.DraftedBy = "officer"
+
+	s.txm.Start()
+	err := s.r.InsertLocalPNR(insertedPNR.Id, insertedPNR)
+	s.txm.End()
+	assert.NoError(err)
+
+	actual, err := s.r.GetPNR(insertedPNR.Id)
+	assert.NoError(err)
+	assert.Equal(insertedPNR, actual)
+
+	s.txm.Start()
+	err = s.r.InsertLocalPNR(insertedPNR.Id, insertedPNR)
+	s.txm.End()
+	assert.Error(err)
+}
+
+func (s *RepositoryTestSuite) TestInsertForwardedPNR() {
+	assert := assert.New(s.T())
+
+	insertedPNR := testdata.PNRs[0]
+	gc := entities.GCMetadata{Id: insertedPNR.Id, CreationTimestamp: insertedPNR.RequestTimestamp}
+
+	s.txm.Start()
+	err := s.r.InsertForwardedPNR(insertedPNR, gc)
+	s.txm.End()
+	assert.NoError(err)
+
+	actualPNRs, _ := s.r.GetPNRs(entities.PNRFilter{})
+	assert.ElementsMatch([]entities.PNR{insertedPNR}, actualPNRs)
+
+	actualGCs, _ := s.r.GetGCMetadatas()
+	assert.ElementsMatch([]entities.GCMetadata{gc}, actualGCs)
+}
+
+func (s *RepositoryTestSuite) TestInsertForwardedPNRAlreadyExists() {
+	assert := assert.New(s.T())
+
+	insertedPNR := testdata.PNRs[0]
+	gc := entities.GCMetadata{Id: insertedPNR.Id, CreationTimestamp: insertedPNR.RequestTimestamp}
+
+	s.txm.Start()
+	s.r.InsertPNR(insertedPNR.Id, insertedPNR)
+	s.txm.End()
+
+	s.txm.Start()
+	err := s.r.InsertForwardedPNR(insertedPNR, gc)
+	s.txm.End()
+	assert.Error(err)
+
+	actual, _ := s.r.GetGCMetadatas()
+	assert.Empty(actual)
+}
+
 func (s *RepositoryTestSuite) TestUpdatePNR() {
 	assert := assert.New(s.T())
 
@@ -597,3 +848,67 @@ func (s *RepositoryTestSuite) TestDeleteGCMetadata() {
 	assert.NoError(err)
 	assert.ElementsMatch(expected, actual)
 }
+
+func (s *RepositoryTestSuite) TestInsertPNRClarification() {
+	assert := assert.New(s.T())
+
+	pnr := testdata.PNRs[0]
+	msgs := []entities.ClarificationMessage{
+		{Id: pnr.Id, Sequence: 1, Author: pnr.RespondingPIU, Timestamp: testdata.MiddleTimestamp, Message: "\"question\""},
+		{Id: pnr.Id, Sequence: 2, Author: pnr.RequestingPIU, Timestamp: testdata.LatestTimestamp, Message: "\"answer\""},
+	}
+
+	s.txm.Start()
+	s.r.InsertPNR(pnr.Id, pnr)
+	s.txm.End()
+
+	for _, msg := range msgs {
+		s.txm.Start()
+		err := s.r.InsertPNRClarification(pnr, msg)
+		s.txm.End()
+		assert.NoError(err)
+	}
+
+	actual, err := s.r.GetPNRClarifications(pnr.Id)
+	assert.NoError(err)
+	assert.ElementsMatch(msgs, actual)
+}
+
+func (s *RepositoryTestSuite) TestInsertPNRClarificationAlreadyExists() {
+	assert := assert.New(s.T())
+
+	pnr := testdata.PNRs[0]
+	msg := entities.ClarificationMessage{Id: pnr.Id, Sequence: 1, Author: pnr.RespondingPIU, Timestamp: testdata.MiddleTimestamp, Message: "\"question\""}
+
+	s.txm.Start()
+	s.r.InsertPNR(pnr.Id, pnr)
+	s.r.InsertPNRClarification(pnr, msg)
+	s.txm.End()
+
+	s.txm.Start()
+	err := s.r.InsertPNRClarification(pnr, msg)
+	s.txm.End()
+	assert.Error(err)
+}
+
+func (s *RepositoryTestSuite) TestPurgePNRClarifications() {
+	assert := assert.New(s.T())
+
+	s.txm.Start()
+	for _, pnr := range testdata.PNRs[0:2] {
+		s.r.InsertPNR(pnr.Id, pnr)
+		s.r.InsertPNRClarification(pnr, entities.ClarificationMessage{Id: pnr.Id, Sequence: 1, Author: pnr.RespondingPIU, Message: "\"question\""})
+	}
+	s.txm.End()
+
+	s.txm.Start()
+	err := s.r.PurgePNRClarifications(testdata.PNRs[0].Id)
+	s.txm.End()
+	assert.NoError(err)
+
+	actual, _ := s.r.GetPNRClarifications(testdata.PNRs[0].Id)
+	assert.Empty(actual)
+
+	actual, _ = s.r.GetPNRClarifications(testdata.PNRs[1].Id)
+	assert.Len(actual, 1)
+}
@@ -0,0 +1,196 @@
+package privatedata
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+)
+
+// pnrRecordObjectTypes lists object types of records kept for every PNR
+// request, which are backed up and compared between copies.
+var pnrRecordObjectTypes = []string{pnrMetaObjectType, pnrDataObjectType, pnrKeysObjectType, gcMetadataObjectType}
+
+// readableKey writes the composite key as the object type followed by its
+// attributes separated by colons.
+func (r *PrivateDataRepository) readableKey(key string) string {
+	objectType, attributes, err := r.ctx.GetStub().SplitCompositeKey(key)
+
+	if err != nil {
+		return key
+	}
+
+	return strings.Join(append([]string{objectType}, attributes...), ":")
+}
+
+// restoreRecord writes the value of the key into the collection if it
+// matches the hash of the key on the ledger.
+func (r *PrivateDataRepository) restoreRecord(collection string, key string, value []byte, output *entities.RestorePrivateDataOutput) error {
+	hash, err := r.ctx.GetStub().GetPrivateDataHash(collection, key)
+
+	if err != nil {
+		slog.Error(
+			"could not get hash of private data",
+			"key", key,
+			"collection", collection,
+			"error", err,
+		)
+		return err
+	}
+
+	if hash == nil {
+		output.Purged = append(output.Purged, r.readableKey(key))
+		return nil
+	}
+
+	sum := sha256.Sum256(value)
+
+	if !bytes.Equal(hash, sum[:]) {
+		output.Mismatched = append(output.Mismatched, r.readableKey(key))
+		return nil
+	}
+
+	err = r.ctx.GetStub().PutPrivateData(collection, key, value)
+
+	if err != nil {
+		slog.Error(
+			"could not restore private data",
+			"key", key,
+			"collection", collection,
+			"error", err,
+		)
+		return err
+	}
+
+	output.Restored++
+
+	return nil
+}
+
+// BackupLocalRecords returns PNR metadata, data, wrapped data keys and GC
+// metadata kept in the local collection of the PIU.
+func (r *PrivateDataRepository) BackupLocalRecords() (entities.PrivateDataArchive, error) {
+	archive := entities.PrivateDataArchive{
+		Collection: r.localData,
+		Records:    []entities.ArchiveRecord{},
+	}
+
+	for _, objectType := range pnrRecordObjectTypes {
+		records, err := r.getPrivateDataByPartialCompositeKey(r.localData, objectType, []string{})
+
+		if err != nil {
+			return entities.PrivateDataArchive{}, err
+		}
+
+		for _, record := range records {
+			archive.Records = append(archive.Records, entities.ArchiveRecord{Key: record.Key, Value: record.Value})
+		}
+	}
+
+	return archive, nil
+}
+
+// RestoreLocalRecords writes archived records missing from the local
+// collection back, as long as they match their hashes on the ledger.
+func (r *PrivateDataRepository) RestoreLocalRecords(records []entities.ArchiveRecord) (entities.RestorePrivateDataOutput, error) {
+	var output entities.RestorePrivateDataOutput
+
+	for _, record := range records {
+		objectType, _, err := r.ctx.GetStub().SplitCompositeKey(record.Key)
+
+		if err != nil || !slices.Contains(pnrRecordObjectTypes, objectType) {
+			return entities.RestorePrivateDataOutput{}, fmt.Errorf("unsupported archived key %q", r.readableKey(record.Key))
+		}
+
+		current, err := r.ctx.GetStub().GetPrivateData(r.localData, record.Key)
+
+		if err != nil {
+			return entities.RestorePrivateDataOutput{}, err
+		}
+
+		if current != nil {
+			output.Present++
+			continue
+		}
+
+		err = r.restoreRecord(r.localData, record.Key, record.Value, &output)
+
+		if err != nil {
+			return entities.RestorePrivateDataOutput{}, err
+		}
+	}
+
+	return output, nil
+}
+
+// RestoreSharedRecords writes local copies of PNR records shared with the
+// PIU into its collection, as long as they match their hashes on the ledger.
+// The PIU cannot read the local copies itself, so the transaction is invoked
+// by its counterpart to recover records the PIU lost.
+//
+// Peers of both PIUs hold pairwise collections and reconcile them on their
+// own, so there is nothing to restore in the pairwise mode.
+func (r *PrivateDataRepository) RestoreSharedRecords(piuId string) (entities.RestorePrivateDataOutput, error) {
+	var output entities.RestorePrivateDataOutput
+
+	if r.pairNaming != nil {
+		return output, errors.New("pairwise collections are reconciled by peers")
+	}
+
+	collection := r.naming(piuId)
+
+	if collection == r.localData {
+		return output, errors.New("cannot restore records of the PIU itself")
+	}
+
+	metas, err := r.getPrivateDataByPartialCompositeKey(r.localData, pnrMetaObjectType, []string{})
+
+	if err != nil {
+		return output, err
+	}
+
+	for _, record := range metas {
+		meta, err := metaModelToMetaEntity(record.Value)
+
+		if err != nil {
+			return entities.RestorePrivateDataOutput{}, err
+		}
+
+		if meta.RequestingPIU != piuId && meta.RespondingPIU != piuId {
+			continue
+		}
+
+		for _, objectType := range pnrRecordObjectTypes {
+			key, err := shim.CreateCompositeKey(objectType, []string{meta.Id})
+
+			if err != nil {
+				return entities.RestorePrivateDataOutput{}, err
+			}
+
+			value, err := r.ctx.GetStub().GetPrivateData(r.localData, key)
+
+			if err != nil {
+				return entities.RestorePrivateDataOutput{}, err
+			}
+
+			if value == nil {
+				continue
+			}
+
+			err = r.restoreRecord(collection, key, value, &output)
+
+			if err != nil {
+				return entities.RestorePrivateDataOutput{}, err
+			}
+		}
+	}
+
+	return output, nil
+}
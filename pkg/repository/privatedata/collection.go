@@ -0,0 +1,35 @@
+package privatedata
+
+import (
+	"fmt"
+)
+
+// CollectionNaming returns the name of the private data collection of a PIU.
+type CollectionNaming func(piuId string) string
+
+// ExplicitCollectionNaming names collections <MSPID>Collection, which have
+// to be defined in the collections configuration of the chaincode.
+func ExplicitCollectionNaming(piuId string) string {
+	return fmt.Sprintf("%sCollection", piuId)
+}
+
+// ImplicitOrgCollectionNaming uses implicit per-organisation collections
+// provided by Fabric, which need no collections configuration. As there is
+// one such collection per organisation, PIU ids must equal MSP ids.
+func ImplicitOrgCollectionNaming(piuId string) string {
+	return "_implicit_org_" + piuId
+}
+
+// PairCollectionNaming returns the name of the private data collection shared
+// by a pair of PIUs. The name must not depend on the order of the PIUs.
+type PairCollectionNaming func(piuId string, otherPIUId string) string
+
+// SortedPairCollectionNaming names the collection of a pair of PIUs after
+// their ids in lexical order joined by an underscore, e.g. piu1_piu2.
+func SortedPairCollectionNaming(piuId string, otherPIUId string) string {
+	if otherPIUId < piuId {
+		piuId, otherPIUId = otherPIUId, piuId
+	}
+
+	return piuId + "_" + otherPIUId
+}
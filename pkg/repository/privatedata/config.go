@@ -0,0 +1,38 @@
+package privatedata
+
+import (
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+	"github.com/nesfit/tenacity-chaincode/pkg/repository/storedmodel"
+)
+
+type configModel []byte
+
+const configObjectType = "config"
+
+func configEntityToModel(entity entities.ConsortiumConfig) (configModel, error) {
+	model, err := storedmodel.Encode(entity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+func configModelToEntity(model configModel) (entities.ConsortiumConfig, error) {
+	var entity entities.ConsortiumConfig
+
+	_, err := storedmodel.Decode(model, &entity)
+
+	if err != nil {
+		return entities.ConsortiumConfig{}, err
+	}
+
+	return entity, nil
+}
+
+func getConfigCompositeKey() (string, error) {
+	return shim.CreateCompositeKey(configObjectType, []string{})
+}
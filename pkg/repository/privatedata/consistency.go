@@ -0,0 +1,71 @@
+package privatedata
+
+import (
+	"bytes"
+	"encoding/hex"
+	"log/slog"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+)
+
+// ComparePNRCopies compares hashes of the copies of PNR records kept in the
+// shared collections and in the local collection. Records are consistent
+// when all copies are equal or all of them are missing.
+//
+// In the pairwise mode the local collection holds a copy only after a change
+// of the record the other party does not see, so missing local copies are
+// not compared.
+func (r *PrivateDataRepository) ComparePNRCopies(pnr entities.PNR) ([]entities.KeyConsistency, error) {
+	var result []entities.KeyConsistency
+
+	for _, objectType := range pnrRecordObjectTypes {
+		if objectType == pnrKeysObjectType && pnr.DataKeyId == "" {
+			continue
+		}
+
+		key, err := shim.CreateCompositeKey(objectType, []string{pnr.Id})
+
+		if err != nil {
+			return nil, err
+		}
+
+		comparison := entities.KeyConsistency{Key: r.readableKey(key), Consistent: true}
+
+		var first []byte
+
+		for _, collection := range r.purgeCollections(pnr) {
+			hash, err := r.ctx.GetStub().GetPrivateDataHash(collection, key)
+
+			if err != nil {
+				slog.Error(
+					"could not get hash of private data",
+					"key", key,
+					"collection", collection,
+					"error", err,
+				)
+				return nil, err
+			}
+
+			if hash == nil && r.pairNaming != nil && collection == r.localData {
+				continue
+			}
+
+			if len(comparison.Copies) == 0 {
+				first = hash
+			} else if !bytes.Equal(first, hash) {
+				comparison.Consistent = false
+			}
+
+			comparison.Copies = append(comparison.Copies, entities.RecordCopy{
+				Collection: collection,
+				SHA256:     hex.EncodeToString(hash),
+			})
+		}
+
+		result = append(result, comparison)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,85 @@
+package privatedata
+
+import (
+	"log/slog"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+)
+
+// MSPNaming returns the MSP id of the organisation hosting a PIU.
+type MSPNaming func(piuId string) string
+
+// WithKeyEndorsement sets key-level endorsement policies of PNR metadata, so
+// that changes of a shared PNR request have to be endorsed by peers of both
+// its requesting and responding organisation, not by any single peer of the
+// invoking one. Records kept only in the local collection of a PIU need the
+// endorsement of its own organisation.
+//
+// A peer can only endorse transactions reading collections it is a member
+// of, so both organisations can endorse changes of the same record only in
+// the pairwise mode, see WithPairCollections.
+func WithKeyEndorsement(mspNaming MSPNaming) PrivateDataRepositoryOption {
+	return func(r *PrivateDataRepository) {
+		r.mspNaming = mspNaming
+	}
+}
+
+// setKeyEndorsement requires the organisations of the PIUs to endorse
+// changes of the key in the collections.
+func (r *PrivateDataRepository) setKeyEndorsement(collections []string, key string, piuIds ...string) error {
+	if r.mspNaming == nil {
+		return nil
+	}
+
+	ep, err := statebased.NewStateEP(nil)
+
+	if err != nil {
+		return err
+	}
+
+	var mspIds []string
+
+	for _, piuId := range piuIds {
+		mspIds = append(mspIds, r.mspNaming(piuId))
+	}
+
+	err = ep.AddOrgs(statebased.RoleTypePeer, mspIds...)
+
+	if err != nil {
+		slog.Error(
+			"could not create key-level endorsement policy",
+			"key", key,
+			"mspIds", mspIds,
+			"error", err,
+		)
+		return err
+	}
+
+	policy, err := ep.Policy()
+
+	if err != nil {
+		slog.Error(
+			"could not marshal key-level endorsement policy",
+			"key", key,
+			"mspIds", mspIds,
+			"error", err,
+		)
+		return err
+	}
+
+	for _, collection := range collections {
+		err = r.ctx.GetStub().SetPrivateDataValidationParameter(collection, key, policy)
+
+		if err != nil {
+			slog.Error(
+				"could not set key-level endorsement policy",
+				"key", key,
+				"collection", collection,
+				"error", err,
+			)
+			return err
+		}
+	}
+
+	return nil
+}
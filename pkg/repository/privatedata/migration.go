@@ -0,0 +1,242 @@
+package privatedata
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+	"github.com/nesfit/tenacity-chaincode/pkg/repository/storedmodel"
+)
+
+// modelMigration decodes a stored model of any supported version and encodes
+// it in the current one.
+type modelMigration func(data []byte) ([]byte, error)
+
+func migrateModel[M ~[]byte, E any](decode func(M) (E, error)) modelMigration {
+	return func(data []byte) ([]byte, error) {
+		entity, err := decode(M(data))
+
+		if err != nil {
+			return nil, err
+		}
+
+		return storedmodel.Encode(entity)
+	}
+}
+
+// migrationSource is the set of records of one object type, stored either in
+// the public state or in a private data collection.
+type migrationSource struct {
+	collection string
+	objectType string
+	migrate    modelMigration
+}
+
+// migrationBookmark is the position of the first record of the next batch.
+type migrationBookmark struct {
+	Source int    `json:"source"`
+	Key    string `json:"key"`
+}
+
+func decodeMigrationBookmark(bookmark string) (migrationBookmark, error) {
+	var position migrationBookmark
+
+	if bookmark == "" {
+		return position, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(bookmark)
+
+	if err != nil {
+		return position, err
+	}
+
+	err = json.Unmarshal(data, &position)
+
+	if err == nil && position.Source < 0 {
+		err = errors.New("invalid migration bookmark")
+	}
+
+	return position, err
+}
+
+func encodeMigrationBookmark(position migrationBookmark) (string, error) {
+	data, err := json.Marshal(position)
+
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// migrationSources lists records the PIU can read in the order they are
+// migrated, records in collections of other PIUs are migrated by them.
+func (r *PrivateDataRepository) migrationSources() []migrationSource {
+	sources := []migrationSource{
+		{objectType: piuObjectType, migrate: migrateModel(piuModelToEntity)},
+		{objectType: agreementObjectType, migrate: migrateModel(agreementModelToEntity)},
+		{objectType: agreementUsageObjectType, migrate: migrateModel(agreementUsageModelToEntity)},
+		{objectType: configObjectType, migrate: migrateModel(configModelToEntity)},
+	}
+
+	for _, collection := range r.readCollections() {
+		sources = append(sources,
+			migrationSource{collection: collection, objectType: pnrMetaObjectType, migrate: migrateModel(metaModelToMetaEntity)},
+			migrationSource{collection: collection, objectType: pnrDataObjectType, migrate: migrateModel(dataModelToDataEntity)},
+			migrationSource{collection: collection, objectType: pnrKeysObjectType, migrate: migrateModel(keysModelToKeysEntity)},
+			migrationSource{collection: collection, objectType: gcMetadataObjectType, migrate: migrateModel(gcMetadataModelToEntity)},
+			migrationSource{collection: collection, objectType: clarificationObjectType, migrate: migrateModel(clarificationModelToEntity)},
+			migrationSource{collection: collection, objectType: quotaCounterObjectType, migrate: migrateModel(quotaCounterModelToEntity)},
+		)
+	}
+
+	return sources
+}
+
+// getMigrationIterator iterates over records of the source from its first
+// key. Range queries refuse composite keys and paginated queries are only
+// supported in read only transactions, so neither can start at a bookmark.
+func (r *PrivateDataRepository) getMigrationIterator(source migrationSource) (shim.StateQueryIteratorInterface, error) {
+	if source.collection != "" {
+		return r.ctx.GetStub().GetPrivateDataByPartialCompositeKey(source.collection, source.objectType, []string{})
+	}
+
+	return r.ctx.GetStub().GetStateByPartialCompositeKey(source.objectType, []string{})
+}
+
+func (r *PrivateDataRepository) putMigratedRecord(source migrationSource, key string, value []byte) error {
+	if source.collection != "" {
+		return r.ctx.GetStub().PutPrivateData(source.collection, key, value)
+	}
+
+	return r.ctx.GetStub().PutState(key, value)
+}
+
+// migrateSource rewrites records of the source stored with an older model
+// version until the limit is reached, in which case it returns the bookmark
+// of the first record left.
+func (r *PrivateDataRepository) migrateSource(sources []migrationSource, index int, from string, limit int, output *entities.MigrateDataOutput) error {
+	source := sources[index]
+
+	iterator, err := r.getMigrationIterator(source)
+
+	if err != nil {
+		slog.Error(
+			"could not get records to migrate",
+			"collection", source.collection,
+			"objectType", source.objectType,
+			"error", err,
+		)
+		return err
+	}
+	defer iterator.Close()
+
+	for iterator.HasNext() {
+		record, err := iterator.Next()
+
+		if err != nil {
+			return err
+		}
+
+		// Records before the bookmark were migrated by previous batches.
+		if record.Key < from {
+			continue
+		}
+
+		version, err := storedmodel.StoredVersion(record.Value)
+
+		if err != nil {
+			slog.Error(
+				"could not get version of stored model",
+				"key", record.Key,
+				"collection", source.collection,
+				"error", err,
+			)
+			return err
+		}
+
+		if version == storedmodel.Version {
+			continue
+		}
+
+		if limit > 0 && output.Migrated == limit {
+			output.Bookmark, err = encodeMigrationBookmark(migrationBookmark{Source: index, Key: record.Key})
+			return err
+		}
+
+		model, err := source.migrate(record.Value)
+
+		if err != nil {
+			slog.Error(
+				"could not migrate stored model",
+				"key", record.Key,
+				"collection", source.collection,
+				"version", version,
+				"error", err,
+			)
+			return err
+		}
+
+		err = r.putMigratedRecord(source, record.Key, model)
+
+		if err != nil {
+			slog.Error(
+				"could not store migrated model",
+				"key", record.Key,
+				"collection", source.collection,
+				"error", err,
+			)
+			return err
+		}
+
+		output.Migrated++
+	}
+
+	return nil
+}
+
+// MigrateData rewrites at most limit records stored with an older model
+// version, all of them when limit is not positive, starting at the bookmark.
+// The returned bookmark is empty once all records are migrated. A batch
+// resumed at the bookmark reads again the keys of its object type before it,
+// so limits should be large enough to keep the number of batches low.
+//
+// Rewritten PNR metadata keep their key-level endorsement policies, so their
+// migration has to be endorsed by both parties when WithKeyEndorsement is used.
+func (r *PrivateDataRepository) MigrateData(bookmark string, limit int) (entities.MigrateDataOutput, error) {
+	var output entities.MigrateDataOutput
+
+	position, err := decodeMigrationBookmark(bookmark)
+
+	if err != nil {
+		slog.Error(
+			"invalid migration bookmark",
+			"bookmark", bookmark,
+			"error", err,
+		)
+		return output, err
+	}
+
+	sources := r.migrationSources()
+
+	for i := position.Source; i < len(sources) && output.Bookmark == ""; i++ {
+		from := ""
+
+		if i == position.Source {
+			from = position.Key
+		}
+
+		err = r.migrateSource(sources, i, from, limit, &output)
+
+		if err != nil {
+			return output, err
+		}
+	}
+
+	return output, nil
+}
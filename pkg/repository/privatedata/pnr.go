@@ -1,22 +1,37 @@
 package privatedata
 
 import (
-	"encoding/json"
 	"time"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 
 	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+	"github.com/nesfit/tenacity-chaincode/pkg/repository/storedmodel"
 )
 
 type pnrMeta struct {
-	Id                string                `json:"id" required:"true" format:"uuid" description:"Id of PNR request"`
-	RequestingPIU     string                `json:"requestingPIU" required:"true" description:"Id of requesting PIU"`
-	RespondingPIU     string                `json:"respondingPIU" required:"true" description:"Id of responding PIU"`
-	RequestTimestamp  time.Time             `json:"requestTimestamp" required:"false" description:"Timestamp of request"`
-	ResponseTimestamp time.Time             `json:"responseTimestamp" required:"false" description:"Timestamp of response"`
-	State             entities.RequestState `json:"state" required:"true" enum:"Pending,PendingConfirmed,Ack,AckConfirmed,Nack,NackConfirmed,Terminated" description:"State of the PNR request"`
-	PNRHashes         []string              `json:"pnrHashes" required:"true" description:"Hashes of PNRs included in response"`
+	Id                      string                   `json:"id" required:"true" format:"uuid" description:"Id of PNR request"`
+	RequestingPIU           string                   `json:"requestingPIU" required:"true" description:"Id of requesting PIU"`
+	RespondingPIU           string                   `json:"respondingPIU" required:"true" description:"Id of responding PIU"`
+	RequestTimestamp        time.Time                `json:"requestTimestamp" required:"false" description:"Timestamp of request"`
+	ResponseTimestamp       time.Time                `json:"responseTimestamp" required:"false" description:"Timestamp of response"`
+	State                   entities.RequestState    `json:"state" required:"true" enum:"Pending,PendingConfirmed,Ack,AckConfirmed,Nack,NackConfirmed,Terminated,ClarificationRequested,Forwarded,Draft,AckDraft" description:"State of the PNR request"`
+	PNRHashes               []string                 `json:"pnrHashes" required:"true" description:"Hashes of PNRs included in response"`
+	RequestKeyId            string                   `json:"requestKeyId,omitempty" required:"false" description:"Id of the encryption key the request data is encrypted to"`
+	ResponseKeyId           string                   `json:"responseKeyId,omitempty" required:"false" description:"Id of the encryption key the response data is encrypted to"`
+	DataKeyId               string                   `json:"dataKeyId,omitempty" required:"false" description:"Id of the data key the request and response data are encrypted with at rest"`
+	ResponseDigest          string                   `json:"responseDigest,omitempty" required:"false" description:"Hex encoded SHA-256 hash of the canonical response data"`
+	ResponseSignature       []byte                   `json:"responseSignature,omitempty" required:"false" description:"Detached signature of the response digest by the responding PIU"`
+	ResponseCertificate     string                   `json:"responseCertificate,omitempty" required:"false" description:"PEM encoded certificate the response was signed with"`
+	ResponseSignedAt        time.Time                `json:"responseSignedAt" required:"false" description:"Transaction time at which the response signature was verified, later verifications use the same time"`
+	ParentId                string                   `json:"parentId" required:"false" description:"Id of the PNR request this request follows up on"`
+	AllowOnBehalfForwarding bool                     `json:"allowOnBehalfForwarding" required:"false" description:"Responder may forward the request to another PIU on behalf of the requester"`
+	Priority                entities.RequestPriority `json:"priority" required:"false" enum:"Routine,Urgent,Immediate" description:"Priority of the PNR request"`
+	Deadline                time.Time                `json:"deadline" required:"false" description:"Time by which the response is expected"`
+	PayloadProfile          string                   `json:"payloadProfile" required:"false" description:"Payload profile of request and response data"`
+	Purpose                 string                   `json:"purpose" required:"false" description:"Purpose or offence category the PNR request is made for"`
+	AgreementId             string                   `json:"agreementId" required:"false" description:"Id of the agreement covering the PNR request"`
+	DraftedBy               string                   `json:"draftedBy,omitempty" required:"false" description:"Id of the officer who drafted the request or response awaiting approval"`
 }
 
 type pnrData struct {
@@ -24,20 +39,42 @@ type pnrData struct {
 	ResponseData string `json:"responseData" required:"true" description:"PNR response data"`
 }
 
+// pnrKeys holds the wrapped data key of a PNR request encrypted at rest apart
+// from its data, so that destroying it leaves any copy of the data unreadable.
+type pnrKeys struct {
+	DataKeys map[string]entities.EncryptedPayload `json:"dataKeys" required:"true" description:"Data key wrapped to the encryption keys of the parties"`
+}
+
 type pnrModel []byte
 
 const pnrMetaObjectType = "pnrMeta"
 const pnrDataObjectType = "pnrData"
+const pnrKeysObjectType = "pnrKey"
 
 func pnrEntityToMetaEntity(entity entities.PNR) pnrMeta {
 	return pnrMeta{
-		Id:                entity.Id,
-		RequestingPIU:     entity.RequestingPIU,
-		RespondingPIU:     entity.RespondingPIU,
-		RequestTimestamp:  entity.RequestTimestamp,
-		ResponseTimestamp: entity.ResponseTimestamp,
-		State:             entity.State,
-		PNRHashes:         entity.PNRHashes,
+		Id:                      entity.Id,
+		RequestingPIU:           entity.RequestingPIU,
+		RespondingPIU:           entity.RespondingPIU,
+		RequestTimestamp:        entity.RequestTimestamp,
+		ResponseTimestamp:       entity.ResponseTimestamp,
+		State:                   entity.State,
+		PNRHashes:               entity.PNRHashes,
+		RequestKeyId:            entity.RequestKeyId,
+		ResponseKeyId:           entity.ResponseKeyId,
+		DataKeyId:               entity.DataKeyId,
+		ResponseDigest:          entity.ResponseDigest,
+		ResponseSignature:       entity.ResponseSignature,
+		ResponseCertificate:     entity.ResponseCertificate,
+		ResponseSignedAt:        entity.ResponseSignedAt,
+		ParentId:                entity.ParentId,
+		AllowOnBehalfForwarding: entity.AllowOnBehalfForwarding,
+		Priority:                entity.Priority,
+		Deadline:                entity.Deadline,
+		PayloadProfile:          entity.PayloadProfile,
+		Purpose:                 entity.Purpose,
+		AgreementId:             entity.AgreementId,
+		DraftedBy:               entity.DraftedBy,
 	}
 }
 
@@ -50,22 +87,37 @@ func pnrEntityToDataEntity(entity entities.PNR) pnrData {
 
 func pnrEntitiesToEntity(metaEntity pnrMeta, dataEntity pnrData) entities.PNR {
 	return entities.PNR{
-		Id:                metaEntity.Id,
-		RequestingPIU:     metaEntity.RequestingPIU,
-		RespondingPIU:     metaEntity.RespondingPIU,
-		RequestTimestamp:  metaEntity.RequestTimestamp,
-		ResponseTimestamp: metaEntity.ResponseTimestamp,
-		State:             metaEntity.State,
-		PNRHashes:         metaEntity.PNRHashes,
-		RequestData:       dataEntity.RequestData,
-		ResponseData:      dataEntity.ResponseData,
+		Id:                      metaEntity.Id,
+		RequestingPIU:           metaEntity.RequestingPIU,
+		RespondingPIU:           metaEntity.RespondingPIU,
+		RequestTimestamp:        metaEntity.RequestTimestamp,
+		ResponseTimestamp:       metaEntity.ResponseTimestamp,
+		State:                   metaEntity.State,
+		PNRHashes:               metaEntity.PNRHashes,
+		RequestKeyId:            metaEntity.RequestKeyId,
+		ResponseKeyId:           metaEntity.ResponseKeyId,
+		DataKeyId:               metaEntity.DataKeyId,
+		ResponseDigest:          metaEntity.ResponseDigest,
+		ResponseSignature:       metaEntity.ResponseSignature,
+		ResponseCertificate:     metaEntity.ResponseCertificate,
+		ResponseSignedAt:        metaEntity.ResponseSignedAt,
+		ParentId:                metaEntity.ParentId,
+		AllowOnBehalfForwarding: metaEntity.AllowOnBehalfForwarding,
+		Priority:                metaEntity.Priority,
+		Deadline:                metaEntity.Deadline,
+		PayloadProfile:          metaEntity.PayloadProfile,
+		Purpose:                 metaEntity.Purpose,
+		AgreementId:             metaEntity.AgreementId,
+		DraftedBy:               metaEntity.DraftedBy,
+		RequestData:             dataEntity.RequestData,
+		ResponseData:            dataEntity.ResponseData,
 	}
 }
 
 func pnrEntityToMetaModel(entity entities.PNR) (pnrModel, error) {
 	metaEntity := pnrEntityToMetaEntity(entity)
 
-	model, err := json.Marshal(metaEntity)
+	model, err := storedmodel.Encode(metaEntity)
 
 	if err != nil {
 		return nil, err
@@ -77,7 +129,17 @@ func pnrEntityToMetaModel(entity entities.PNR) (pnrModel, error) {
 func pnrEntityToDataModel(entity entities.PNR) (pnrModel, error) {
 	dataEntity := pnrEntityToDataEntity(entity)
 
-	model, err := json.Marshal(dataEntity)
+	model, err := storedmodel.Encode(dataEntity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+func pnrEntityToKeysModel(entity entities.PNR) (pnrModel, error) {
+	model, err := storedmodel.Encode(pnrKeys{DataKeys: entity.DataKeys})
 
 	if err != nil {
 		return nil, err
@@ -89,7 +151,7 @@ func pnrEntityToDataModel(entity entities.PNR) (pnrModel, error) {
 func metaModelToMetaEntity(model pnrModel) (pnrMeta, error) {
 	var entity pnrMeta
 
-	err := json.Unmarshal(model, &entity)
+	_, err := storedmodel.Decode(model, &entity)
 
 	if err != nil {
 		return pnrMeta{}, err
@@ -101,7 +163,7 @@ func metaModelToMetaEntity(model pnrModel) (pnrMeta, error) {
 func dataModelToDataEntity(model pnrModel) (pnrData, error) {
 	var entity pnrData
 
-	err := json.Unmarshal(model, &entity)
+	_, err := storedmodel.Decode(model, &entity)
 
 	if err != nil {
 		return pnrData{}, err
@@ -110,6 +172,18 @@ func dataModelToDataEntity(model pnrModel) (pnrData, error) {
 	return entity, nil
 }
 
+func keysModelToKeysEntity(model pnrModel) (pnrKeys, error) {
+	var entity pnrKeys
+
+	_, err := storedmodel.Decode(model, &entity)
+
+	if err != nil {
+		return pnrKeys{}, err
+	}
+
+	return entity, nil
+}
+
 func pnrModelsToEntity(metaModel pnrModel, dataModel pnrModel) (entities.PNR, error) {
 	metaEntity, err := metaModelToMetaEntity(metaModel)
 
@@ -134,6 +208,10 @@ func getPNRDataCompositeKey(id string) (string, error) {
 	return shim.CreateCompositeKey(pnrDataObjectType, []string{id})
 }
 
+func getPNRKeysCompositeKey(id string) (string, error) {
+	return shim.CreateCompositeKey(pnrKeysObjectType, []string{id})
+}
+
 func getRemotePIU(pnr entities.PNR, localPIU string) string {
 	if pnr.RequestingPIU == localPIU {
 		return pnr.RespondingPIU
@@ -147,7 +225,7 @@ type gcMetadataModel []byte
 const gcMetadataObjectType = "gc"
 
 func gcMetadataEntityToModel(entity entities.GCMetadata) (gcMetadataModel, error) {
-	model, err := json.Marshal(entity)
+	model, err := storedmodel.Encode(entity)
 
 	if err != nil {
 		return nil, err
@@ -159,7 +237,7 @@ func gcMetadataEntityToModel(entity entities.GCMetadata) (gcMetadataModel, error
 func gcMetadataModelToEntity(model pnrModel) (entities.GCMetadata, error) {
 	var entity entities.GCMetadata
 
-	err := json.Unmarshal(model, &entity)
+	_, err := storedmodel.Decode(model, &entity)
 
 	if err != nil {
 		return entities.GCMetadata{}, err
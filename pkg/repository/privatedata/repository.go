@@ -2,30 +2,66 @@ package privatedata
 
 import (
 	"errors"
-	"fmt"
 	"log/slog"
+	"slices"
+	"sort"
+	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
 
 	"github.com/nesfit/tenacity-chaincode/pkg/entities"
 )
 
-func getCollectionName(clientOrgID string) string {
-	return fmt.Sprintf("%sCollection", clientOrgID)
+type PrivateDataRepository struct {
+	ctx        contractapi.TransactionContextInterface
+	piuId      string
+	naming     CollectionNaming
+	pairNaming PairCollectionNaming
+	pairPIUs   []string
+	mspNaming  MSPNaming
+	localData  string
 }
 
-type PrivateDataRepository struct {
-	ctx       contractapi.TransactionContextInterface
-	piuId     string
-	localData string
+type PrivateDataRepositoryOption func(*PrivateDataRepository)
+
+// WithCollectionNaming sets the naming of private data collections,
+// ExplicitCollectionNaming is used otherwise.
+func WithCollectionNaming(naming CollectionNaming) PrivateDataRepositoryOption {
+	return func(r *PrivateDataRepository) {
+		r.naming = naming
+	}
 }
 
-func NewPrivateDataRepository(ctx contractapi.TransactionContextInterface, piuId string) *PrivateDataRepository {
-	return &PrivateDataRepository{
-		ctx:       ctx,
-		piuId:     piuId,
-		localData: getCollectionName(piuId),
+// WithPairCollections stores records shared by the parties of a PNR request
+// once in the collection of their pair instead of in the collections of both
+// PIUs. The PIUs are those the pair collections are defined for, see
+// collections.Generate. The local collection of the PIU then holds only
+// records not shared with the other PIU, such as drafts, and hides the shared
+// records with the same key. Records removed only locally, e.g. when a PNR
+// request is terminated, therefore stay in the pair collection until they
+// are purged there.
+func WithPairCollections(pairNaming PairCollectionNaming, piuIds []string) PrivateDataRepositoryOption {
+	return func(r *PrivateDataRepository) {
+		r.pairNaming = pairNaming
+		r.pairPIUs = piuIds
+	}
+}
+
+func NewPrivateDataRepository(ctx contractapi.TransactionContextInterface, piuId string, opts ...PrivateDataRepositoryOption) *PrivateDataRepository {
+	r := &PrivateDataRepository{
+		ctx:    ctx,
+		piuId:  piuId,
+		naming: ExplicitCollectionNaming,
+	}
+
+	for _, opt := range opts {
+		opt(r)
 	}
+
+	r.localData = r.naming(piuId)
+
+	return r
 }
 
 func (r *PrivateDataRepository) PIUExists(id string) (bool, error) {
@@ -121,6 +157,56 @@ func (r *PrivateDataRepository) GetPIUs() ([]entities.PIU, error) {
 	return result, nil
 }
 
+func (r *PrivateDataRepository) GetPIUHistory(id string) ([]entities.PIUHistoryEntry, error) {
+	result := []entities.PIUHistoryEntry{}
+
+	key, err := getPIUCompositeKey(id)
+
+	if err != nil {
+		slog.Error(
+			"could not create PIU composite key",
+			"id", id,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	iterator, err := r.ctx.GetStub().GetHistoryForKey(key)
+	if err != nil {
+		slog.Error(
+			"could not get PIU history",
+			"id", id,
+			"error", err,
+		)
+		return nil, err
+	}
+	defer iterator.Close()
+
+	for iterator.HasNext() {
+		modification, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		entry := entities.PIUHistoryEntry{
+			TxId:      modification.TxId,
+			Timestamp: modification.Timestamp.AsTime(),
+			IsDelete:  modification.IsDelete,
+		}
+
+		if !modification.IsDelete {
+			entry.PIU, err = piuModelToEntity(modification.Value)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		result = append(result, entry)
+	}
+
+	return result, nil
+}
+
 func (r *PrivateDataRepository) InsertPIU(id string, piu entities.PIU) error {
 	exists, _ := r.PIUExists(id)
 
@@ -207,6 +293,185 @@ func (r *PrivateDataRepository) UpdatePIU(id string, piu entities.PIU) error {
 	return nil
 }
 
+func (r *PrivateDataRepository) AgreementExists(id string) (bool, error) {
+	key, err := getAgreementCompositeKey(id)
+
+	if err != nil {
+		slog.Error(
+			"could not create agreement composite key",
+			"id", id,
+			"error", err,
+		)
+		return false, err
+	}
+
+	agreementModel, err := r.ctx.GetStub().GetState(key)
+
+	if err != nil {
+		slog.Error(
+			"could not get agreement",
+			"id", id,
+			"error", err,
+		)
+		return false, err
+	}
+
+	exists := agreementModel != nil
+
+	return exists, nil
+}
+
+func (r *PrivateDataRepository) GetAgreement(id string) (entities.Agreement, error) {
+	key, err := getAgreementCompositeKey(id)
+
+	if err != nil {
+		slog.Error(
+			"could not create agreement composite key",
+			"id", id,
+			"error", err,
+		)
+		return entities.Agreement{}, err
+	}
+
+	agreementModel, err := r.ctx.GetStub().GetState(key)
+
+	if err != nil {
+		slog.Error(
+			"could not get agreement",
+			"id", id,
+			"error", err,
+		)
+		return entities.Agreement{}, err
+	}
+
+	exists := agreementModel != nil
+
+	if !exists {
+		err = errors.New("agreement not found")
+		slog.Error(
+			err.Error(),
+			"id", id,
+		)
+		return entities.Agreement{}, err
+	}
+
+	return agreementModelToEntity(agreementModel)
+}
+
+func (r *PrivateDataRepository) GetAgreements() ([]entities.Agreement, error) {
+	var result []entities.Agreement
+
+	iterator, err := r.ctx.GetStub().GetStateByPartialCompositeKey(agreementObjectType, []string{})
+	if err != nil {
+		slog.Error(
+			err.Error(),
+		)
+		return []entities.Agreement{}, err
+	}
+	defer iterator.Close()
+
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		agreement, err := agreementModelToEntity(queryResponse.Value)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, agreement)
+	}
+
+	return result, nil
+}
+
+func (r *PrivateDataRepository) InsertAgreement(id string, agreement entities.Agreement) error {
+	exists, _ := r.AgreementExists(id)
+
+	if exists {
+		return errors.New("agreement already exists")
+	}
+
+	key, err := getAgreementCompositeKey(id)
+
+	if err != nil {
+		slog.Error(
+			"could not create agreement composite key",
+			"id", id,
+			"error", err,
+		)
+		return err
+	}
+
+	agreementModel, err := agreementEntityToModel(agreement)
+
+	if err != nil {
+		slog.Error(
+			"could not map agreement entity to model",
+			"id", id,
+			"error", err,
+		)
+		return err
+	}
+
+	err = r.ctx.GetStub().PutState(key, agreementModel)
+
+	if err != nil {
+		slog.Error(
+			"could not put model into ledger",
+			"id", id,
+			"error", err,
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *PrivateDataRepository) UpdateAgreement(id string, agreement entities.Agreement) error {
+	exists, _ := r.AgreementExists(id)
+
+	if !exists {
+		return errors.New("agreement does not exist")
+	}
+
+	key, err := getAgreementCompositeKey(id)
+
+	if err != nil {
+		slog.Error(
+			"could not create agreement composite key",
+			"id", id,
+			"error", err,
+		)
+		return err
+	}
+
+	agreementModel, err := agreementEntityToModel(agreement)
+
+	if err != nil {
+		slog.Error(
+			"could not map agreement entity to model",
+			"id", id,
+			"error", err,
+		)
+		return err
+	}
+
+	err = r.ctx.GetStub().PutState(key, agreementModel)
+
+	if err != nil {
+		slog.Error(
+			"could not put model into ledger",
+			"id", id,
+			"error", err,
+		)
+		return err
+	}
+
+	return nil
+}
+
 func (r *PrivateDataRepository) getPNRMeta(id string) (string, pnrMeta, error) {
 	metaKey, err := getPNRMetaCompositeKey(id)
 
@@ -219,7 +484,7 @@ func (r *PrivateDataRepository) getPNRMeta(id string) (string, pnrMeta, error) {
 		return "", pnrMeta{}, err
 	}
 
-	metaModel, err := r.ctx.GetStub().GetPrivateData(r.localData, metaKey)
+	metaModel, err := r.getVisiblePrivateData(metaKey)
 
 	if err != nil {
 		slog.Error(
@@ -256,7 +521,7 @@ func (r *PrivateDataRepository) getPNRData(id string) (string, pnrData, error) {
 		return "", pnrData{}, err
 	}
 
-	dataModel, err := r.ctx.GetStub().GetPrivateData(r.localData, dataKey)
+	dataModel, err := r.getVisiblePrivateData(dataKey)
 
 	if err != nil {
 		slog.Error(
@@ -281,118 +546,616 @@ func (r *PrivateDataRepository) getPNRData(id string) (string, pnrData, error) {
 	return dataKey, dataEntity, nil
 }
 
-func (r *PrivateDataRepository) putToBothPrivateCollections(remoteCollection string, key string, value []byte) error {
-	var err error
+// getPNRKeys returns the wrapped data keys of the PNR request, which exist
+// only while its data encrypted at rest are kept.
+func (r *PrivateDataRepository) getPNRKeys(metaEntity pnrMeta) (map[string]entities.EncryptedPayload, error) {
+	if metaEntity.DataKeyId == "" {
+		return nil, nil
+	}
 
-	err = r.ctx.GetStub().PutPrivateData(remoteCollection, key, value)
+	keysKey, err := getPNRKeysCompositeKey(metaEntity.Id)
 
 	if err != nil {
 		slog.Error(
-			"could not put data into remote private collection",
-			"key", key,
+			"could not create PNR keys composite key",
+			"id", metaEntity.Id,
 			"error", err,
 		)
-		return err
+		return nil, err
 	}
 
-	err = r.ctx.GetStub().PutPrivateData(r.localData, key, value)
+	keysModel, err := r.getVisiblePrivateData(keysKey)
 
 	if err != nil {
 		slog.Error(
-			"could not put data into local private collection",
-			"key", key,
+			"could not get PNR keys",
+			"id", metaEntity.Id,
 			"error", err,
 		)
-		return err
+		return nil, err
 	}
 
-	return nil
-}
+	if keysModel == nil {
+		return nil, nil
+	}
 
-func (r *PrivateDataRepository) PNRExists(id string) (bool, error) {
-	key, err := getPNRMetaCompositeKey(id)
+	keysEntity, err := keysModelToKeysEntity(keysModel)
 
 	if err != nil {
 		slog.Error(
-			"could not create PNR composite key",
-			"id", id,
+			"could not convert PNR keys model to entity",
+			"id", metaEntity.Id,
 			"error", err,
 		)
-		return false, err
+		return nil, err
+	}
+
+	return keysEntity.DataKeys, nil
+}
+
+// putPNRKeys writes the wrapped data keys of the PNR request with the put
+// function and returns key of the record, which is empty when the data are
+// not encrypted at rest.
+func (r *PrivateDataRepository) putPNRKeys(pnr entities.PNR, put func(key string, value []byte) error) (string, error) {
+	if pnr.DataKeyId == "" || len(pnr.DataKeys) == 0 {
+		return "", nil
 	}
 
-	pnrModel, err := r.ctx.GetStub().GetPrivateData(r.localData, key)
+	keysKey, err := getPNRKeysCompositeKey(pnr.Id)
 
 	if err != nil {
 		slog.Error(
-			"could not get PNR",
-			"id", id,
+			"could not create PNR keys composite key",
+			"id", pnr.Id,
 			"error", err,
 		)
-		return false, err
+		return "", err
 	}
 
-	exists := pnrModel != nil
+	keysModel, err := pnrEntityToKeysModel(pnr)
 
-	return exists, nil
+	if err != nil {
+		slog.Error(
+			"could not map PNR entity to keys model",
+			"id", pnr.Id,
+			"error", err,
+		)
+		return "", err
+	}
+
+	return keysKey, put(keysKey, keysModel)
 }
 
-func (r *PrivateDataRepository) GetPNR(id string) (entities.PNR, error) {
-	_, metaEntity, err := r.getPNRMeta(id)
+// sharedCollections returns the collections holding the records the PIU
+// shares with the other party of the PNR request.
+func (r *PrivateDataRepository) sharedCollections(pnr entities.PNR) []string {
+	remotePIU := getRemotePIU(pnr, r.piuId)
 
-	if err != nil {
-		return entities.PNR{}, err
+	if r.pairNaming != nil {
+		return []string{r.pairNaming(r.piuId, remotePIU)}
 	}
 
-	if !entities.HasData(metaEntity.State) {
-		return pnrEntitiesToEntity(metaEntity, pnrData{}), nil
+	return []string{r.naming(remotePIU), r.localData}
+}
+
+// partyCollections returns the collections holding the records shared by the
+// requesting and responding PIU of the PNR request, which do not have to
+// include the PIU itself.
+func (r *PrivateDataRepository) partyCollections(pnr entities.PNR) []string {
+	if r.pairNaming != nil {
+		return []string{r.pairNaming(pnr.RequestingPIU, pnr.RespondingPIU)}
 	}
 
-	_, dataEntity, err := r.getPNRData(id)
+	return []string{r.naming(pnr.RequestingPIU), r.naming(pnr.RespondingPIU)}
+}
 
-	if err != nil {
-		return entities.PNR{}, err
+// purgeCollections returns the shared collections of the PNR request and the
+// local collection, which may hold a local copy of its records.
+func (r *PrivateDataRepository) purgeCollections(pnr entities.PNR) []string {
+	collections := r.sharedCollections(pnr)
+
+	if !slices.Contains(collections, r.localData) {
+		collections = append(collections, r.localData)
 	}
 
-	return pnrEntitiesToEntity(metaEntity, dataEntity), nil
+	return collections
 }
 
-func (r *PrivateDataRepository) GetPNRs(filter entities.PNRFilter) ([]entities.PNR, error) {
-	var result []entities.PNR
+// readCollections returns the collections the PIU reads records from, its
+// local collection first.
+func (r *PrivateDataRepository) readCollections() []string {
+	collections := []string{r.localData}
 
-	iterator, err := r.ctx.GetStub().GetPrivateDataByPartialCompositeKey(r.localData, pnrMetaObjectType, []string{})
-	if err != nil {
-		slog.Error(
-			err.Error(),
-		)
-		return []entities.PNR{}, err
+	if r.pairNaming == nil {
+		return collections
 	}
-	defer iterator.Close()
 
-	for iterator.HasNext() {
-		queryResponse, err := iterator.Next()
-		if err != nil {
-			slog.Error(
-				"failed calling iterator.Next()",
-				"error", err,
-			)
+	for _, piuId := range r.pairPIUs {
+		if piuId == r.piuId {
 			continue
 		}
 
-		meta, err := metaModelToMetaEntity(queryResponse.Value)
-		if err != nil {
-			slog.Error(
-				"failed to map PNR metadata model to entity",
-				"model", queryResponse.Value,
-				"error", err,
-			)
-			continue
-		}
+		collections = append(collections, r.pairNaming(r.piuId, piuId))
+	}
 
-		pnr := pnrEntitiesToEntity(meta, pnrData{})
+	return collections
+}
 
-		if entities.IsMatchingPNR(filter, pnr) {
-			id := meta.Id
+// getVisiblePrivateData returns the value of the key from the first of the
+// read collections holding it.
+func (r *PrivateDataRepository) getVisiblePrivateData(key string) ([]byte, error) {
+	for _, collection := range r.readCollections() {
+		value, err := r.ctx.GetStub().GetPrivateData(collection, key)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if value != nil {
+			return value, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// getVisiblePrivateDataByPartialCompositeKey returns the records matching the
+// partial composite key from all read collections ordered by key, each key
+// once as returned by getVisiblePrivateData.
+func (r *PrivateDataRepository) getVisiblePrivateDataByPartialCompositeKey(objectType string, attributes []string) ([]*queryresult.KV, error) {
+	var result []*queryresult.KV
+	seen := make(map[string]bool)
+
+	for _, collection := range r.readCollections() {
+		records, err := r.getPrivateDataByPartialCompositeKey(collection, objectType, attributes)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, record := range records {
+			if seen[record.Key] {
+				continue
+			}
+
+			seen[record.Key] = true
+			result = append(result, record)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+
+	return result, nil
+}
+
+func (r *PrivateDataRepository) getPrivateDataByPartialCompositeKey(collection string, objectType string, attributes []string) ([]*queryresult.KV, error) {
+	var result []*queryresult.KV
+
+	iterator, err := r.ctx.GetStub().GetPrivateDataByPartialCompositeKey(collection, objectType, attributes)
+	if err != nil {
+		slog.Error(
+			err.Error(),
+		)
+		return nil, err
+	}
+	defer iterator.Close()
+
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, queryResponse)
+	}
+
+	return result, nil
+}
+
+func (r *PrivateDataRepository) putToSharedPrivateCollections(pnr entities.PNR, key string, value []byte) error {
+	for _, collection := range r.sharedCollections(pnr) {
+		err := r.ctx.GetStub().PutPrivateData(collection, key, value)
+
+		if err != nil {
+			slog.Error(
+				"could not put data into shared private collection",
+				"key", key,
+				"collection", collection,
+				"error", err,
+			)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *PrivateDataRepository) putToPartyPrivateCollections(pnr entities.PNR, key string, value []byte) error {
+	for _, collection := range r.partyCollections(pnr) {
+		err := r.ctx.GetStub().PutPrivateData(collection, key, value)
+
+		if err != nil {
+			slog.Error(
+				"could not put data into party private collection",
+				"key", key,
+				"collection", collection,
+				"error", err,
+			)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *PrivateDataRepository) purgeFromPrivateCollections(pnr entities.PNR, key string) error {
+	for _, collection := range r.purgeCollections(pnr) {
+		err := r.ctx.GetStub().PurgePrivateData(collection, key)
+
+		if err != nil {
+			slog.Error(
+				"could not purge data from private collection",
+				"key", key,
+				"collection", collection,
+				"error", err,
+			)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteLocalCopies removes the keys from the local collection if it is not
+// one of the shared collections of the PNR request, so that a local copy,
+// e.g. of an approved draft, no longer hides the shared records.
+func (r *PrivateDataRepository) deleteLocalCopies(pnr entities.PNR, keys ...string) error {
+	if slices.Contains(r.sharedCollections(pnr), r.localData) {
+		return nil
+	}
+
+	for _, key := range keys {
+		existing, err := r.ctx.GetStub().GetPrivateData(r.localData, key)
+
+		if err != nil {
+			return err
+		}
+
+		if existing == nil {
+			continue
+		}
+
+		err = r.ctx.GetStub().DelPrivateData(r.localData, key)
+
+		if err != nil {
+			slog.Error(
+				"could not delete local copy from local collection",
+				"key", key,
+				"error", err,
+			)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *PrivateDataRepository) GetAgreementUsage(id string) (entities.AgreementUsage, error) {
+	key, err := getAgreementUsageCompositeKey(id)
+
+	if err != nil {
+		slog.Error(
+			"could not create agreement usage composite key",
+			"id", id,
+			"error", err,
+		)
+		return entities.AgreementUsage{}, err
+	}
+
+	agreementUsageModel, err := r.ctx.GetStub().GetState(key)
+
+	if err != nil {
+		slog.Error(
+			"could not get agreement usage",
+			"id", id,
+			"error", err,
+		)
+		return entities.AgreementUsage{}, err
+	}
+
+	if agreementUsageModel == nil {
+		return entities.AgreementUsage{AgreementId: id}, nil
+	}
+
+	return agreementUsageModelToEntity(agreementUsageModel)
+}
+
+func (r *PrivateDataRepository) PutAgreementUsage(usage entities.AgreementUsage) error {
+	key, err := getAgreementUsageCompositeKey(usage.AgreementId)
+
+	if err != nil {
+		slog.Error(
+			"could not create agreement usage composite key",
+			"id", usage.AgreementId,
+			"error", err,
+		)
+		return err
+	}
+
+	agreementUsageModel, err := agreementUsageEntityToModel(usage)
+
+	if err != nil {
+		slog.Error(
+			"could not map agreement usage entity to model",
+			"id", usage.AgreementId,
+			"error", err,
+		)
+		return err
+	}
+
+	err = r.ctx.GetStub().PutState(key, agreementUsageModel)
+
+	if err != nil {
+		slog.Error(
+			"could not put model into ledger",
+			"id", usage.AgreementId,
+			"error", err,
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *PrivateDataRepository) ConfigExists() (bool, error) {
+	key, err := getConfigCompositeKey()
+
+	if err != nil {
+		slog.Error(
+			"could not create config composite key",
+			"error", err,
+		)
+		return false, err
+	}
+
+	configModel, err := r.ctx.GetStub().GetState(key)
+
+	if err != nil {
+		slog.Error(
+			"could not get config",
+			"error", err,
+		)
+		return false, err
+	}
+
+	exists := configModel != nil
+
+	return exists, nil
+}
+
+func (r *PrivateDataRepository) GetConfig() (entities.ConsortiumConfig, error) {
+	key, err := getConfigCompositeKey()
+
+	if err != nil {
+		slog.Error(
+			"could not create config composite key",
+			"error", err,
+		)
+		return entities.ConsortiumConfig{}, err
+	}
+
+	configModel, err := r.ctx.GetStub().GetState(key)
+
+	if err != nil {
+		slog.Error(
+			"could not get config",
+			"error", err,
+		)
+		return entities.ConsortiumConfig{}, err
+	}
+
+	if configModel == nil {
+		err = errors.New("config not found")
+		slog.Error(
+			err.Error(),
+		)
+		return entities.ConsortiumConfig{}, err
+	}
+
+	return configModelToEntity(configModel)
+}
+
+func (r *PrivateDataRepository) PutConfig(config entities.ConsortiumConfig) error {
+	key, err := getConfigCompositeKey()
+
+	if err != nil {
+		slog.Error(
+			"could not create config composite key",
+			"error", err,
+		)
+		return err
+	}
+
+	configModel, err := configEntityToModel(config)
+
+	if err != nil {
+		slog.Error(
+			"could not map config entity to model",
+			"error", err,
+		)
+		return err
+	}
+
+	err = r.ctx.GetStub().PutState(key, configModel)
+
+	if err != nil {
+		slog.Error(
+			"could not put model into state",
+			"error", err,
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *PrivateDataRepository) GetQuotaCounter(piuId string, counterpart string, bucket time.Time) (entities.QuotaCounter, error) {
+	bucket = entities.GetQuotaBucket(bucket)
+
+	key, err := getQuotaCounterCompositeKey(piuId, counterpart, bucket)
+
+	if err != nil {
+		slog.Error(
+			"could not create quota counter composite key",
+			"piuId", piuId,
+			"counterpart", counterpart,
+			"error", err,
+		)
+		return entities.QuotaCounter{}, err
+	}
+
+	quotaCounterModel, err := r.ctx.GetStub().GetPrivateData(r.localData, key)
+
+	if err != nil {
+		slog.Error(
+			"could not get quota counter",
+			"piuId", piuId,
+			"counterpart", counterpart,
+			"error", err,
+		)
+		return entities.QuotaCounter{}, err
+	}
+
+	if quotaCounterModel == nil {
+		return entities.QuotaCounter{PIU: piuId, Counterpart: counterpart, Bucket: bucket}, nil
+	}
+
+	return quotaCounterModelToEntity(quotaCounterModel)
+}
+
+func (r *PrivateDataRepository) PutQuotaCounter(counter entities.QuotaCounter) error {
+	counter.Bucket = entities.GetQuotaBucket(counter.Bucket)
+
+	key, err := getQuotaCounterCompositeKey(counter.PIU, counter.Counterpart, counter.Bucket)
+
+	if err != nil {
+		slog.Error(
+			"could not create quota counter composite key",
+			"piuId", counter.PIU,
+			"counterpart", counter.Counterpart,
+			"error", err,
+		)
+		return err
+	}
+
+	quotaCounterModel, err := quotaCounterEntityToModel(counter)
+
+	if err != nil {
+		slog.Error(
+			"could not map quota counter entity to model",
+			"piuId", counter.PIU,
+			"counterpart", counter.Counterpart,
+			"error", err,
+		)
+		return err
+	}
+
+	err = r.ctx.GetStub().PutPrivateData(r.localData, key, quotaCounterModel)
+
+	if err != nil {
+		slog.Error(
+			"could not put model into private collection",
+			"piuId", counter.PIU,
+			"counterpart", counter.Counterpart,
+			"error", err,
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *PrivateDataRepository) PNRExists(id string) (bool, error) {
+	key, err := getPNRMetaCompositeKey(id)
+
+	if err != nil {
+		slog.Error(
+			"could not create PNR composite key",
+			"id", id,
+			"error", err,
+		)
+		return false, err
+	}
+
+	pnrModel, err := r.getVisiblePrivateData(key)
+
+	if err != nil {
+		slog.Error(
+			"could not get PNR",
+			"id", id,
+			"error", err,
+		)
+		return false, err
+	}
+
+	exists := pnrModel != nil
+
+	return exists, nil
+}
+
+func (r *PrivateDataRepository) GetPNR(id string) (entities.PNR, error) {
+	_, metaEntity, err := r.getPNRMeta(id)
+
+	if err != nil {
+		return entities.PNR{}, err
+	}
+
+	if !entities.HasData(metaEntity.State) {
+		return pnrEntitiesToEntity(metaEntity, pnrData{}), nil
+	}
+
+	_, dataEntity, err := r.getPNRData(id)
+
+	if err != nil {
+		return entities.PNR{}, err
+	}
+
+	pnr := pnrEntitiesToEntity(metaEntity, dataEntity)
+
+	pnr.DataKeys, err = r.getPNRKeys(metaEntity)
+
+	if err != nil {
+		return entities.PNR{}, err
+	}
+
+	return pnr, nil
+}
+
+func (r *PrivateDataRepository) GetPNRs(filter entities.PNRFilter) ([]entities.PNR, error) {
+	var result []entities.PNR
+
+	records, err := r.getVisiblePrivateDataByPartialCompositeKey(pnrMetaObjectType, []string{})
+	if err != nil {
+		return []entities.PNR{}, err
+	}
+
+	for _, queryResponse := range records {
+		meta, err := metaModelToMetaEntity(queryResponse.Value)
+		if err != nil {
+			slog.Error(
+				"failed to map PNR metadata model to entity",
+				"model", queryResponse.Value,
+				"error", err,
+			)
+			continue
+		}
+
+		pnr := pnrEntitiesToEntity(meta, pnrData{})
+
+		if entities.IsMatchingPNR(filter, pnr) {
+			id := meta.Id
 
 			switch pnr.State {
 			case entities.RequestStateAckConfirmed, entities.RequestStateNackConfirmed, entities.RequestStateTerminated:
@@ -406,6 +1169,12 @@ func (r *PrivateDataRepository) GetPNRs(filter entities.PNRFilter) ([]entities.P
 
 				pnrWithData := pnrEntitiesToEntity(meta, dataEntity)
 
+				pnrWithData.DataKeys, err = r.getPNRKeys(meta)
+
+				if err != nil {
+					continue
+				}
+
 				result = append(result, pnrWithData)
 			}
 		}
@@ -421,33 +1190,214 @@ func (r *PrivateDataRepository) InsertPNR(id string, pnr entities.PNR) error {
 		return errors.New("PNR already exists")
 	}
 
-	metaKey, err := getPNRMetaCompositeKey(id)
+	metaKey, err := getPNRMetaCompositeKey(id)
+
+	if err != nil {
+		slog.Error(
+			"could not create PNR metadata composite key",
+			"id", id,
+			"error", err,
+		)
+		return err
+	}
+
+	dataKey, err := getPNRDataCompositeKey(id)
+
+	if err != nil {
+		slog.Error(
+			"could not create PNR data composite key",
+			"id", id,
+			"error", err,
+		)
+		return err
+	}
+
+	metaModel, err := pnrEntityToMetaModel(pnr)
+	if err != nil {
+		slog.Error(
+			"could not map PNR entity to metadata model",
+			"id", id,
+			"error", err,
+		)
+		return err
+	}
+
+	dataModel, err := pnrEntityToDataModel(pnr)
+	if err != nil {
+		slog.Error(
+			"could not map PNR entity to data model",
+			"id", id,
+			"error", err,
+		)
+		return err
+	}
+
+	err = r.putToSharedPrivateCollections(pnr, metaKey, metaModel)
+
+	if err != nil {
+		return err
+	}
+
+	err = r.setKeyEndorsement(r.sharedCollections(pnr), metaKey, pnr.RequestingPIU, pnr.RespondingPIU)
+
+	if err != nil {
+		return err
+	}
+
+	err = r.putToSharedPrivateCollections(pnr, dataKey, dataModel)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.putPNRKeys(pnr, func(key string, value []byte) error {
+		return r.putToSharedPrivateCollections(pnr, key, value)
+	})
+
+	return err
+}
+
+// InsertLocalPNR writes a PNR request into the local collection only, so that
+// it is not visible to the other PIU, e.g. while it awaits approval.
+func (r *PrivateDataRepository) InsertLocalPNR(id string, pnr entities.PNR) error {
+	exists, _ := r.PNRExists(id)
+
+	if exists {
+		return errors.New("PNR already exists")
+	}
+
+	metaKey, err := getPNRMetaCompositeKey(id)
+
+	if err != nil {
+		slog.Error(
+			"could not create PNR metadata composite key",
+			"id", id,
+			"error", err,
+		)
+		return err
+	}
+
+	dataKey, err := getPNRDataCompositeKey(id)
+
+	if err != nil {
+		slog.Error(
+			"could not create PNR data composite key",
+			"id", id,
+			"error", err,
+		)
+		return err
+	}
+
+	metaModel, err := pnrEntityToMetaModel(pnr)
+	if err != nil {
+		slog.Error(
+			"could not map PNR entity to metadata model",
+			"id", id,
+			"error", err,
+		)
+		return err
+	}
+
+	dataModel, err := pnrEntityToDataModel(pnr)
+	if err != nil {
+		slog.Error(
+			"could not map PNR entity to data model",
+			"id", id,
+			"error", err,
+		)
+		return err
+	}
+
+	err = r.ctx.GetStub().PutPrivateData(r.localData, metaKey, metaModel)
+
+	if err != nil {
+		slog.Error(
+			"could not put data into local private collection",
+			"key", metaKey,
+			"error", err,
+		)
+		return err
+	}
+
+	err = r.setKeyEndorsement([]string{r.localData}, metaKey, r.piuId)
+
+	if err != nil {
+		return err
+	}
+
+	err = r.ctx.GetStub().PutPrivateData(r.localData, dataKey, dataModel)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.putPNRKeys(pnr, func(key string, value []byte) error {
+		return r.ctx.GetStub().PutPrivateData(r.localData, key, value)
+	})
+
+	return err
+}
+
+// InsertForwardedPNR writes a PNR request created by forwarding together with
+// its GC metadata into the collections of its requesting and responding PIU
+// only, as the forwarding PIU does not have to be one of them.
+func (r *PrivateDataRepository) InsertForwardedPNR(pnr entities.PNR, gc entities.GCMetadata) error {
+	exists, _ := r.PNRExists(pnr.Id)
+
+	if exists {
+		return errors.New("PNR already exists")
+	}
+
+	metaKey, err := getPNRMetaCompositeKey(pnr.Id)
+
+	if err != nil {
+		slog.Error(
+			"could not create PNR metadata composite key",
+			"id", pnr.Id,
+			"error", err,
+		)
+		return err
+	}
+
+	dataKey, err := getPNRDataCompositeKey(pnr.Id)
 
 	if err != nil {
 		slog.Error(
-			"could not create PNR metadata composite key",
-			"id", id,
+			"could not create PNR data composite key",
+			"id", pnr.Id,
 			"error", err,
 		)
 		return err
 	}
 
-	dataKey, err := getPNRDataCompositeKey(id)
+	gcKey, err := getGCMetatadaCompositeKey(pnr.Id)
 
 	if err != nil {
 		slog.Error(
-			"could not create PNR data composite key",
-			"id", id,
+			"could not create GC metadata composite key",
+			"id", pnr.Id,
 			"error", err,
 		)
 		return err
 	}
 
+	for _, collection := range r.partyCollections(pnr) {
+		if collection == r.localData {
+			continue
+		}
+
+		existing, err := r.ctx.GetStub().GetPrivateDataHash(collection, metaKey)
+
+		if err == nil && existing != nil {
+			return errors.New("PNR already exists")
+		}
+	}
+
 	metaModel, err := pnrEntityToMetaModel(pnr)
 	if err != nil {
 		slog.Error(
 			"could not map PNR entity to metadata model",
-			"id", id,
+			"id", pnr.Id,
 			"error", err,
 		)
 		return err
@@ -457,22 +1407,49 @@ func (r *PrivateDataRepository) InsertPNR(id string, pnr entities.PNR) error {
 	if err != nil {
 		slog.Error(
 			"could not map PNR entity to data model",
-			"id", id,
+			"id", pnr.Id,
 			"error", err,
 		)
 		return err
 	}
 
-	remotePIU := getRemotePIU(pnr, r.piuId)
-	remoteData := getCollectionName(remotePIU)
+	gcMetadataModel, err := gcMetadataEntityToModel(gc)
+	if err != nil {
+		slog.Error(
+			"could not map GC metadata entity to model",
+			"id", pnr.Id,
+			"error", err,
+		)
+		return err
+	}
+
+	err = r.putToPartyPrivateCollections(pnr, metaKey, metaModel)
+
+	if err != nil {
+		return err
+	}
+
+	err = r.setKeyEndorsement(r.partyCollections(pnr), metaKey, pnr.RequestingPIU, pnr.RespondingPIU)
+
+	if err != nil {
+		return err
+	}
+
+	err = r.putToPartyPrivateCollections(pnr, dataKey, dataModel)
+
+	if err != nil {
+		return err
+	}
 
-	err = r.putToBothPrivateCollections(remoteData, metaKey, metaModel)
+	_, err = r.putPNRKeys(pnr, func(key string, value []byte) error {
+		return r.putToPartyPrivateCollections(pnr, key, value)
+	})
 
 	if err != nil {
 		return err
 	}
 
-	return r.putToBothPrivateCollections(remoteData, dataKey, dataModel)
+	return r.putToPartyPrivateCollections(pnr, gcKey, gcMetadataModel)
 }
 
 func (r *PrivateDataRepository) UpdatePNR(id string, pnr entities.PNR) error {
@@ -488,6 +1465,9 @@ func (r *PrivateDataRepository) UpdatePNR(id string, pnr entities.PNR) error {
 		return err
 	}
 
+	// The record is updated in the collections it was written into.
+	existing := pnrEntitiesToEntity(metaEntity, pnrData{})
+
 	metaModel, err := pnrEntityToMetaModel(pnr)
 	if err != nil {
 		slog.Error(
@@ -498,17 +1478,20 @@ func (r *PrivateDataRepository) UpdatePNR(id string, pnr entities.PNR) error {
 		return err
 	}
 
-	remotePIU := getRemotePIU(pnr, r.piuId)
-	remoteData := getCollectionName(remotePIU)
+	err = r.putToSharedPrivateCollections(existing, metaKey, metaModel)
+
+	if err != nil {
+		return err
+	}
 
-	err = r.putToBothPrivateCollections(remoteData, metaKey, metaModel)
+	err = r.setKeyEndorsement(r.sharedCollections(existing), metaKey, existing.RequestingPIU, existing.RespondingPIU)
 
 	if err != nil {
 		return err
 	}
 
 	if !entities.HasData(metaEntity.State) {
-		return nil
+		return r.deleteLocalCopies(existing, metaKey)
 	}
 
 	dataKey, _, err := r.getPNRData(id)
@@ -527,7 +1510,27 @@ func (r *PrivateDataRepository) UpdatePNR(id string, pnr entities.PNR) error {
 		return err
 	}
 
-	return r.putToBothPrivateCollections(remoteData, dataKey, dataModel)
+	err = r.putToSharedPrivateCollections(existing, dataKey, dataModel)
+
+	if err != nil {
+		return err
+	}
+
+	keysKey, err := r.putPNRKeys(pnr, func(key string, value []byte) error {
+		return r.putToSharedPrivateCollections(existing, key, value)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	keys := []string{metaKey, dataKey}
+
+	if keysKey != "" {
+		keys = append(keys, keysKey)
+	}
+
+	return r.deleteLocalCopies(existing, keys...)
 }
 
 func (r *PrivateDataRepository) UpdateLocalPNR(id string, pnr entities.PNR) error {
@@ -559,6 +1562,18 @@ func (r *PrivateDataRepository) UpdateLocalPNR(id string, pnr entities.PNR) erro
 		return err
 	}
 
+	existing := pnrEntitiesToEntity(metaEntity, pnrData{})
+
+	// A local copy of a shared record needs only the endorsement of the PIU
+	// keeping it.
+	if !slices.Contains(r.sharedCollections(existing), r.localData) {
+		err = r.setKeyEndorsement([]string{r.localData}, metaKey, r.piuId)
+
+		if err != nil {
+			return err
+		}
+	}
+
 	if !entities.HasData(metaEntity.State) {
 		return nil
 	}
@@ -579,7 +1594,17 @@ func (r *PrivateDataRepository) UpdateLocalPNR(id string, pnr entities.PNR) erro
 		return err
 	}
 
-	return r.ctx.GetStub().PutPrivateData(r.localData, dataKey, dataModel)
+	err = r.ctx.GetStub().PutPrivateData(r.localData, dataKey, dataModel)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.putPNRKeys(pnr, func(key string, value []byte) error {
+		return r.ctx.GetStub().PutPrivateData(r.localData, key, value)
+	})
+
+	return err
 }
 
 func (r *PrivateDataRepository) PurgePNRData(id string) error {
@@ -608,32 +1633,30 @@ func (r *PrivateDataRepository) PurgePNRData(id string) error {
 
 	pnr := pnrEntitiesToEntity(metaEntity, pnrData{})
 
-	remotePIU := getRemotePIU(pnr, r.piuId)
-	remoteData := getCollectionName(remotePIU)
-
-	err = r.ctx.GetStub().PurgePrivateData(remoteData, dataKey)
+	err = r.purgeFromPrivateCollections(pnr, dataKey)
 
 	if err != nil {
-		slog.Error(
-			"could not purge PNR data from remote collection",
-			"id", id,
-			"error", err,
-		)
 		return err
 	}
 
-	err = r.ctx.GetStub().PurgePrivateData(r.localData, dataKey)
+	if metaEntity.DataKeyId == "" {
+		return nil
+	}
+
+	// Destroying the wrapped data key leaves copies of the data, which may
+	// survive in backups of peers, unreadable.
+	keysKey, err := getPNRKeysCompositeKey(id)
 
 	if err != nil {
 		slog.Error(
-			"could not purge PNR data from local collection",
+			"could not create PNR keys composite key",
 			"id", id,
 			"error", err,
 		)
 		return err
 	}
 
-	return nil
+	return r.purgeFromPrivateCollections(pnr, keysKey)
 }
 
 func (r *PrivateDataRepository) PurgeLocalPNRData(id string) error {
@@ -665,6 +1688,38 @@ func (r *PrivateDataRepository) PurgeLocalPNRData(id string) error {
 		return err
 	}
 
+	_, metaEntity, err := r.getPNRMeta(id)
+
+	if err != nil {
+		return err
+	}
+
+	if metaEntity.DataKeyId == "" {
+		return nil
+	}
+
+	keysKey, err := getPNRKeysCompositeKey(id)
+
+	if err != nil {
+		slog.Error(
+			"could not create PNR keys composite key",
+			"id", id,
+			"error", err,
+		)
+		return err
+	}
+
+	err = r.ctx.GetStub().PurgePrivateData(r.localData, keysKey)
+
+	if err != nil {
+		slog.Error(
+			"could not purge PNR keys from local collection",
+			"id", id,
+			"error", err,
+		)
+		return err
+	}
+
 	return nil
 }
 
@@ -680,7 +1735,7 @@ func (r *PrivateDataRepository) GCMetadataExists(id string) (bool, error) {
 		return false, err
 	}
 
-	gcMetadataModel, err := r.ctx.GetStub().GetPrivateData(r.localData, key)
+	gcMetadataModel, err := r.getVisiblePrivateData(key)
 
 	if err != nil {
 		slog.Error(
@@ -725,10 +1780,7 @@ func (r *PrivateDataRepository) InsertGCMetadata(pnr entities.PNR, gc entities.G
 		return err
 	}
 
-	remotePIU := getRemotePIU(pnr, r.piuId)
-	remoteData := getCollectionName(remotePIU)
-
-	err = r.putToBothPrivateCollections(remoteData, key, gcMetadataModel)
+	err = r.putToSharedPrivateCollections(pnr, key, gcMetadataModel)
 
 	if err != nil {
 		slog.Error(
@@ -771,10 +1823,7 @@ func (r *PrivateDataRepository) UpdateGCMetadata(pnr entities.PNR, gc entities.G
 		return err
 	}
 
-	remotePIU := getRemotePIU(pnr, r.piuId)
-	remoteData := getCollectionName(remotePIU)
-
-	err = r.putToBothPrivateCollections(remoteData, key, gcMetadataModel)
+	err = r.putToSharedPrivateCollections(pnr, key, gcMetadataModel)
 
 	if err != nil {
 		slog.Error(
@@ -795,9 +1844,6 @@ func (r *PrivateDataRepository) DeleteGCMetadata(pnr entities.PNR) error {
 		return errors.New("GC metadata does not exist")
 	}
 
-	remotePIU := getRemotePIU(pnr, r.piuId)
-	remoteData := getCollectionName(remotePIU)
-
 	key, err := getGCMetatadaCompositeKey(pnr.Id)
 
 	if err != nil {
@@ -809,26 +1855,18 @@ func (r *PrivateDataRepository) DeleteGCMetadata(pnr entities.PNR) error {
 		return err
 	}
 
-	err = r.ctx.GetStub().DelPrivateData(remoteData, key)
-
-	if err != nil {
-		slog.Error(
-			"could not delete GC metadata from remote collection",
-			"id", pnr.Id,
-			"error", err,
-		)
-		return err
-	}
-
-	err = r.ctx.GetStub().DelPrivateData(r.localData, key)
+	for _, collection := range r.purgeCollections(pnr) {
+		err = r.ctx.GetStub().DelPrivateData(collection, key)
 
-	if err != nil {
-		slog.Error(
-			"could not delete GC metadata from local collection",
-			"id", pnr.Id,
-			"error", err,
-		)
-		return err
+		if err != nil {
+			slog.Error(
+				"could not delete GC metadata from private collection",
+				"id", pnr.Id,
+				"collection", collection,
+				"error", err,
+			)
+			return err
+		}
 	}
 
 	return nil
@@ -878,7 +1916,7 @@ func (r *PrivateDataRepository) GetGCMetadata(id string) (entities.GCMetadata, e
 		return entities.GCMetadata{}, err
 	}
 
-	gcMetadataModel, err := r.ctx.GetStub().GetPrivateData(r.localData, key)
+	gcMetadataModel, err := r.getVisiblePrivateData(key)
 
 	if err != nil {
 		slog.Error(
@@ -906,30 +1944,164 @@ func (r *PrivateDataRepository) GetGCMetadata(id string) (entities.GCMetadata, e
 func (r *PrivateDataRepository) GetGCMetadatas() ([]entities.GCMetadata, error) {
 	var result []entities.GCMetadata
 
-	iterator, err := r.ctx.GetStub().GetPrivateDataByPartialCompositeKey(r.localData, gcMetadataObjectType, []string{})
+	records, err := r.getVisiblePrivateDataByPartialCompositeKey(gcMetadataObjectType, []string{})
 	if err != nil {
-		slog.Error(
-			err.Error(),
-		)
 		return []entities.GCMetadata{}, err
 	}
-	defer iterator.Close()
 
-	for iterator.HasNext() {
-		queryResponse, err := iterator.Next()
+	for _, queryResponse := range records {
+		pnr, err := gcMetadataModelToEntity(queryResponse.Value)
 		if err != nil {
 			return nil, err
 		}
+		result = append(result, pnr)
+	}
 
-		pnr, err := gcMetadataModelToEntity(queryResponse.Value)
+	return result, nil
+}
+
+func (r *PrivateDataRepository) getLocalPNRClarificationKeys(id string) ([]string, error) {
+	records, err := r.getPrivateDataByPartialCompositeKey(r.localData, clarificationObjectType, []string{id})
+	if err != nil {
+		return nil, err
+	}
+
+	return recordKeys(records), nil
+}
+
+func (r *PrivateDataRepository) getPNRClarificationKeys(id string) ([]string, error) {
+	records, err := r.getVisiblePrivateDataByPartialCompositeKey(clarificationObjectType, []string{id})
+	if err != nil {
+		return nil, err
+	}
+
+	return recordKeys(records), nil
+}
+
+func recordKeys(records []*queryresult.KV) []string {
+	var result []string
+
+	for _, record := range records {
+		result = append(result, record.Key)
+	}
+
+	return result
+}
+
+func (r *PrivateDataRepository) InsertPNRClarification(pnr entities.PNR, msg entities.ClarificationMessage) error {
+	key, err := getClarificationCompositeKey(pnr.Id, msg.Sequence)
+
+	if err != nil {
+		slog.Error(
+			"could not create clarification composite key",
+			"id", pnr.Id,
+			"error", err,
+		)
+		return err
+	}
+
+	existing, err := r.getVisiblePrivateData(key)
+
+	if err != nil {
+		slog.Error(
+			"could not get clarification",
+			"id", pnr.Id,
+			"error", err,
+		)
+		return err
+	}
+
+	if existing != nil {
+		return errors.New("clarification already exists")
+	}
+
+	model, err := clarificationEntityToModel(msg)
+
+	if err != nil {
+		slog.Error(
+			"could not map clarification entity to model",
+			"id", pnr.Id,
+			"error", err,
+		)
+		return err
+	}
+
+	return r.putToSharedPrivateCollections(pnr, key, model)
+}
+
+func (r *PrivateDataRepository) GetPNRClarifications(id string) ([]entities.ClarificationMessage, error) {
+	var result []entities.ClarificationMessage
+
+	records, err := r.getVisiblePrivateDataByPartialCompositeKey(clarificationObjectType, []string{id})
+	if err != nil {
+		return []entities.ClarificationMessage{}, err
+	}
+
+	for _, queryResponse := range records {
+		msg, err := clarificationModelToEntity(queryResponse.Value)
 		if err != nil {
 			return nil, err
 		}
-		result = append(result, pnr)
+		result = append(result, msg)
 	}
 
 	return result, nil
 }
 
+func (r *PrivateDataRepository) PurgePNRClarifications(id string) error {
+	exists, _ := r.PNRExists(id)
+
+	if !exists {
+		return errors.New("PNR does not exist")
+	}
+
+	_, metaEntity, err := r.getPNRMeta(id)
+
+	if err != nil {
+		return err
+	}
+
+	keys, err := r.getPNRClarificationKeys(id)
+
+	if err != nil {
+		return err
+	}
+
+	pnr := pnrEntitiesToEntity(metaEntity, pnrData{})
+
+	for _, key := range keys {
+		err = r.purgeFromPrivateCollections(pnr, key)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *PrivateDataRepository) PurgeLocalPNRClarifications(id string) error {
+	keys, err := r.getLocalPNRClarificationKeys(id)
+
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		err = r.ctx.GetStub().PurgePrivateData(r.localData, key)
+
+		if err != nil {
+			slog.Error(
+				"could not purge clarification from local collection",
+				"id", id,
+				"error", err,
+			)
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (r *PrivateDataRepository) Close() {
 }
@@ -1,13 +1,19 @@
 package privatedata_test
 
 import (
+	"crypto/sha256"
+	"encoding/json"
 	"testing"
 
 	"github.com/google/uuid"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"github.com/nesfit/shimtest/pkg/shimtest"
 
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
 	"github.com/nesfit/tenacity-chaincode/pkg/repository"
 	"github.com/nesfit/tenacity-chaincode/pkg/repository/privatedata"
 	"github.com/nesfit/tenacity-chaincode/pkg/testdata"
@@ -18,11 +24,21 @@ func newMockTransactionContext() *shimtest.MockTransactionContext {
 }
 
 type publicLedgerRepositoryFactory struct {
+	naming     privatedata.CollectionNaming
+	pairNaming privatedata.PairCollectionNaming
+	pairPIUs   []string
 }
 
 func (f publicLedgerRepositoryFactory) New() (repository.Repository, repository.TransactionManager) {
 	ctx := newMockTransactionContext()
-	return privatedata.NewPrivateDataRepository(ctx, testdata.PIUs[0].Id), newTransactionManager(ctx)
+
+	opts := []privatedata.PrivateDataRepositoryOption{privatedata.WithCollectionNaming(f.naming)}
+
+	if f.pairNaming != nil {
+		opts = append(opts, privatedata.WithPairCollections(f.pairNaming, f.pairPIUs))
+	}
+
+	return privatedata.NewPrivateDataRepository(ctx, testdata.PIUs[0].Id, opts...), newTransactionManager(ctx)
 }
 
 type publicledgerTransactionManager struct {
@@ -45,6 +61,493 @@ func (txm publicledgerTransactionManager) End() {
 }
 
 func TestRepositorySuite(t *testing.T) {
-	s := repository.NewRepositoryTestSuite(publicLedgerRepositoryFactory{})
+	s := repository.NewRepositoryTestSuite(publicLedgerRepositoryFactory{naming: privatedata.ExplicitCollectionNaming})
+	suite.Run(t, s)
+}
+
+func TestRepositorySuiteImplicitCollections(t *testing.T) {
+	s := repository.NewRepositoryTestSuite(publicLedgerRepositoryFactory{naming: privatedata.ImplicitOrgCollectionNaming})
 	suite.Run(t, s)
 }
+
+func TestRepositorySuitePairCollections(t *testing.T) {
+	s := repository.NewRepositoryTestSuite(publicLedgerRepositoryFactory{
+		naming:     privatedata.ExplicitCollectionNaming,
+		pairNaming: privatedata.SortedPairCollectionNaming,
+		pairPIUs:   []string{"piu1", "piu2", "piu3", "new-piu", "other-new-piu"},
+	})
+	suite.Run(t, s)
+}
+
+func TestPairCollectionsStoreRecordsOnce(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := newMockTransactionContext()
+	stub := ctx.GetStub().(*shimtest.MockStub)
+	txm := newTransactionManager(ctx)
+	r := privatedata.NewPrivateDataRepository(ctx, "piu1", privatedata.WithPairCollections(privatedata.SortedPairCollectionNaming, []string{"piu1", "piu2"}))
+
+	pnr := testdata.PNRs[0]
+	metaKey, _ := shim.CreateCompositeKey("pnrMeta", []string{pnr.Id})
+
+	txm.Start()
+	err := r.InsertPNR(pnr.Id, pnr)
+	txm.End()
+	assert.NoError(err)
+
+	assert.NotNil(stub.PvtState["piu1_piu2"][metaKey])
+	assert.Nil(stub.PvtState["piu1Collection"][metaKey])
+	assert.Nil(stub.PvtState["piu2Collection"][metaKey])
+
+	other := privatedata.NewPrivateDataRepository(ctx, "piu2", privatedata.WithPairCollections(privatedata.SortedPairCollectionNaming, []string{"piu1", "piu2"}))
+	actual, err := other.GetPNR(pnr.Id)
+	assert.NoError(err)
+	assert.Equal(pnr, actual)
+}
+
+func TestPairCollectionsApproveDraft(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := newMockTransactionContext()
+	stub := ctx.GetStub().(*shimtest.MockStub)
+	txm := newTransactionManager(ctx)
+	r := privatedata.NewPrivateDataRepository(ctx, "piu1", privatedata.WithPairCollections(privatedata.SortedPairCollectionNaming, []string{"piu1", "piu2"}))
+
+	draft := testdata.PNRs[0]
+	draft.State = entities.RequestStateDraft
+	metaKey, _ := shim.CreateCompositeKey("pnrMeta", []string{draft.Id})
+
+	txm.Start()
+	err := r.InsertLocalPNR(draft.Id, draft)
+	txm.End()
+	assert.NoError(err)
+
+	assert.NotNil(stub.PvtState["piu1Collection"][metaKey])
+	assert.Nil(stub.PvtState["piu1_piu2"][metaKey])
+
+	approved := testdata.PNRs[0]
+
+	txm.Start()
+	err = r.UpdatePNR(approved.Id, approved)
+	txm.End()
+	assert.NoError(err)
+
+	assert.Nil(stub.PvtState["piu1Collection"][metaKey])
+	assert.NotNil(stub.PvtState["piu1_piu2"][metaKey])
+
+	actual, err := r.GetPNR(approved.Id)
+	assert.NoError(err)
+	assert.Equal(approved, actual)
+}
+
+func keyEndorsementOrgs(stub *shimtest.MockStub, collection string, key string) []string {
+	policy, _ := stub.GetPrivateDataValidationParameter(collection, key)
+
+	if policy == nil {
+		return nil
+	}
+
+	ep, _ := statebased.NewStateEP(policy)
+
+	return ep.ListOrgs()
+}
+
+func TestKeyEndorsement(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := newMockTransactionContext()
+	stub := ctx.GetStub().(*shimtest.MockStub)
+	txm := newTransactionManager(ctx)
+	r := privatedata.NewPrivateDataRepository(ctx, "piu1", privatedata.WithKeyEndorsement(func(piuId string) string {
+		return piuId + "MSP"
+	}))
+
+	pnr := testdata.PNRs[0]
+	metaKey, _ := shim.CreateCompositeKey("pnrMeta", []string{pnr.Id})
+
+	txm.Start()
+	err := r.InsertPNR(pnr.Id, pnr)
+	txm.End()
+	assert.NoError(err)
+
+	assert.ElementsMatch([]string{"piu1MSP", "piu2MSP"}, keyEndorsementOrgs(stub, "piu1Collection", metaKey))
+	assert.ElementsMatch([]string{"piu1MSP", "piu2MSP"}, keyEndorsementOrgs(stub, "piu2Collection", metaKey))
+
+	draft := testdata.PNRs[1]
+	draft.State = entities.RequestStateDraft
+	draftKey, _ := shim.CreateCompositeKey("pnrMeta", []string{draft.Id})
+
+	txm.Start()
+	err = r.InsertLocalPNR(draft.Id, draft)
+	txm.End()
+	assert.NoError(err)
+
+	assert.Equal([]string{"piu1MSP"}, keyEndorsementOrgs(stub, "piu1Collection", draftKey))
+	assert.Nil(keyEndorsementOrgs(stub, "piu2Collection", draftKey))
+
+	txm.Start()
+	err = r.UpdatePNR(draft.Id, testdata.PNRs[1])
+	txm.End()
+	assert.NoError(err)
+
+	assert.ElementsMatch([]string{"piu1MSP", "piu2MSP"}, keyEndorsementOrgs(stub, "piu1Collection", draftKey))
+	assert.ElementsMatch([]string{"piu1MSP", "piu2MSP"}, keyEndorsementOrgs(stub, "piu2Collection", draftKey))
+}
+
+func TestKeyEndorsementPairCollections(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := newMockTransactionContext()
+	stub := ctx.GetStub().(*shimtest.MockStub)
+	txm := newTransactionManager(ctx)
+	r := privatedata.NewPrivateDataRepository(
+		ctx,
+		"piu1",
+		privatedata.WithPairCollections(privatedata.SortedPairCollectionNaming, []string{"piu1", "piu2"}),
+		privatedata.WithKeyEndorsement(func(piuId string) string {
+			return piuId + "MSP"
+		}),
+	)
+
+	pnr := testdata.PNRs[0]
+	metaKey, _ := shim.CreateCompositeKey("pnrMeta", []string{pnr.Id})
+
+	txm.Start()
+	err := r.InsertPNR(pnr.Id, pnr)
+	txm.End()
+	assert.NoError(err)
+
+	assert.ElementsMatch([]string{"piu1MSP", "piu2MSP"}, keyEndorsementOrgs(stub, "piu1_piu2", metaKey))
+
+	terminated := pnr
+	terminated.State = entities.RequestStateTerminated
+
+	txm.Start()
+	err = r.UpdateLocalPNR(pnr.Id, terminated)
+	txm.End()
+	assert.NoError(err)
+
+	assert.Equal([]string{"piu1MSP"}, keyEndorsementOrgs(stub, "piu1Collection", metaKey))
+	assert.ElementsMatch([]string{"piu1MSP", "piu2MSP"}, keyEndorsementOrgs(stub, "piu1_piu2", metaKey))
+}
+
+func storedModelVersion(data []byte) any {
+	var envelope map[string]any
+	json.Unmarshal(data, &envelope)
+	return envelope["v"]
+}
+
+func TestMigrateData(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := newMockTransactionContext()
+	stub := ctx.GetStub().(*shimtest.MockStub)
+	txm := newTransactionManager(ctx)
+	r := privatedata.NewPrivateDataRepository(ctx, "piu1")
+
+	piu := testdata.PIUs[0]
+	pnr := testdata.PNRs[0]
+	piuKey, _ := shim.CreateCompositeKey("piu", []string{piu.Id})
+	metaKey, _ := shim.CreateCompositeKey("pnrMeta", []string{pnr.Id})
+	dataKey, _ := shim.CreateCompositeKey("pnrData", []string{pnr.Id})
+
+	txm.Start()
+	stub.PutState(piuKey, []byte(`{"id": "`+piu.Id+`", "name": "`+piu.Name+`"}`))
+	stub.PutPrivateData("piu1Collection", metaKey, []byte(`{"id": "`+pnr.Id+`", "requestingPIU": "`+pnr.RequestingPIU+`", "respondingPIU": "`+pnr.RespondingPIU+`", "state": "Pending"}`))
+	stub.PutPrivateData("piu1Collection", dataKey, []byte(`{"requestData": "request", "responseData": ""}`))
+	txm.End()
+
+	expected, err := r.GetPNR(pnr.Id)
+	assert.NoError(err)
+	assert.Equal(pnr.RequestingPIU, expected.RequestingPIU)
+	assert.Equal("request", expected.RequestData)
+
+	migrated := 0
+	batches := 0
+	bookmark := ""
+
+	for {
+		txm.Start()
+		output, err := r.MigrateData(bookmark, 1)
+		txm.End()
+		assert.NoError(err)
+
+		migrated += output.Migrated
+		batches++
+		bookmark = output.Bookmark
+
+		if bookmark == "" || batches > 3 {
+			break
+		}
+	}
+
+	assert.Equal(3, migrated)
+	assert.Equal(3, batches)
+
+	assert.Equal(float64(1), storedModelVersion(stub.State[piuKey]))
+	assert.Equal(float64(1), storedModelVersion(stub.PvtState["piu1Collection"][metaKey]))
+	assert.Equal(float64(1), storedModelVersion(stub.PvtState["piu1Collection"][dataKey]))
+
+	actual, err := r.GetPNR(pnr.Id)
+	assert.NoError(err)
+	assert.Equal(expected, actual)
+
+	actualPIU, err := r.GetPIU(piu.Id)
+	assert.NoError(err)
+	assert.Equal(piu.Name, actualPIU.Name)
+
+	txm.Start()
+	output, err := r.MigrateData("", 1)
+	txm.End()
+	assert.NoError(err)
+	assert.Zero(output.Migrated)
+
+	_, err = r.MigrateData("invalid", 1)
+	assert.Error(err)
+}
+
+// hashingStub keeps hashes of private data, which the mock stub does not
+// provide, so that they outlive the data lost by peers.
+type hashingStub struct {
+	*shimtest.MockStub
+	hashes map[string]map[string][]byte
+}
+
+func (s *hashingStub) PutPrivateData(collection string, key string, value []byte) error {
+	if s.hashes[collection] == nil {
+		s.hashes[collection] = map[string][]byte{}
+	}
+
+	sum := sha256.Sum256(value)
+	s.hashes[collection][key] = sum[:]
+
+	return s.MockStub.PutPrivateData(collection, key, value)
+}
+
+func (s *hashingStub) GetPrivateDataHash(collection string, key string) ([]byte, error) {
+	return s.hashes[collection][key], nil
+}
+
+// loseRecords removes private data of the collection while keeping their
+// hashes.
+func (s *hashingStub) loseRecords(collection string) {
+	for key := range s.PvtState[collection] {
+		s.MockStub.DelPrivateData(collection, key)
+	}
+}
+
+type hashingTransactionContext struct {
+	*shimtest.MockTransactionContext
+	stub *hashingStub
+}
+
+func (ctx *hashingTransactionContext) GetStub() shim.ChaincodeStubInterface {
+	return ctx.stub
+}
+
+func newHashingTransactionContext() (*hashingTransactionContext, repository.TransactionManager) {
+	ctx := newMockTransactionContext()
+	stub := &hashingStub{MockStub: ctx.GetStub().(*shimtest.MockStub), hashes: map[string]map[string][]byte{}}
+
+	return &hashingTransactionContext{MockTransactionContext: ctx, stub: stub}, newTransactionManager(ctx)
+}
+
+func TestBackupAndRestoreLocalRecords(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, txm := newHashingTransactionContext()
+	r := privatedata.NewPrivateDataRepository(ctx, "piu1")
+
+	pnr := testdata.PNRs[0]
+
+	txm.Start()
+	r.InsertPNR(pnr.Id, pnr)
+	r.InsertGCMetadata(pnr, entities.GCMetadata{Id: pnr.Id, CreationTimestamp: pnr.RequestTimestamp})
+	txm.End()
+
+	archive, err := r.BackupLocalRecords()
+	assert.NoError(err)
+	assert.Equal("piu1Collection", archive.Collection)
+	assert.Len(archive.Records, 3)
+
+	ctx.stub.loseRecords("piu1Collection")
+
+	_, err = r.GetPNR(pnr.Id)
+	assert.Error(err)
+
+	tampered := archive.Records[0]
+	tampered.Value = []byte(`{}`)
+
+	txm.Start()
+	output, err := r.RestoreLocalRecords(append([]entities.ArchiveRecord{tampered}, archive.Records[1:]...))
+	txm.End()
+	assert.NoError(err)
+	assert.Equal(2, output.Restored)
+	assert.Len(output.Mismatched, 1)
+
+	txm.Start()
+	output, err = r.RestoreLocalRecords(archive.Records)
+	txm.End()
+	assert.NoError(err)
+	assert.Equal(1, output.Restored)
+	assert.Equal(2, output.Present)
+
+	actual, err := r.GetPNR(pnr.Id)
+	assert.NoError(err)
+	assert.Equal(pnr, actual)
+
+	piuKey, _ := shim.CreateCompositeKey("piu", []string{"piu1"})
+
+	_, err = r.RestoreLocalRecords([]entities.ArchiveRecord{{Key: piuKey, Value: []byte(`{}`)}})
+	assert.Error(err)
+}
+
+func TestRestoreSharedRecords(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, txm := newHashingTransactionContext()
+	r := privatedata.NewPrivateDataRepository(ctx, "piu1")
+
+	shared := testdata.PNRs[0]
+	other := testdata.PNRs[0]
+	other.Id = "otherPNR"
+	other.RespondingPIU = "piu3"
+
+	txm.Start()
+	r.InsertPNR(shared.Id, shared)
+	r.InsertPNR(other.Id, other)
+	txm.End()
+
+	ctx.stub.loseRecords("piu2Collection")
+
+	txm.Start()
+	output, err := r.RestoreSharedRecords("piu2")
+	txm.End()
+	assert.NoError(err)
+	assert.Equal(2, output.Restored)
+
+	remote := privatedata.NewPrivateDataRepository(ctx, "piu2")
+
+	actual, err := remote.GetPNR(shared.Id)
+	assert.NoError(err)
+	assert.Equal(shared, actual)
+
+	exists, _ := remote.PNRExists(other.Id)
+	assert.False(exists)
+
+	_, err = r.RestoreSharedRecords("piu1")
+	assert.Error(err)
+
+	pairwise := privatedata.NewPrivateDataRepository(ctx, "piu1", privatedata.WithPairCollections(privatedata.SortedPairCollectionNaming, []string{"piu1", "piu2"}))
+
+	_, err = pairwise.RestoreSharedRecords("piu2")
+	assert.Error(err)
+}
+
+func TestComparePNRCopies(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, txm := newHashingTransactionContext()
+	r := privatedata.NewPrivateDataRepository(ctx, "piu1")
+
+	pnr := testdata.PNRs[0]
+
+	txm.Start()
+	r.InsertPNR(pnr.Id, pnr)
+	r.InsertGCMetadata(pnr, entities.GCMetadata{Id: pnr.Id, CreationTimestamp: pnr.RequestTimestamp})
+	txm.End()
+
+	actual, err := r.ComparePNRCopies(pnr)
+	assert.NoError(err)
+	assert.Len(actual, 3)
+
+	for _, key := range actual {
+		assert.True(key.Consistent)
+		assert.Len(key.Copies, 2)
+		assert.NotEmpty(key.Copies[0].SHA256)
+	}
+
+	terminated := pnr
+	terminated.State = entities.RequestStateTerminated
+
+	txm.Start()
+	r.UpdateLocalPNR(pnr.Id, terminated)
+	txm.End()
+
+	actual, err = r.ComparePNRCopies(pnr)
+	assert.NoError(err)
+	assert.Equal("pnrMeta:"+pnr.Id, actual[0].Key)
+	assert.False(actual[0].Consistent)
+	assert.True(actual[1].Consistent)
+}
+
+func TestComparePNRCopiesPairCollections(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, txm := newHashingTransactionContext()
+	r := privatedata.NewPrivateDataRepository(ctx, "piu1", privatedata.WithPairCollections(privatedata.SortedPairCollectionNaming, []string{"piu1", "piu2"}))
+
+	pnr := testdata.PNRs[0]
+
+	txm.Start()
+	r.InsertPNR(pnr.Id, pnr)
+	txm.End()
+
+	actual, err := r.ComparePNRCopies(pnr)
+	assert.NoError(err)
+	assert.True(actual[0].Consistent)
+	assert.Equal([]entities.RecordCopy{{Collection: "piu1_piu2", SHA256: actual[0].Copies[0].SHA256}}, actual[0].Copies)
+
+	terminated := pnr
+	terminated.State = entities.RequestStateTerminated
+
+	txm.Start()
+	r.UpdateLocalPNR(pnr.Id, terminated)
+	txm.End()
+
+	actual, err = r.ComparePNRCopies(pnr)
+	assert.NoError(err)
+	assert.False(actual[0].Consistent)
+	assert.Len(actual[0].Copies, 2)
+}
+
+func TestPurgePNRDataDestroysDataKeys(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := newMockTransactionContext()
+	txm := newTransactionManager(ctx)
+	r := privatedata.NewPrivateDataRepository(ctx, "piu1")
+
+	pnr := testdata.PNRs[0]
+	pnr.DataKeyId = "dataKeyId"
+	pnr.DataKeys = map[string]entities.EncryptedPayload{
+		pnr.RequestingPIU: {Format: entities.EncryptedPayloadFormat, KeyId: "requester"},
+		pnr.RespondingPIU: {Format: entities.EncryptedPayloadFormat, KeyId: "responder"},
+	}
+
+	txm.Start()
+	err := r.InsertPNR(pnr.Id, pnr)
+	txm.End()
+	assert.NoError(err)
+
+	actual, err := r.GetPNR(pnr.Id)
+	assert.NoError(err)
+	assert.Equal(pnr, actual)
+
+	key, _ := shim.CreateCompositeKey("pnrKey", []string{pnr.Id})
+
+	for _, collection := range []string{"piu1Collection", "piu2Collection"} {
+		stored, _ := ctx.GetStub().GetPrivateData(collection, key)
+		assert.NotNil(stored)
+	}
+
+	txm.Start()
+	err = r.PurgePNRData(pnr.Id)
+	txm.End()
+	assert.NoError(err)
+
+	for _, collection := range []string{"piu1Collection", "piu2Collection"} {
+		stored, _ := ctx.GetStub().GetPrivateData(collection, key)
+		assert.Nil(stored)
+	}
+}
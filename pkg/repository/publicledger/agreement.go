@@ -0,0 +1,68 @@
+package publicledger
+
+import (
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+	"github.com/nesfit/tenacity-chaincode/pkg/repository/storedmodel"
+)
+
+type agreementModel []byte
+
+type agreementUsageModel []byte
+
+const agreementObjectType = "agreement"
+
+const agreementUsageObjectType = "agreementUsage"
+
+func agreementEntityToModel(entity entities.Agreement) (agreementModel, error) {
+	model, err := storedmodel.Encode(entity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+func agreementModelToEntity(model agreementModel) (entities.Agreement, error) {
+	var entity entities.Agreement
+
+	_, err := storedmodel.Decode(model, &entity)
+
+	if err != nil {
+		return entities.Agreement{}, err
+	}
+
+	return entity, nil
+}
+
+func getAgreementCompositeKey(id string) (string, error) {
+	return shim.CreateCompositeKey(agreementObjectType, []string{id})
+}
+
+func agreementUsageEntityToModel(entity entities.AgreementUsage) (agreementUsageModel, error) {
+	model, err := storedmodel.Encode(entity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+func agreementUsageModelToEntity(model agreementUsageModel) (entities.AgreementUsage, error) {
+	var entity entities.AgreementUsage
+
+	_, err := storedmodel.Decode(model, &entity)
+
+	if err != nil {
+		return entities.AgreementUsage{}, err
+	}
+
+	return entity, nil
+}
+
+func getAgreementUsageCompositeKey(id string) (string, error) {
+	return shim.CreateCompositeKey(agreementUsageObjectType, []string{id})
+}
@@ -0,0 +1,40 @@
+package publicledger
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+	"github.com/nesfit/tenacity-chaincode/pkg/repository/storedmodel"
+)
+
+type clarificationModel []byte
+
+const clarificationObjectType = "pnrMsg"
+
+func clarificationEntityToModel(entity entities.ClarificationMessage) (clarificationModel, error) {
+	model, err := storedmodel.Encode(entity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+func clarificationModelToEntity(model clarificationModel) (entities.ClarificationMessage, error) {
+	var entity entities.ClarificationMessage
+
+	_, err := storedmodel.Decode(model, &entity)
+
+	if err != nil {
+		return entities.ClarificationMessage{}, err
+	}
+
+	return entity, nil
+}
+
+func getClarificationCompositeKey(id string, sequence int) (string, error) {
+	return shim.CreateCompositeKey(clarificationObjectType, []string{id, fmt.Sprintf("%08d", sequence)})
+}
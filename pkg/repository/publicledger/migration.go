@@ -0,0 +1,200 @@
+package publicledger
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+	"github.com/nesfit/tenacity-chaincode/pkg/repository/storedmodel"
+)
+
+// modelMigration decodes a stored model of any supported version and encodes
+// it in the current one.
+type modelMigration func(data []byte) ([]byte, error)
+
+func migrateModel[M ~[]byte, E any](decode func(M) (E, error)) modelMigration {
+	return func(data []byte) ([]byte, error) {
+		entity, err := decode(M(data))
+
+		if err != nil {
+			return nil, err
+		}
+
+		return storedmodel.Encode(entity)
+	}
+}
+
+// migrationSource is the set of records of one object type.
+type migrationSource struct {
+	objectType string
+	migrate    modelMigration
+}
+
+// migrationSources lists stored records in the order they are migrated.
+var migrationSources = []migrationSource{
+	{objectType: piuObjectType, migrate: migrateModel(piuModelToEntity)},
+	{objectType: agreementObjectType, migrate: migrateModel(agreementModelToEntity)},
+	{objectType: agreementUsageObjectType, migrate: migrateModel(agreementUsageModelToEntity)},
+	{objectType: configObjectType, migrate: migrateModel(configModelToEntity)},
+	{objectType: pnrObjectType, migrate: migrateModel(pnrModelToEntity)},
+	{objectType: gcMetadataObjectType, migrate: migrateModel(gcMetadataModelToEntity)},
+	{objectType: clarificationObjectType, migrate: migrateModel(clarificationModelToEntity)},
+	{objectType: quotaCounterObjectType, migrate: migrateModel(quotaCounterModelToEntity)},
+}
+
+// migrationBookmark is the position of the first record of the next batch.
+type migrationBookmark struct {
+	Source int    `json:"source"`
+	Key    string `json:"key"`
+}
+
+func decodeMigrationBookmark(bookmark string) (migrationBookmark, error) {
+	var position migrationBookmark
+
+	if bookmark == "" {
+		return position, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(bookmark)
+
+	if err != nil {
+		return position, err
+	}
+
+	err = json.Unmarshal(data, &position)
+
+	if err == nil && position.Source < 0 {
+		err = errors.New("invalid migration bookmark")
+	}
+
+	return position, err
+}
+
+func encodeMigrationBookmark(position migrationBookmark) (string, error) {
+	data, err := json.Marshal(position)
+
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// migrateSource rewrites records of the source stored with an older model
+// version until the limit is reached, in which case it returns the bookmark
+// of the first record left. The iteration starts at the first key of the
+// source, since range queries refuse composite keys and paginated queries are
+// only supported in read only transactions.
+func (r *PublicLedgerRepository) migrateSource(index int, from string, limit int, output *entities.MigrateDataOutput) error {
+	source := migrationSources[index]
+
+	iterator, err := r.ctx.GetStub().GetStateByPartialCompositeKey(source.objectType, []string{})
+
+	if err != nil {
+		slog.Error(
+			"could not get records to migrate",
+			"objectType", source.objectType,
+			"error", err,
+		)
+		return err
+	}
+	defer iterator.Close()
+
+	for iterator.HasNext() {
+		record, err := iterator.Next()
+
+		if err != nil {
+			return err
+		}
+
+		// Records before the bookmark were migrated by previous batches.
+		if record.Key < from {
+			continue
+		}
+
+		version, err := storedmodel.StoredVersion(record.Value)
+
+		if err != nil {
+			slog.Error(
+				"could not get version of stored model",
+				"key", record.Key,
+				"error", err,
+			)
+			return err
+		}
+
+		if version == storedmodel.Version {
+			continue
+		}
+
+		if limit > 0 && output.Migrated == limit {
+			output.Bookmark, err = encodeMigrationBookmark(migrationBookmark{Source: index, Key: record.Key})
+			return err
+		}
+
+		model, err := source.migrate(record.Value)
+
+		if err != nil {
+			slog.Error(
+				"could not migrate stored model",
+				"key", record.Key,
+				"version", version,
+				"error", err,
+			)
+			return err
+		}
+
+		err = r.ctx.GetStub().PutState(record.Key, model)
+
+		if err != nil {
+			slog.Error(
+				"could not store migrated model",
+				"key", record.Key,
+				"error", err,
+			)
+			return err
+		}
+
+		output.Migrated++
+	}
+
+	return nil
+}
+
+// MigrateData rewrites at most limit records stored with an older model
+// version, all of them when limit is not positive, starting at the bookmark.
+// The returned bookmark is empty once all records are migrated. A batch
+// resumed at the bookmark reads again the keys of its object type before it,
+// so limits should be large enough to keep the number of batches low.
+func (r *PublicLedgerRepository) MigrateData(bookmark string, limit int) (entities.MigrateDataOutput, error) {
+	var output entities.MigrateDataOutput
+
+	position, err := decodeMigrationBookmark(bookmark)
+
+	if err != nil {
+		slog.Error(
+			"invalid migration bookmark",
+			"bookmark", bookmark,
+			"error", err,
+		)
+		return output, err
+	}
+
+	for i := position.Source; i < len(migrationSources) && output.Bookmark == ""; i++ {
+		from := ""
+
+		if i == position.Source {
+			from = position.Key
+		}
+
+		err = r.migrateSource(i, from, limit, &output)
+
+		if err != nil {
+			return output, err
+		}
+	}
+
+	return output, nil
+}
@@ -1,11 +1,10 @@
 package publicledger
 
 import (
-	"encoding/json"
-
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 
 	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+	"github.com/nesfit/tenacity-chaincode/pkg/repository/storedmodel"
 )
 
 type piuModel []byte
@@ -13,7 +12,7 @@ type piuModel []byte
 const piuObjectType = "piu"
 
 func piuEntityToModel(entity entities.PIU) (piuModel, error) {
-	model, err := json.Marshal(entity)
+	model, err := storedmodel.Encode(entity)
 
 	if err != nil {
 		return nil, err
@@ -25,7 +24,7 @@ func piuEntityToModel(entity entities.PIU) (piuModel, error) {
 func piuModelToEntity(model piuModel) (entities.PIU, error) {
 	var entity entities.PIU
 
-	err := json.Unmarshal(model, &entity)
+	_, err := storedmodel.Decode(model, &entity)
 
 	if err != nil {
 		return entities.PIU{}, err
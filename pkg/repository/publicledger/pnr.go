@@ -1,11 +1,10 @@
 package publicledger
 
 import (
-	"encoding/json"
-
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 
 	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+	"github.com/nesfit/tenacity-chaincode/pkg/repository/storedmodel"
 )
 
 type pnrModel []byte
@@ -13,7 +12,7 @@ type pnrModel []byte
 const pnrObjectType = "pnr"
 
 func pnrEntityToModel(entity entities.PNR) (pnrModel, error) {
-	model, err := json.Marshal(entity)
+	model, err := storedmodel.Encode(entity)
 
 	if err != nil {
 		return nil, err
@@ -25,7 +24,7 @@ func pnrEntityToModel(entity entities.PNR) (pnrModel, error) {
 func pnrModelToEntity(model pnrModel) (entities.PNR, error) {
 	var entity entities.PNR
 
-	err := json.Unmarshal(model, &entity)
+	_, err := storedmodel.Decode(model, &entity)
 
 	if err != nil {
 		return entities.PNR{}, err
@@ -43,7 +42,7 @@ type gcMetadataModel []byte
 const gcMetadataObjectType = "gc"
 
 func gcMetadataEntityToModel(entity entities.GCMetadata) (gcMetadataModel, error) {
-	model, err := json.Marshal(entity)
+	model, err := storedmodel.Encode(entity)
 
 	if err != nil {
 		return nil, err
@@ -55,7 +54,7 @@ func gcMetadataEntityToModel(entity entities.GCMetadata) (gcMetadataModel, error
 func gcMetadataModelToEntity(model pnrModel) (entities.GCMetadata, error) {
 	var entity entities.GCMetadata
 
-	err := json.Unmarshal(model, &entity)
+	_, err := storedmodel.Decode(model, &entity)
 
 	if err != nil {
 		return entities.GCMetadata{}, err
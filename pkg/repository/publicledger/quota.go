@@ -0,0 +1,44 @@
+package publicledger
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+	"github.com/nesfit/tenacity-chaincode/pkg/repository/storedmodel"
+)
+
+type quotaCounterModel []byte
+
+const quotaCounterObjectType = "quota"
+
+// quotaBucketKeyLayout keeps lexicographical order of bucket keys equal to
+// their chronological order.
+const quotaBucketKeyLayout = "2006010215"
+
+func quotaCounterEntityToModel(entity entities.QuotaCounter) (quotaCounterModel, error) {
+	model, err := storedmodel.Encode(entity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+func quotaCounterModelToEntity(model quotaCounterModel) (entities.QuotaCounter, error) {
+	var entity entities.QuotaCounter
+
+	_, err := storedmodel.Decode(model, &entity)
+
+	if err != nil {
+		return entities.QuotaCounter{}, err
+	}
+
+	return entity, nil
+}
+
+func getQuotaCounterCompositeKey(piuId string, counterpart string, bucket time.Time) (string, error) {
+	return shim.CreateCompositeKey(quotaCounterObjectType, []string{piuId, counterpart, entities.GetQuotaBucket(bucket).Format(quotaBucketKeyLayout)})
+}
@@ -3,6 +3,7 @@ package publicledger
 import (
 	"errors"
 	"log/slog"
+	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 
@@ -112,6 +113,56 @@ func (r *PublicLedgerRepository) GetPIUs() ([]entities.PIU, error) {
 	return result, nil
 }
 
+func (r *PublicLedgerRepository) GetPIUHistory(id string) ([]entities.PIUHistoryEntry, error) {
+	result := []entities.PIUHistoryEntry{}
+
+	key, err := getPIUCompositeKey(id)
+
+	if err != nil {
+		slog.Error(
+			"could not create PIU composite key",
+			"id", id,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	iterator, err := r.ctx.GetStub().GetHistoryForKey(key)
+	if err != nil {
+		slog.Error(
+			"could not get PIU history",
+			"id", id,
+			"error", err,
+		)
+		return nil, err
+	}
+	defer iterator.Close()
+
+	for iterator.HasNext() {
+		modification, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		entry := entities.PIUHistoryEntry{
+			TxId:      modification.TxId,
+			Timestamp: modification.Timestamp.AsTime(),
+			IsDelete:  modification.IsDelete,
+		}
+
+		if !modification.IsDelete {
+			entry.PIU, err = piuModelToEntity(modification.Value)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		result = append(result, entry)
+	}
+
+	return result, nil
+}
+
 func (r *PublicLedgerRepository) InsertPIU(id string, piu entities.PIU) error {
 	exists, _ := r.PIUExists(id)
 
@@ -198,6 +249,420 @@ func (r *PublicLedgerRepository) UpdatePIU(id string, piu entities.PIU) error {
 	return nil
 }
 
+func (r *PublicLedgerRepository) AgreementExists(id string) (bool, error) {
+	key, err := getAgreementCompositeKey(id)
+
+	if err != nil {
+		slog.Error(
+			"could not create agreement composite key",
+			"id", id,
+			"error", err,
+		)
+		return false, err
+	}
+
+	agreementModel, err := r.ctx.GetStub().GetState(key)
+
+	if err != nil {
+		slog.Error(
+			"could not get agreement",
+			"id", id,
+			"error", err,
+		)
+		return false, err
+	}
+
+	exists := agreementModel != nil
+
+	return exists, nil
+}
+
+func (r *PublicLedgerRepository) GetAgreement(id string) (entities.Agreement, error) {
+	key, err := getAgreementCompositeKey(id)
+
+	if err != nil {
+		slog.Error(
+			"could not create agreement composite key",
+			"id", id,
+			"error", err,
+		)
+		return entities.Agreement{}, err
+	}
+
+	agreementModel, err := r.ctx.GetStub().GetState(key)
+
+	if err != nil {
+		slog.Error(
+			"could not get agreement",
+			"id", id,
+			"error", err,
+		)
+		return entities.Agreement{}, err
+	}
+
+	exists := agreementModel != nil
+
+	if !exists {
+		err = errors.New("agreement not found")
+		slog.Error(
+			err.Error(),
+			"id", id,
+		)
+		return entities.Agreement{}, err
+	}
+
+	return agreementModelToEntity(agreementModel)
+}
+
+func (r *PublicLedgerRepository) GetAgreements() ([]entities.Agreement, error) {
+	var result []entities.Agreement
+
+	iterator, err := r.ctx.GetStub().GetStateByPartialCompositeKey(agreementObjectType, []string{})
+	if err != nil {
+		slog.Error(
+			err.Error(),
+		)
+		return []entities.Agreement{}, err
+	}
+	defer iterator.Close()
+
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		agreement, err := agreementModelToEntity(queryResponse.Value)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, agreement)
+	}
+
+	return result, nil
+}
+
+func (r *PublicLedgerRepository) InsertAgreement(id string, agreement entities.Agreement) error {
+	exists, _ := r.AgreementExists(id)
+
+	if exists {
+		return errors.New("agreement already exists")
+	}
+
+	key, err := getAgreementCompositeKey(id)
+
+	if err != nil {
+		slog.Error(
+			"could not create agreement composite key",
+			"id", id,
+			"error", err,
+		)
+		return err
+	}
+
+	agreementModel, err := agreementEntityToModel(agreement)
+
+	if err != nil {
+		slog.Error(
+			"could not map agreement entity to model",
+			"id", id,
+			"error", err,
+		)
+		return err
+	}
+
+	err = r.ctx.GetStub().PutState(key, agreementModel)
+
+	if err != nil {
+		slog.Error(
+			"could not put model into ledger",
+			"id", id,
+			"error", err,
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *PublicLedgerRepository) UpdateAgreement(id string, agreement entities.Agreement) error {
+	exists, _ := r.AgreementExists(id)
+
+	if !exists {
+		return errors.New("agreement does not exist")
+	}
+
+	key, err := getAgreementCompositeKey(id)
+
+	if err != nil {
+		slog.Error(
+			"could not create agreement composite key",
+			"id", id,
+			"error", err,
+		)
+		return err
+	}
+
+	agreementModel, err := agreementEntityToModel(agreement)
+
+	if err != nil {
+		slog.Error(
+			"could not map agreement entity to model",
+			"id", id,
+			"error", err,
+		)
+		return err
+	}
+
+	err = r.ctx.GetStub().PutState(key, agreementModel)
+
+	if err != nil {
+		slog.Error(
+			"could not put model into ledger",
+			"id", id,
+			"error", err,
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *PublicLedgerRepository) GetAgreementUsage(id string) (entities.AgreementUsage, error) {
+	key, err := getAgreementUsageCompositeKey(id)
+
+	if err != nil {
+		slog.Error(
+			"could not create agreement usage composite key",
+			"id", id,
+			"error", err,
+		)
+		return entities.AgreementUsage{}, err
+	}
+
+	agreementUsageModel, err := r.ctx.GetStub().GetState(key)
+
+	if err != nil {
+		slog.Error(
+			"could not get agreement usage",
+			"id", id,
+			"error", err,
+		)
+		return entities.AgreementUsage{}, err
+	}
+
+	if agreementUsageModel == nil {
+		return entities.AgreementUsage{AgreementId: id}, nil
+	}
+
+	return agreementUsageModelToEntity(agreementUsageModel)
+}
+
+func (r *PublicLedgerRepository) PutAgreementUsage(usage entities.AgreementUsage) error {
+	key, err := getAgreementUsageCompositeKey(usage.AgreementId)
+
+	if err != nil {
+		slog.Error(
+			"could not create agreement usage composite key",
+			"id", usage.AgreementId,
+			"error", err,
+		)
+		return err
+	}
+
+	agreementUsageModel, err := agreementUsageEntityToModel(usage)
+
+	if err != nil {
+		slog.Error(
+			"could not map agreement usage entity to model",
+			"id", usage.AgreementId,
+			"error", err,
+		)
+		return err
+	}
+
+	err = r.ctx.GetStub().PutState(key, agreementUsageModel)
+
+	if err != nil {
+		slog.Error(
+			"could not put model into ledger",
+			"id", usage.AgreementId,
+			"error", err,
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *PublicLedgerRepository) ConfigExists() (bool, error) {
+	key, err := getConfigCompositeKey()
+
+	if err != nil {
+		slog.Error(
+			"could not create config composite key",
+			"error", err,
+		)
+		return false, err
+	}
+
+	configModel, err := r.ctx.GetStub().GetState(key)
+
+	if err != nil {
+		slog.Error(
+			"could not get config",
+			"error", err,
+		)
+		return false, err
+	}
+
+	exists := configModel != nil
+
+	return exists, nil
+}
+
+func (r *PublicLedgerRepository) GetConfig() (entities.ConsortiumConfig, error) {
+	key, err := getConfigCompositeKey()
+
+	if err != nil {
+		slog.Error(
+			"could not create config composite key",
+			"error", err,
+		)
+		return entities.ConsortiumConfig{}, err
+	}
+
+	configModel, err := r.ctx.GetStub().GetState(key)
+
+	if err != nil {
+		slog.Error(
+			"could not get config",
+			"error", err,
+		)
+		return entities.ConsortiumConfig{}, err
+	}
+
+	if configModel == nil {
+		err = errors.New("config not found")
+		slog.Error(
+			err.Error(),
+		)
+		return entities.ConsortiumConfig{}, err
+	}
+
+	return configModelToEntity(configModel)
+}
+
+func (r *PublicLedgerRepository) PutConfig(config entities.ConsortiumConfig) error {
+	key, err := getConfigCompositeKey()
+
+	if err != nil {
+		slog.Error(
+			"could not create config composite key",
+			"error", err,
+		)
+		return err
+	}
+
+	configModel, err := configEntityToModel(config)
+
+	if err != nil {
+		slog.Error(
+			"could not map config entity to model",
+			"error", err,
+		)
+		return err
+	}
+
+	err = r.ctx.GetStub().PutState(key, configModel)
+
+	if err != nil {
+		slog.Error(
+			"could not put model into state",
+			"error", err,
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *PublicLedgerRepository) GetQuotaCounter(piuId string, counterpart string, bucket time.Time) (entities.QuotaCounter, error) {
+	bucket = entities.GetQuotaBucket(bucket)
+
+	key, err := getQuotaCounterCompositeKey(piuId, counterpart, bucket)
+
+	if err != nil {
+		slog.Error(
+			"could not create quota counter composite key",
+			"piuId", piuId,
+			"counterpart", counterpart,
+			"error", err,
+		)
+		return entities.QuotaCounter{}, err
+	}
+
+	quotaCounterModel, err := r.ctx.GetStub().GetState(key)
+
+	if err != nil {
+		slog.Error(
+			"could not get quota counter",
+			"piuId", piuId,
+			"counterpart", counterpart,
+			"error", err,
+		)
+		return entities.QuotaCounter{}, err
+	}
+
+	if quotaCounterModel == nil {
+		return entities.QuotaCounter{PIU: piuId, Counterpart: counterpart, Bucket: bucket}, nil
+	}
+
+	return quotaCounterModelToEntity(quotaCounterModel)
+}
+
+func (r *PublicLedgerRepository) PutQuotaCounter(counter entities.QuotaCounter) error {
+	counter.Bucket = entities.GetQuotaBucket(counter.Bucket)
+
+	key, err := getQuotaCounterCompositeKey(counter.PIU, counter.Counterpart, counter.Bucket)
+
+	if err != nil {
+		slog.Error(
+			"could not create quota counter composite key",
+			"piuId", counter.PIU,
+			"counterpart", counter.Counterpart,
+			"error", err,
+		)
+		return err
+	}
+
+	quotaCounterModel, err := quotaCounterEntityToModel(counter)
+
+	if err != nil {
+		slog.Error(
+			"could not map quota counter entity to model",
+			"piuId", counter.PIU,
+			"counterpart", counter.Counterpart,
+			"error", err,
+		)
+		return err
+	}
+
+	err = r.ctx.GetStub().PutState(key, quotaCounterModel)
+
+	if err != nil {
+		slog.Error(
+			"could not put model into ledger",
+			"piuId", counter.PIU,
+			"counterpart", counter.Counterpart,
+			"error", err,
+		)
+		return err
+	}
+
+	return nil
+}
+
 func (r *PublicLedgerRepository) PNRExists(id string) (bool, error) {
 	key, err := getPNRCompositeKey(id)
 
@@ -336,6 +801,20 @@ func (r *PublicLedgerRepository) InsertPNR(id string, pnr entities.PNR) error {
 	return nil
 }
 
+func (r *PublicLedgerRepository) InsertLocalPNR(id string, pnr entities.PNR) error {
+	return r.InsertPNR(id, pnr)
+}
+
+func (r *PublicLedgerRepository) InsertForwardedPNR(pnr entities.PNR, gc entities.GCMetadata) error {
+	err := r.InsertPNR(pnr.Id, pnr)
+
+	if err != nil {
+		return err
+	}
+
+	return r.InsertGCMetadata(pnr, gc)
+}
+
 func (r *PublicLedgerRepository) UpdatePNR(id string, pnr entities.PNR) error {
 	exists, _ := r.PNRExists(id)
 
@@ -390,8 +869,7 @@ func (r *PublicLedgerRepository) PurgePNRData(id string) error {
 		return err
 	}
 
-	pnr.RequestData = ""
-	pnr.ResponseData = ""
+	pnr = entities.WithoutData(pnr)
 
 	r.UpdatePNR(id, pnr)
 
@@ -617,5 +1095,143 @@ func (r *PublicLedgerRepository) GetGCMetadatas() ([]entities.GCMetadata, error)
 	return result, nil
 }
 
+func (r *PublicLedgerRepository) InsertPNRClarification(pnr entities.PNR, msg entities.ClarificationMessage) error {
+	key, err := getClarificationCompositeKey(pnr.Id, msg.Sequence)
+
+	if err != nil {
+		slog.Error(
+			"could not create clarification composite key",
+			"id", pnr.Id,
+			"error", err,
+		)
+		return err
+	}
+
+	existing, err := r.ctx.GetStub().GetState(key)
+
+	if err != nil {
+		slog.Error(
+			"could not get clarification",
+			"id", pnr.Id,
+			"error", err,
+		)
+		return err
+	}
+
+	if existing != nil {
+		return errors.New("clarification already exists")
+	}
+
+	model, err := clarificationEntityToModel(msg)
+
+	if err != nil {
+		slog.Error(
+			"could not map clarification entity to model",
+			"id", pnr.Id,
+			"error", err,
+		)
+		return err
+	}
+
+	err = r.ctx.GetStub().PutState(key, model)
+
+	if err != nil {
+		slog.Error(
+			"could not put model into ledger",
+			"id", pnr.Id,
+			"error", err,
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *PublicLedgerRepository) GetPNRClarifications(id string) ([]entities.ClarificationMessage, error) {
+	var result []entities.ClarificationMessage
+
+	iterator, err := r.ctx.GetStub().GetStateByPartialCompositeKey(clarificationObjectType, []string{id})
+	if err != nil {
+		slog.Error(
+			err.Error(),
+		)
+		return []entities.ClarificationMessage{}, err
+	}
+	defer iterator.Close()
+
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		msg, err := clarificationModelToEntity(queryResponse.Value)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, msg)
+	}
+
+	return result, nil
+}
+
+func (r *PublicLedgerRepository) PurgePNRClarifications(id string) error {
+	msgs, err := r.GetPNRClarifications(id)
+
+	if err != nil {
+		return err
+	}
+
+	for _, msg := range msgs {
+		key, err := getClarificationCompositeKey(id, msg.Sequence)
+
+		if err != nil {
+			slog.Error(
+				"could not create clarification composite key",
+				"id", id,
+				"error", err,
+			)
+			return err
+		}
+
+		err = r.ctx.GetStub().DelState(key)
+
+		if err != nil {
+			slog.Error(
+				"could not delete clarification from ledger",
+				"id", id,
+				"error", err,
+			)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *PublicLedgerRepository) PurgeLocalPNRClarifications(id string) error {
+	return r.PurgePNRClarifications(id)
+}
+
+// BackupLocalRecords returns an empty archive, the repository keeps no
+// private data.
+func (r *PublicLedgerRepository) BackupLocalRecords() (entities.PrivateDataArchive, error) {
+	return entities.PrivateDataArchive{Records: []entities.ArchiveRecord{}}, nil
+}
+
+func (r *PublicLedgerRepository) RestoreLocalRecords(records []entities.ArchiveRecord) (entities.RestorePrivateDataOutput, error) {
+	return entities.RestorePrivateDataOutput{}, nil
+}
+
+func (r *PublicLedgerRepository) RestoreSharedRecords(piuId string) (entities.RestorePrivateDataOutput, error) {
+	return entities.RestorePrivateDataOutput{}, nil
+}
+
+// ComparePNRCopies returns no comparisons, the repository keeps a single
+// copy of every record.
+func (r *PublicLedgerRepository) ComparePNRCopies(pnr entities.PNR) ([]entities.KeyConsistency, error) {
+	return []entities.KeyConsistency{}, nil
+}
+
 func (r *PublicLedgerRepository) Close() {
 }
@@ -1,15 +1,20 @@
 package publicledger_test
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/google/uuid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"github.com/nesfit/shimtest/pkg/shimtest"
 
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
 	"github.com/nesfit/tenacity-chaincode/pkg/repository"
 	"github.com/nesfit/tenacity-chaincode/pkg/repository/publicledger"
+	"github.com/nesfit/tenacity-chaincode/pkg/testdata"
 )
 
 func newMockTransactionContext() *shimtest.MockTransactionContext {
@@ -47,3 +52,53 @@ func TestRepositorySuite(t *testing.T) {
 	s := repository.NewRepositoryTestSuite(publicLedgerRepositoryFactory{})
 	suite.Run(t, s)
 }
+
+func TestMigrateData(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := newMockTransactionContext()
+	stub := ctx.GetStub().(*shimtest.MockStub)
+	txm := newTransactionManager(ctx)
+	r := publicledger.NewPublicLedgerRepository(ctx)
+
+	pnr := testdata.PNRs[0]
+	gc := entities.GCMetadata{Id: pnr.Id, CreationTimestamp: testdata.MiddleTimestamp}
+	pnrKey, _ := shim.CreateCompositeKey("pnr", []string{pnr.Id})
+	gcKey, _ := shim.CreateCompositeKey("gc", []string{gc.Id})
+
+	pnrModel, _ := json.Marshal(pnr)
+	gcModel, _ := json.Marshal(gc)
+
+	txm.Start()
+	stub.PutState(pnrKey, pnrModel)
+	stub.PutState(gcKey, gcModel)
+	txm.End()
+
+	txm.Start()
+	output, err := r.MigrateData("", 1)
+	txm.End()
+	assert.NoError(err)
+	assert.Equal(1, output.Migrated)
+	assert.NotEmpty(output.Bookmark)
+
+	txm.Start()
+	output, err = r.MigrateData(output.Bookmark, 1)
+	txm.End()
+	assert.NoError(err)
+	assert.Equal(1, output.Migrated)
+	assert.Empty(output.Bookmark)
+
+	for _, key := range []string{pnrKey, gcKey} {
+		var envelope map[string]any
+		json.Unmarshal(stub.State[key], &envelope)
+		assert.Equal(float64(1), envelope["v"])
+	}
+
+	actual, err := r.GetPNR(pnr.Id)
+	assert.NoError(err)
+	assert.Equal(pnr, actual)
+
+	actualGC, err := r.GetGCMetadata(gc.Id)
+	assert.NoError(err)
+	assert.Equal(gc, actualGC)
+}
@@ -0,0 +1,69 @@
+// Package storedmodel versions models stored by the ledger repositories.
+package storedmodel
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Version is the version of stored models written by the repositories.
+// Models stored before they were versioned are bare JSON of version 0, which
+// has the same layout as version 1.
+const Version = 1
+
+// modelEnvelope wraps every stored model with its version, so that decoders
+// can tell apart layouts of records written by older chaincode.
+type modelEnvelope struct {
+	Version int             `json:"v"`
+	Model   json.RawMessage `json:"model"`
+}
+
+// Encode wraps the entity in an envelope of the current version.
+func Encode(entity any) ([]byte, error) {
+	model, err := json.Marshal(entity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(modelEnvelope{Version: Version, Model: model})
+}
+
+func unwrapModel(data []byte) (int, json.RawMessage, error) {
+	var envelope modelEnvelope
+
+	err := json.Unmarshal(data, &envelope)
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if envelope.Model == nil {
+		return 0, data, nil
+	}
+
+	if envelope.Version < 1 || envelope.Version > Version {
+		return 0, nil, fmt.Errorf("unsupported model version %d", envelope.Version)
+	}
+
+	return envelope.Version, envelope.Model, nil
+}
+
+// StoredVersion returns the version the model was stored with.
+func StoredVersion(data []byte) (int, error) {
+	version, _, err := unwrapModel(data)
+
+	return version, err
+}
+
+// Decode decodes the stored model into the entity and returns the version
+// it was stored with.
+func Decode(data []byte, entity any) (int, error) {
+	version, model, err := unwrapModel(data)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return version, json.Unmarshal(model, entity)
+}
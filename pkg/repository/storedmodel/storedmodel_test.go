@@ -0,0 +1,43 @@
+package storedmodel_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/repository/storedmodel"
+)
+
+type testEntity struct {
+	Name string `json:"name"`
+}
+
+func TestEncodeDecode(t *testing.T) {
+	assert := assert.New(t)
+
+	data, err := storedmodel.Encode(testEntity{Name: "foo"})
+	assert.NoError(err)
+
+	var entity testEntity
+
+	version, err := storedmodel.Decode(data, &entity)
+	assert.NoError(err)
+	assert.Equal(storedmodel.Version, version)
+	assert.Equal(testEntity{Name: "foo"}, entity)
+}
+
+func TestDecodeUnversioned(t *testing.T) {
+	assert := assert.New(t)
+
+	var entity testEntity
+
+	version, err := storedmodel.Decode([]byte(`{"name": "foo"}`), &entity)
+	assert.NoError(err)
+	assert.Equal(0, version)
+	assert.Equal(testEntity{Name: "foo"}, entity)
+}
+
+func TestStoredVersionUnsupported(t *testing.T) {
+	_, err := storedmodel.StoredVersion([]byte(`{"v": 99, "model": {}}`))
+	assert.Error(t, err)
+}
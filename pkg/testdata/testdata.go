@@ -12,16 +12,19 @@ var PIUs = []entities.PIU{
 		Id:         "piu1",
 		Name:       "PIU 1",
 		AdminEmail: "admin@piu1.org",
+		Status:     entities.PIUStatusActive,
 	},
 	{
 		Id:         "piu2",
 		Name:       "PIU 2",
 		AdminEmail: "admin@piu2.org",
+		Status:     entities.PIUStatusActive,
 	},
 	{
 		Id:         "piu3",
 		Name:       "PIU 3",
 		AdminEmail: "admin@piu3.org",
+		Status:     entities.PIUStatusActive,
 	},
 }
 
@@ -49,6 +52,8 @@ var PNRs = []entities.PNR{
 		RequestData:       "\"requestData\"",
 		ResponseData:      "\"responseData\"",
 		PNRHashes:         []string{},
+		Priority:          entities.RequestPriorityUrgent,
+		Deadline:          MiddleTimestamp.Add(24 * time.Hour),
 	},
 	{
 		Id:                "pnr3",
@@ -71,5 +76,51 @@ var PNRs = []entities.PNR{
 		RequestData:       "\"requestData\"",
 		ResponseData:      "\"responseData\"",
 		PNRHashes:         []string{},
+		Priority:          entities.RequestPriorityImmediate,
+		Deadline:          LatestTimestamp.Add(4 * time.Hour),
+	},
+	{
+		Id:               "pnr5",
+		RequestingPIU:    "piu1",
+		RespondingPIU:    "piu2",
+		RequestTimestamp: LatestTimestamp,
+		State:            entities.RequestStatePending,
+		RequestData:      "\"requestData\"",
+		PNRHashes:        []string{},
+		ParentId:         "pnr1",
+	},
+}
+
+var Agreements = []entities.Agreement{
+	{
+		Id:                "agreement12",
+		ProposingPIU:      "piu1",
+		CounterpartyPIU:   "piu2",
+		Purposes:          []string{"terrorism", "seriousCrime"},
+		ValidFrom:         EarliestTimestamp.Add(-24 * time.Hour),
+		State:             entities.AgreementStateActive,
+		SignedBy:          []string{"piu1", "piu2"},
+		ProposalTimestamp: EarliestTimestamp.Add(-48 * time.Hour),
+	},
+	{
+		Id:                "agreement13",
+		ProposingPIU:      "piu3",
+		CounterpartyPIU:   "piu1",
+		Purposes:          []string{"terrorism"},
+		ValidFrom:         EarliestTimestamp.Add(-24 * time.Hour),
+		ValidTo:           LatestTimestamp.Add(24 * time.Hour),
+		State:             entities.AgreementStateActive,
+		SignedBy:          []string{"piu3", "piu1"},
+		ProposalTimestamp: EarliestTimestamp.Add(-48 * time.Hour),
+	},
+	{
+		Id:                "agreement23",
+		ProposingPIU:      "piu2",
+		CounterpartyPIU:   "piu3",
+		Purposes:          []string{"terrorism"},
+		ValidFrom:         EarliestTimestamp.Add(-24 * time.Hour),
+		State:             entities.AgreementStateActive,
+		SignedBy:          []string{"piu2", "piu3"},
+		ProposalTimestamp: EarliestTimestamp.Add(-48 * time.Hour),
 	},
 }
@@ -0,0 +1,344 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/swaggest/usecase/status"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+)
+
+func (u RMTUsecase) ProposeAgreement(ctx context.Context, input entities.ProposeAgreementInput, output *entities.ProposeAgreementOutput) error {
+	slog.Debug(
+		"ProposeAgreement called",
+		"input", input,
+	)
+
+	if input.Id == "" {
+		err := errors.New("Agreement id must not be empty")
+		slog.Error(
+			err.Error(),
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	if u.isThisPIU(input.CounterpartyPIU) {
+		err := errors.New("Cannot make agreement with itself")
+		slog.Error(
+			err.Error(),
+			"clientId", u.piuId,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	err := u.checkActivePIU(u.piuId)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = u.rep.GetPIU(input.CounterpartyPIU)
+
+	if err != nil {
+		slog.Error(
+			"Could not get information about counterparty PIU",
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	if len(input.Purposes) == 0 || slices.Contains(input.Purposes, "") {
+		err := errors.New("Agreement must allow at least one non-empty purpose")
+		slog.Error(
+			err.Error(),
+			"purposes", input.Purposes,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	if (input.ValidTo != time.Time{}) && !input.ValidTo.After(input.ValidFrom) {
+		err := errors.New("Agreement validity must end after it starts")
+		slog.Error(
+			err.Error(),
+			"validFrom", input.ValidFrom,
+			"validTo", input.ValidTo,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	if input.MaxRequests < 0 {
+		err := errors.New("Maximum number of requests must not be negative")
+		slog.Error(
+			err.Error(),
+			"maxRequests", input.MaxRequests,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	agreement := entities.Agreement{
+		Id:                input.Id,
+		ProposingPIU:      u.piuId,
+		CounterpartyPIU:   input.CounterpartyPIU,
+		Purposes:          input.Purposes,
+		ValidFrom:         input.ValidFrom,
+		ValidTo:           input.ValidTo,
+		MaxRequests:       input.MaxRequests,
+		State:             entities.AgreementStateProposed,
+		SignedBy:          []string{u.piuId},
+		ProposalTimestamp: input.ProposalTimestamp,
+	}
+
+	err = u.rep.InsertAgreement(agreement.Id, agreement)
+
+	if err != nil {
+		slog.Error(
+			"Could not insert agreement",
+			"id", agreement.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	*output = entities.ProposeAgreementOutput{Id: agreement.Id}
+
+	slog.Debug(
+		"ProposeAgreement finished",
+		"output", output,
+	)
+
+	return nil
+}
+
+func (u RMTUsecase) getAgreementAsParty(id string) (entities.Agreement, error) {
+	agreement, err := u.rep.GetAgreement(id)
+
+	if err != nil {
+		slog.Error(
+			"Could not get agreement",
+			"id", id,
+			"error", err,
+		)
+		return entities.Agreement{}, status.Wrap(err, status.InvalidArgument)
+	}
+
+	if !entities.IsAgreementParty(agreement, u.piuId) {
+		err := errors.New("Not a party of the agreement")
+		slog.Error(
+			err.Error(),
+			"clientId", u.piuId,
+			"id", id,
+		)
+		return entities.Agreement{}, status.Wrap(err, status.PermissionDenied)
+	}
+
+	return agreement, nil
+}
+
+// SignAgreement is called by the counterparty PIU to accept a proposed
+// agreement, which becomes active once signed by both parties.
+func (u RMTUsecase) SignAgreement(ctx context.Context, input entities.SignAgreementInput, output *entities.SignAgreementOutput) error {
+	slog.Debug(
+		"SignAgreement called",
+		"input", input,
+	)
+
+	agreement, err := u.getAgreementAsParty(input.Id)
+
+	if err != nil {
+		return err
+	}
+
+	if agreement.State != entities.AgreementStateProposed || slices.Contains(agreement.SignedBy, u.piuId) {
+		err := errors.New("Agreement cannot be signed")
+		slog.Error(
+			err.Error(),
+			"id", input.Id,
+			"state", agreement.State,
+			"signedBy", agreement.SignedBy,
+		)
+		return status.Wrap(err, status.FailedPrecondition)
+	}
+
+	agreement.SignedBy = append(agreement.SignedBy, u.piuId)
+
+	if slices.Contains(agreement.SignedBy, agreement.ProposingPIU) && slices.Contains(agreement.SignedBy, agreement.CounterpartyPIU) {
+		agreement.State = entities.AgreementStateActive
+	}
+
+	err = u.rep.UpdateAgreement(agreement.Id, agreement)
+
+	if err != nil {
+		slog.Error(
+			"Could not update agreement",
+			"id", agreement.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	*output = entities.SignAgreementOutput{}
+
+	slog.Debug(
+		"SignAgreement finished",
+		"output", output,
+	)
+
+	return nil
+}
+
+func (u RMTUsecase) RevokeAgreement(ctx context.Context, input entities.RevokeAgreementInput, output *entities.RevokeAgreementOutput) error {
+	slog.Debug(
+		"RevokeAgreement called",
+		"input", input,
+	)
+
+	agreement, err := u.getAgreementAsParty(input.Id)
+
+	if err != nil {
+		return err
+	}
+
+	if agreement.State == entities.AgreementStateRevoked {
+		err := errors.New("Agreement is already revoked")
+		slog.Error(
+			err.Error(),
+			"id", input.Id,
+		)
+		return status.Wrap(err, status.FailedPrecondition)
+	}
+
+	agreement.State = entities.AgreementStateRevoked
+
+	err = u.rep.UpdateAgreement(agreement.Id, agreement)
+
+	if err != nil {
+		slog.Error(
+			"Could not update agreement",
+			"id", agreement.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	*output = entities.RevokeAgreementOutput{}
+
+	slog.Debug(
+		"RevokeAgreement finished",
+		"output", output,
+	)
+
+	return nil
+}
+
+func (u RMTUsecase) GetAgreements(ctx context.Context, input entities.GetAgreementsInput, output *[]entities.Agreement) error {
+	slog.Debug(
+		"GetAgreements called",
+		"input", input,
+	)
+
+	out, err := u.rep.GetAgreements()
+
+	if err != nil {
+		slog.Error(
+			"Failed to get agreements from the repository",
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	out = slices.DeleteFunc(out, func(agreement entities.Agreement) bool {
+		return !entities.IsAgreementParty(agreement, u.piuId)
+	})
+
+	*output = out
+
+	slog.Debug(
+		"GetAgreements finished",
+		"output", output,
+	)
+
+	return nil
+}
+
+// findAgreement returns an agreement covering a PNR request sent now that
+// has not reached its maximum request volume yet.
+func (u RMTUsecase) findAgreement(requestingPIU string, respondingPIU string, purpose string, now time.Time) (entities.Agreement, error) {
+	agreements, err := u.rep.GetAgreements()
+
+	if err != nil {
+		slog.Error(
+			"Failed to get agreements from the repository",
+			"error", err,
+		)
+		return entities.Agreement{}, status.Wrap(err, status.Internal)
+	}
+
+	slices.SortFunc(agreements, func(a entities.Agreement, b entities.Agreement) int {
+		return strings.Compare(a.Id, b.Id)
+	})
+
+	for _, agreement := range agreements {
+		if !entities.IsCoveringAgreement(agreement, requestingPIU, respondingPIU, purpose, now) {
+			continue
+		}
+
+		if agreement.MaxRequests == 0 {
+			return agreement, nil
+		}
+
+		usage, err := u.rep.GetAgreementUsage(agreement.Id)
+
+		if err != nil {
+			slog.Error(
+				"Failed to get agreement usage from the repository",
+				"id", agreement.Id,
+				"error", err,
+			)
+			return entities.Agreement{}, status.Wrap(err, status.Internal)
+		}
+
+		if usage.Count < agreement.MaxRequests {
+			return agreement, nil
+		}
+	}
+
+	err = errors.New("PNR request is not covered by an active agreement")
+	slog.Error(
+		err.Error(),
+		"requestingPIU", requestingPIU,
+		"respondingPIU", respondingPIU,
+		"purpose", purpose,
+	)
+	return entities.Agreement{}, status.Wrap(err, status.PermissionDenied)
+}
+
+// consumeAgreement counts a PNR request sent under the agreement. Requests
+// are counted only by agreements with limited volume.
+func (u RMTUsecase) consumeAgreement(agreement entities.Agreement) error {
+	if agreement.MaxRequests == 0 {
+		return nil
+	}
+
+	usage, err := u.rep.GetAgreementUsage(agreement.Id)
+
+	if err == nil {
+		usage.Count++
+		err = u.rep.PutAgreementUsage(usage)
+	}
+
+	if err != nil {
+		slog.Error(
+			"Could not update agreement usage",
+			"id", agreement.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	return nil
+}
@@ -0,0 +1,312 @@
+package usecase
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+
+	"github.com/swaggest/usecase/status"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+)
+
+// isApprovalRequired reports whether the calling PIU requires a second
+// officer to approve the given kind of outgoing PNR messages.
+func (u RMTUsecase) isApprovalRequired(required func(entities.PIU) bool) (bool, error) {
+	piu, err := u.rep.GetPIU(u.piuId)
+
+	if err != nil {
+		slog.Error(
+			"Could not get information about PIU",
+			"id", u.piuId,
+			"error", err,
+		)
+		return false, status.Wrap(err, status.InvalidArgument)
+	}
+
+	return required(piu), nil
+}
+
+// draftPNR stores the PNR request in the collection of the calling PIU only,
+// where it awaits approval of another officer.
+func (u RMTUsecase) draftPNR(state entities.RequestState, pnr entities.PNR, store func(string, entities.PNR) error) error {
+	if u.clientId == "" {
+		err := errors.New("Client id is required to draft PNR request or response")
+		slog.Error(
+			err.Error(),
+			"id", pnr.Id,
+		)
+		return status.Wrap(err, status.PermissionDenied)
+	}
+
+	pnr.State = state
+	pnr.DraftedBy = u.clientId
+
+	err := store(pnr.Id, pnr)
+
+	if err != nil {
+		slog.Error(
+			"Could not store PNR draft",
+			"id", pnr.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	return nil
+}
+
+// getPNRDraft returns the PNR draft in given state, provided the caller is
+// allowed to approve it.
+func (u RMTUsecase) getPNRDraft(id string, state entities.RequestState) (entities.PNR, error) {
+	pnr, err := u.rep.GetPNR(id)
+
+	if err != nil {
+		slog.Error(
+			"Could not get PNR request",
+			"id", id,
+			"error", err,
+		)
+		return entities.PNR{}, status.Wrap(err, status.InvalidArgument)
+	}
+
+	owner := pnr.RequestingPIU
+	if state == entities.RequestStateAckDraft {
+		owner = pnr.RespondingPIU
+	}
+
+	if !u.isThisPIU(owner) || pnr.State != state {
+		err := errors.New("PNR request has no draft to approve")
+		slog.Error(
+			err.Error(),
+			"id", id,
+			"clientId", u.piuId,
+			"state", pnr.State,
+		)
+		return entities.PNR{}, status.Wrap(err, status.FailedPrecondition)
+	}
+
+	if u.clientId == "" || u.clientId == pnr.DraftedBy {
+		err := errors.New("Draft must be approved by another officer")
+		slog.Error(
+			err.Error(),
+			"id", id,
+		)
+		return entities.PNR{}, status.Wrap(err, status.PermissionDenied)
+	}
+
+	pnr.DraftedBy = ""
+
+	return pnr, nil
+}
+
+// checkReviewedDraft verifies that the response draft has not changed since
+// it was reviewed by the approving officer.
+func checkReviewedDraft(pnr entities.PNR, reviewed string) error {
+	if reviewed == "" {
+		err := errors.New("Digest of the reviewed response is required")
+		slog.Error(
+			err.Error(),
+			"id", pnr.Id,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	digest, err := responseDigest(pnr.ResponseData)
+
+	if err != nil {
+		slog.Error(
+			"Failed to transform PNR response to canonical form",
+			"id", pnr.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	if hex.EncodeToString(digest) != reviewed {
+		err := errors.New("Response draft has changed since it was reviewed")
+		slog.Error(
+			err.Error(),
+			"id", pnr.Id,
+			"reviewed", reviewed,
+		)
+		return status.Wrap(err, status.FailedPrecondition)
+	}
+
+	return nil
+}
+
+// ApprovePNRRequest sends a drafted PNR request to the responding PIU.
+func (u RMTUsecase) ApprovePNRRequest(ctx context.Context, input entities.ApprovePNRDraftInput, output *entities.ApprovePNRDraftOutput) error {
+	slog.Debug(
+		"ApprovePNRRequest called",
+		"input", input,
+	)
+
+	pnr, err := u.getPNRDraft(input.Id, entities.RequestStateDraft)
+
+	if err != nil {
+		return err
+	}
+
+	err = u.checkActivePIU(pnr.RespondingPIU)
+
+	if err != nil {
+		return err
+	}
+
+	now, err := u.now()
+
+	if err != nil {
+		return err
+	}
+
+	agreement, err := u.findAgreement(u.piuId, pnr.RespondingPIU, pnr.Purpose, now)
+
+	if err != nil {
+		return err
+	}
+
+	err = u.checkQuota(u.piuId, pnr.RespondingPIU, now)
+
+	if err != nil {
+		return err
+	}
+
+	pnr.State = entities.RequestStatePending
+	pnr.AgreementId = agreement.Id
+
+	err = u.rep.UpdatePNR(pnr.Id, pnr)
+
+	if err != nil {
+		slog.Error(
+			"Could not update PNR request",
+			"id", pnr.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	gc := entities.GCMetadata{
+		Id:                pnr.Id,
+		CreationTimestamp: pnr.RequestTimestamp,
+		PurgeDeadline:     u.config.GetPurgeDeadline(pnr.RequestTimestamp),
+	}
+
+	err = u.rep.InsertGCMetadata(pnr, gc)
+
+	if err != nil {
+		slog.Error(
+			"Could not insert new PNR GC metadata",
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	err = u.consumeQuota(u.piuId, pnr.RespondingPIU, now)
+
+	if err != nil {
+		return err
+	}
+
+	err = u.consumeAgreement(agreement)
+
+	if err != nil {
+		return err
+	}
+
+	*output = entities.ApprovePNRDraftOutput{}
+
+	slog.Debug(
+		"ApprovePNRRequest finished",
+		"output", output,
+	)
+
+	return nil
+}
+
+// ApprovePNRResponse releases a drafted response to the requesting PIU once
+// the approving officer confirms the digest of the response they reviewed.
+func (u RMTUsecase) ApprovePNRResponse(ctx context.Context, input entities.ApprovePNRDraftInput, output *entities.ApprovePNRDraftOutput) error {
+	slog.Debug(
+		"ApprovePNRResponse called",
+		"input", input,
+	)
+
+	pnr, err := u.getPNRDraft(input.Id, entities.RequestStateAckDraft)
+
+	if err != nil {
+		return err
+	}
+
+	err = checkReviewedDraft(pnr, input.ResponseDigest)
+
+	if err != nil {
+		return err
+	}
+
+	gc, err := u.rep.GetGCMetadata(input.Id)
+
+	if err != nil {
+		slog.Error(
+			"Could not get PNR GC metadata",
+			"id", input.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	// Response data encrypted at rest are hashed in plaintext, while the
+	// sealed data are kept.
+	plaintext := pnr
+
+	plaintext.ResponseData, err = openPNRData(pnr, input.DataKey, responseDataField, pnr.ResponseData)
+
+	if err != nil {
+		return err
+	}
+
+	err = hashPNRResponse(u.config.Hashing, &plaintext, &gc)
+
+	if err != nil {
+		return err
+	}
+
+	gc.PurgeDeadline = u.config.GetPurgeDeadline(gc.CreationTimestamp)
+
+	pnr.PNRHashes = plaintext.PNRHashes
+
+	pnr.State = entities.RequestStateAck
+
+	err = u.rep.UpdatePNR(input.Id, pnr)
+
+	if err != nil {
+		slog.Error(
+			"Could not update PNR request",
+			"id", input.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	err = u.rep.UpdateGCMetadata(pnr, gc)
+
+	if err != nil {
+		slog.Error(
+			"Could not update PNR GC metadata",
+			"id", input.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	*output = entities.ApprovePNRDraftOutput{}
+
+	slog.Debug(
+		"ApprovePNRResponse finished",
+		"output", output,
+	)
+
+	return nil
+}
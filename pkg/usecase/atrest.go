@@ -0,0 +1,300 @@
+package usecase
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+
+	"github.com/swaggest/usecase/status"
+	"github.com/tidwall/gjson"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+)
+
+const dataKeySize = 32
+
+const requestDataField = "requestData"
+const responseDataField = "responseData"
+
+var nonceKeyLabel = []byte(entities.SealedDataFormat + " nonce")
+
+// dataKeyId identifies a data key by its hash, the key is random, so the hash
+// does not reveal it.
+func dataKeyId(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:])
+}
+
+func isSealedData(data string) bool {
+	return gjson.Valid(data) && gjson.Get(data, "format").String() == entities.SealedDataFormat
+}
+
+func newDataCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// sealData encrypts the data of the field with the data key. The nonce is a
+// MAC of the field and the data under a key derived from the data key, so
+// that every endorser produces the same ciphertext.
+func sealData(key []byte, field string, data string) (string, error) {
+	if data == "" {
+		return "", nil
+	}
+
+	aead, err := newDataCipher(key)
+
+	if err != nil {
+		return "", err
+	}
+
+	nonceKey := hmac.New(sha256.New, key)
+	nonceKey.Write(nonceKeyLabel)
+
+	mac := hmac.New(sha256.New, nonceKey.Sum(nil))
+	mac.Write([]byte(field))
+	mac.Write([]byte{0})
+	mac.Write([]byte(data))
+
+	nonce := mac.Sum(nil)[:aead.NonceSize()]
+
+	sealed, err := json.Marshal(entities.SealedData{
+		Format:     entities.SealedDataFormat,
+		DataKeyId:  dataKeyId(key),
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, []byte(data), []byte(field)),
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(sealed), nil
+}
+
+// openData decrypts data of the field sealed with the data key, data which
+// are not sealed are returned as they are.
+func openData(key []byte, field string, data string) (string, error) {
+	if !isSealedData(data) {
+		return data, nil
+	}
+
+	var sealed entities.SealedData
+
+	err := json.Unmarshal([]byte(data), &sealed)
+
+	if err != nil {
+		return "", err
+	}
+
+	if sealed.DataKeyId != dataKeyId(key) {
+		return "", errors.New("Data are sealed with another data key")
+	}
+
+	aead, err := newDataCipher(key)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed.Nonce) != aead.NonceSize() {
+		return "", errors.New("Invalid nonce size")
+	}
+
+	plaintext, err := aead.Open(nil, sealed.Nonce, sealed.Ciphertext, []byte(field))
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// checkDataKey verifies the data key supplied for the PNR request, which has
+// to be the key its data are sealed with, if any.
+func checkDataKey(pnr entities.PNR, dataKey *entities.DataKey) error {
+	if dataKey == nil {
+		err := errors.New("Data key is required to encrypt PNR data at rest")
+		slog.Error(
+			err.Error(),
+			"id", pnr.Id,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	if len(dataKey.Key) != dataKeySize {
+		err := errors.New("Data key must be an AES-256 key")
+		slog.Error(
+			err.Error(),
+			"id", pnr.Id,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	if pnr.DataKeyId != "" && dataKeyId(dataKey.Key) != pnr.DataKeyId {
+		err := errors.New("Data key does not match the data key of the PNR request")
+		slog.Error(
+			err.Error(),
+			"id", pnr.Id,
+			"dataKeyId", pnr.DataKeyId,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	return nil
+}
+
+// checkWrappedDataKeys verifies that the data key is wrapped to the current
+// encryption key of every party of the PNR request. The chaincode cannot
+// unwrap the keys, so only the format of the wrapped keys is checked.
+func (u RMTUsecase) checkWrappedDataKeys(pnr entities.PNR, dataKey *entities.DataKey) error {
+	parties := []string{pnr.RequestingPIU, pnr.RespondingPIU}
+
+	for piuId := range dataKey.WrappedKeys {
+		if !slices.Contains(parties, piuId) {
+			err := errors.New("Data key is wrapped for a PIU which is not a party of the PNR request")
+			slog.Error(
+				err.Error(),
+				"id", pnr.Id,
+				"piu", piuId,
+			)
+			return status.Wrap(err, status.InvalidArgument)
+		}
+	}
+
+	for _, piuId := range parties {
+		wrapped, ok := dataKey.WrappedKeys[piuId]
+
+		if !ok {
+			err := errors.New("Data key must be wrapped for every party of the PNR request")
+			slog.Error(
+				err.Error(),
+				"id", pnr.Id,
+				"piu", piuId,
+			)
+			return status.Wrap(err, status.InvalidArgument)
+		}
+
+		_, err := u.checkPayloadRecipient(wrapped, piuId)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sealPNRRequest encrypts data of a new PNR request at rest with the data
+// key, which is required when the consortium enables at-rest encryption.
+func (u RMTUsecase) sealPNRRequest(pnr *entities.PNR, dataKey *entities.DataKey) error {
+	if dataKey == nil && !u.config.AtRestEncryption {
+		return nil
+	}
+
+	err := checkDataKey(*pnr, dataKey)
+
+	if err != nil {
+		return err
+	}
+
+	err = u.checkWrappedDataKeys(*pnr, dataKey)
+
+	if err != nil {
+		return err
+	}
+
+	requestData, err := sealData(dataKey.Key, requestDataField, pnr.RequestData)
+
+	if err != nil {
+		slog.Error(
+			"Could not encrypt PNR request data",
+			"id", pnr.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	pnr.RequestData = requestData
+	pnr.DataKeyId = dataKeyId(dataKey.Key)
+	pnr.DataKeys = dataKey.WrappedKeys
+
+	return nil
+}
+
+// sealPNRResponse encrypts response data at rest with the data key of the
+// PNR request, responses to requests which are not encrypted at rest stay in
+// plaintext.
+func (u RMTUsecase) sealPNRResponse(pnr *entities.PNR, dataKey *entities.DataKey) error {
+	if pnr.DataKeyId == "" {
+		if dataKey != nil {
+			err := errors.New("PNR request is not encrypted at rest")
+			slog.Error(
+				err.Error(),
+				"id", pnr.Id,
+			)
+			return status.Wrap(err, status.InvalidArgument)
+		}
+
+		return nil
+	}
+
+	err := checkDataKey(*pnr, dataKey)
+
+	if err != nil {
+		return err
+	}
+
+	responseData, err := sealData(dataKey.Key, responseDataField, pnr.ResponseData)
+
+	if err != nil {
+		slog.Error(
+			"Could not encrypt PNR response data",
+			"id", pnr.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	pnr.ResponseData = responseData
+
+	return nil
+}
+
+// openPNRData decrypts data of the field of the PNR request encrypted at rest.
+func openPNRData(pnr entities.PNR, dataKey *entities.DataKey, field string, data string) (string, error) {
+	if pnr.DataKeyId == "" {
+		return data, nil
+	}
+
+	err := checkDataKey(pnr, dataKey)
+
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := openData(dataKey.Key, field, data)
+
+	if err != nil {
+		slog.Error(
+			"Could not decrypt PNR data",
+			"id", pnr.Id,
+			"field", field,
+			"error", err,
+		)
+		return "", status.Wrap(err, status.InvalidArgument)
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,260 @@
+package usecase
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	"github.com/swaggest/usecase/status"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+)
+
+// verifySignature checks the signature of the SHA-256 digest, RSA keys sign
+// with PKCS #1 v1.5, ECDSA keys with ASN.1 encoded signatures and Ed25519 keys
+// sign the digest itself.
+func verifySignature(key crypto.PublicKey, digest []byte, signature []byte) error {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, signature)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest, signature) {
+			return errors.New("Invalid ECDSA signature")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, digest, signature) {
+			return errors.New("Invalid Ed25519 signature")
+		}
+	default:
+		return errors.New("Unsupported signing key type")
+	}
+
+	return nil
+}
+
+// BackupPrivateData archives PNR records kept in the private data collection
+// of the PIU. The archive has to be signed by the PIU before it can be
+// restored.
+func (u RMTUsecase) BackupPrivateData(ctx context.Context, input entities.BackupPrivateDataInput, output *entities.PrivateDataArchive) error {
+	slog.Debug(
+		"BackupPrivateData called",
+		"input", input,
+	)
+
+	archive, err := u.rep.BackupLocalRecords()
+
+	if err != nil {
+		slog.Error(
+			"Could not get records to archive",
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	digest, err := archive.Digest()
+
+	if err != nil {
+		slog.Error(
+			"Could not compute digest of archive",
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	archive.Timestamp, err = u.now()
+
+	if err != nil {
+		return err
+	}
+
+	archive.PIU = u.piuId
+	archive.SHA256 = hex.EncodeToString(digest)
+
+	*output = archive
+
+	slog.Debug(
+		"BackupPrivateData finished",
+		"collection", output.Collection,
+		"records", len(output.Records),
+	)
+
+	return nil
+}
+
+// RestorePrivateData writes records of an archive signed by the PIU back into
+// its private data collection. Only records missing from the collection and
+// matching their hashes on the ledger are restored.
+func (u RMTUsecase) RestorePrivateData(ctx context.Context, input entities.RestorePrivateDataInput, output *entities.RestorePrivateDataOutput) error {
+	var archive entities.PrivateDataArchive
+
+	err := json.Unmarshal([]byte(entities.OptionalMessage(input.Archive)), &archive)
+
+	if err != nil {
+		slog.Error(
+			"Could not parse archive",
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	slog.Debug(
+		"RestorePrivateData called",
+		"piu", archive.PIU,
+		"collection", archive.Collection,
+		"records", len(archive.Records),
+	)
+
+	if !u.isThisPIU(archive.PIU) {
+		err := errors.New("Archive belongs to another PIU")
+		slog.Error(
+			err.Error(),
+			"clientId", u.piuId,
+			"piu", archive.PIU,
+		)
+		return status.Wrap(err, status.PermissionDenied)
+	}
+
+	piu, err := u.rep.GetPIU(u.piuId)
+
+	if err != nil {
+		slog.Error(
+			"Could not get information about PIU",
+			"id", u.piuId,
+			"error", err,
+		)
+		return status.Wrap(err, status.NotFound)
+	}
+
+	if piu.SigningKey == "" {
+		err := errors.New("PIU has no signing key to verify the archive")
+		slog.Error(
+			err.Error(),
+			"id", u.piuId,
+		)
+		return status.Wrap(err, status.FailedPrecondition)
+	}
+
+	key, err := parsePublicKeyPEM(piu.SigningKey)
+
+	if err != nil {
+		slog.Error(
+			"Could not parse signing key of PIU",
+			"id", u.piuId,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	digest, err := archive.Digest()
+
+	if err != nil {
+		slog.Error(
+			"Could not compute digest of archive",
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	if hex.EncodeToString(digest) != archive.SHA256 {
+		err := errors.New("Archive does not match its digest")
+		slog.Error(
+			err.Error(),
+			"sha256", archive.SHA256,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	err = verifySignature(key, digest, archive.Signature)
+
+	if err != nil {
+		slog.Error(
+			"Invalid signature of archive",
+			"error", err,
+		)
+		return status.Wrap(err, status.PermissionDenied)
+	}
+
+	result, err := u.rep.RestoreLocalRecords(archive.Records)
+
+	if err != nil {
+		slog.Error(
+			"Could not restore archived records",
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	*output = result
+
+	slog.Debug(
+		"RestorePrivateData finished",
+		"output", output,
+	)
+
+	return nil
+}
+
+// ReconcilePrivateData writes copies of PNR records shared with another PIU
+// back into its private data collection, so that it can recover records its
+// peers lost. Only records matching their hashes on the ledger are written.
+func (u RMTUsecase) ReconcilePrivateData(ctx context.Context, input entities.ReconcilePrivateDataInput, output *entities.RestorePrivateDataOutput) error {
+	slog.Debug(
+		"ReconcilePrivateData called",
+		"input", input,
+	)
+
+	if u.isThisPIU(input.PIU) {
+		err := errors.New("Cannot reconcile records with itself")
+		slog.Error(
+			err.Error(),
+			"clientId", u.piuId,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	exists, err := u.rep.PIUExists(input.PIU)
+
+	if err != nil {
+		slog.Error(
+			"Could not check existence of PIU",
+			"id", input.PIU,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	if !exists {
+		err := errors.New("PIU does not exist")
+		slog.Error(
+			err.Error(),
+			"id", input.PIU,
+		)
+		return status.Wrap(err, status.NotFound)
+	}
+
+	result, err := u.rep.RestoreSharedRecords(input.PIU)
+
+	if err != nil {
+		slog.Error(
+			"Could not reconcile shared records",
+			"piu", input.PIU,
+			"error", err,
+		)
+		return status.Wrap(err, status.FailedPrecondition)
+	}
+
+	*output = result
+
+	slog.Debug(
+		"ReconcilePrivateData finished",
+		"output", output,
+	)
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+
+	"github.com/swaggest/usecase/status"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+)
+
+func (u RMTUsecase) addClarification(from entities.RequestState, to entities.RequestState, byResponder bool, ctx context.Context, input entities.ClarificationInput, output *entities.ClarificationOutput) error {
+	pnr, err := u.rep.GetPNR(input.Id)
+	if err != nil {
+		slog.Error(
+			"Could not get PNR request",
+			"id", input.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	author := pnr.RequestingPIU
+	if byResponder {
+		author = pnr.RespondingPIU
+	}
+
+	if !u.isThisPIU(author) {
+		err := errors.New("Not allowed to add clarification to this request")
+		slog.Error(
+			err.Error(),
+			"clientId", u.piuId,
+			"requestingPIU", pnr.RequestingPIU,
+			"respondingPIU", pnr.RespondingPIU,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	if pnr.State != from {
+		err := errors.New("PNR request must be in " + string(from) + " state")
+		slog.Error(
+			err.Error(),
+			"id", input.Id,
+			"state", pnr.State,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	message := entities.OptionalMessage(input.Message)
+
+	if message == "" {
+		err := errors.New("Clarification message must not be empty")
+		slog.Error(
+			err.Error(),
+			"id", input.Id,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	msgs, err := u.rep.GetPNRClarifications(input.Id)
+	if err != nil {
+		slog.Error(
+			"Could not get PNR clarifications",
+			"id", input.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	msg := entities.ClarificationMessage{
+		Id:        pnr.Id,
+		Sequence:  len(msgs) + 1,
+		Author:    u.piuId,
+		Timestamp: input.Timestamp,
+		Message:   message,
+	}
+
+	pnr.State = to
+
+	err = u.rep.UpdatePNR(input.Id, pnr)
+	if err != nil {
+		slog.Error(
+			"Could not update PNR request",
+			"id", input.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	err = u.rep.InsertPNRClarification(pnr, msg)
+	if err != nil {
+		slog.Error(
+			"Could not insert PNR clarification",
+			"id", input.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	*output = entities.ClarificationOutput{}
+
+	return nil
+}
+
+func (u RMTUsecase) RequestClarification(ctx context.Context, input entities.ClarificationInput, output *entities.ClarificationOutput) error {
+	slog.Debug(
+		"RequestClarification called",
+		"input", input,
+	)
+
+	err := u.addClarification(entities.RequestStatePendingConfirmed, entities.RequestStateClarificationRequested, true, ctx, input, output)
+	if err != nil {
+		return err
+	}
+
+	slog.Debug(
+		"RequestClarification finished",
+		"output", output,
+	)
+
+	return nil
+}
+
+func (u RMTUsecase) ProvideClarification(ctx context.Context, input entities.ClarificationInput, output *entities.ClarificationOutput) error {
+	slog.Debug(
+		"ProvideClarification called",
+		"input", input,
+	)
+
+	err := u.addClarification(entities.RequestStateClarificationRequested, entities.RequestStatePendingConfirmed, false, ctx, input, output)
+	if err != nil {
+		return err
+	}
+
+	slog.Debug(
+		"ProvideClarification finished",
+		"output", output,
+	)
+
+	return nil
+}
+
+func (u RMTUsecase) GetPNRClarifications(ctx context.Context, input entities.GetPNRClarificationsInput, output *[]entities.ClarificationMessage) error {
+	slog.Debug(
+		"GetPNRClarifications called",
+		"input", input,
+	)
+
+	pnr, err := u.rep.GetPNR(input.Id)
+	if err != nil {
+		slog.Error(
+			"Could not get PNR request",
+			"id", input.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	if !u.isThisPIU(pnr.RequestingPIU) && !u.isThisPIU(pnr.RespondingPIU) {
+		err := errors.New("Not the requester or responder of this PNR request")
+		slog.Error(
+			err.Error(),
+			"clientId", u.piuId,
+			"requestingPIU", pnr.RequestingPIU,
+			"respondingPIU", pnr.RespondingPIU,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	out, err := u.rep.GetPNRClarifications(input.Id)
+	if err != nil {
+		slog.Error(
+			"Could not get PNR clarifications",
+			"id", input.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	slices.SortFunc(out, func(a, b entities.ClarificationMessage) int {
+		return a.Sequence - b.Sequence
+	})
+
+	*output = out
+
+	slog.Debug(
+		"GetPNRClarifications finished",
+		"output", output,
+	)
+
+	return nil
+}
@@ -0,0 +1,282 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/swaggest/usecase/status"
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+)
+
+// consortiumConfigSchema is the JSON schema of the consortium configuration.
+// Durations are in nanoseconds.
+const consortiumConfigSchema = `{
+	"type": "object",
+	"additionalProperties": false,
+	"required": ["adminMSPs"],
+	"definitions": {
+		"duration": {"type": "integer", "minimum": 0},
+		"limit": {"type": "integer", "minimum": 0},
+		"id": {"type": "string", "minLength": 1}
+	},
+	"properties": {
+		"version": {"type": "integer", "minimum": 0},
+		"priorityDeadlines": {
+			"type": ["object", "null"],
+			"propertyNames": {"enum": ["Routine", "Urgent", "Immediate"]},
+			"additionalProperties": {"$ref": "#/definitions/duration"}
+		},
+		"adminMSPs": {"type": "array", "minItems": 1, "items": {"$ref": "#/definitions/id"}},
+		"multiPIUMSPs": {"type": ["array", "null"], "items": {"$ref": "#/definitions/id"}},
+		"quota": {
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {
+				"window": {"$ref": "#/definitions/duration"},
+				"perPIU": {"$ref": "#/definitions/limit"},
+				"perPair": {"$ref": "#/definitions/limit"},
+				"piuLimits": {"type": ["object", "null"], "additionalProperties": {"$ref": "#/definitions/limit"}},
+				"pairLimits": {
+					"type": ["object", "null"],
+					"additionalProperties": {"type": "object", "additionalProperties": {"$ref": "#/definitions/limit"}}
+				}
+			}
+		},
+		"retention": {"$ref": "#/definitions/duration"},
+		"payloadProfiles": {"type": ["array", "null"], "items": {"$ref": "#/definitions/id"}},
+		"hashing": {
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {
+				"recordPath": {"type": "string"},
+				"creationTimePath": {"type": "string"}
+			}
+		},
+		"atRestEncryption": {"type": "boolean"}
+	}
+}`
+
+var consortiumConfigSchemaLoader = gojsonschema.NewStringLoader(consortiumConfigSchema)
+
+func validateConsortiumConfig(document []byte) error {
+	result, err := gojsonschema.Validate(consortiumConfigSchemaLoader, gojsonschema.NewBytesLoader(document))
+
+	if err != nil {
+		return err
+	}
+
+	if !result.Valid() {
+		var messages []string
+
+		for _, e := range result.Errors() {
+			messages = append(messages, e.String())
+		}
+
+		return errors.New("Configuration does not match the schema: " + strings.Join(messages, "; "))
+	}
+
+	return nil
+}
+
+func (u RMTUsecase) putConfig(initialise bool, input entities.UpdateConfigInput, output *entities.UpdateConfigOutput) error {
+	if !u.isAdmin() {
+		err := errors.New("Only consortium administrator can change consortium configuration")
+		slog.Error(
+			err.Error(),
+			"clientId", u.piuId,
+		)
+		return status.Wrap(err, status.PermissionDenied)
+	}
+
+	exists, err := u.rep.ConfigExists()
+
+	if err != nil {
+		slog.Error(
+			"Could not check consortium configuration",
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	if initialise && exists {
+		err := errors.New("Consortium configuration is already initialised")
+		slog.Error(
+			err.Error(),
+		)
+		return status.Wrap(err, status.FailedPrecondition)
+	}
+
+	if !initialise && !exists {
+		err := errors.New("Consortium configuration is not initialised")
+		slog.Error(
+			err.Error(),
+		)
+		return status.Wrap(err, status.FailedPrecondition)
+	}
+
+	version := 0
+
+	if exists {
+		current, err := u.rep.GetConfig()
+
+		if err != nil {
+			slog.Error(
+				"Could not get consortium configuration",
+				"error", err,
+			)
+			return status.Wrap(err, status.Internal)
+		}
+
+		version = current.Version
+	}
+
+	if input.ExpectedVersion != 0 && input.ExpectedVersion != version {
+		err := errors.New("Consortium configuration was changed concurrently")
+		slog.Error(
+			err.Error(),
+			"expectedVersion", input.ExpectedVersion,
+			"version", version,
+		)
+		return status.Wrap(err, status.FailedPrecondition)
+	}
+
+	document := []byte(entities.OptionalMessage(input.Config))
+
+	err = validateConsortiumConfig(document)
+
+	if err != nil {
+		slog.Error(
+			"Invalid consortium configuration",
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	config := entities.DefaultConsortiumConfig()
+
+	err = json.Unmarshal(document, &config)
+
+	if err != nil {
+		slog.Error(
+			"Could not parse consortium configuration",
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	if !slices.Contains(config.AdminMSPs, u.mspId) {
+		err := errors.New("Consortium administrator cannot remove itself from administrators")
+		slog.Error(
+			err.Error(),
+			"mspId", u.mspId,
+			"adminMSPs", config.AdminMSPs,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	config.Version = version + 1
+
+	err = u.rep.PutConfig(config)
+
+	if err != nil {
+		slog.Error(
+			"Could not store consortium configuration",
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	*output = entities.UpdateConfigOutput{Version: config.Version}
+
+	return nil
+}
+
+// InitLedger stores the first consortium configuration on the ledger, which
+// replaces the configuration the chaincode was started with.
+func (u RMTUsecase) InitLedger(ctx context.Context, input entities.UpdateConfigInput, output *entities.UpdateConfigOutput) error {
+	slog.Debug(
+		"InitLedger called",
+		"input", input,
+	)
+
+	err := u.putConfig(true, input, output)
+
+	if err != nil {
+		return err
+	}
+
+	slog.Debug(
+		"InitLedger finished",
+		"output", output,
+	)
+
+	return nil
+}
+
+func (u RMTUsecase) UpdateConfig(ctx context.Context, input entities.UpdateConfigInput, output *entities.UpdateConfigOutput) error {
+	slog.Debug(
+		"UpdateConfig called",
+		"input", input,
+	)
+
+	err := u.putConfig(false, input, output)
+
+	if err != nil {
+		return err
+	}
+
+	slog.Debug(
+		"UpdateConfig finished",
+		"output", output,
+	)
+
+	return nil
+}
+
+// GetConfig returns the consortium configuration stored on the ledger, or
+// the one the usecase was created with before the ledger is initialised.
+func (u RMTUsecase) GetConfig(ctx context.Context, input entities.GetConfigInput, output *entities.ConsortiumConfig) error {
+	slog.Debug(
+		"GetConfig called",
+		"input", input,
+	)
+
+	exists, err := u.rep.ConfigExists()
+
+	if err != nil {
+		slog.Error(
+			"Could not check consortium configuration",
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	config := u.config
+
+	if exists {
+		config, err = u.rep.GetConfig()
+
+		if err != nil {
+			slog.Error(
+				"Could not get consortium configuration",
+				"error", err,
+			)
+			return status.Wrap(err, status.Internal)
+		}
+	}
+
+	*output = config
+
+	slog.Debug(
+		"GetConfig finished",
+		"output", output,
+	)
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/swaggest/usecase/status"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+)
+
+func (u RMTUsecase) comparePNRCopies(pnr entities.PNR) (entities.PNRConsistency, error) {
+	keys, err := u.rep.ComparePNRCopies(pnr)
+
+	if err != nil {
+		slog.Error(
+			"Could not compare copies of PNR records",
+			"id", pnr.Id,
+			"error", err,
+		)
+		return entities.PNRConsistency{}, status.Wrap(err, status.Internal)
+	}
+
+	result := entities.PNRConsistency{Id: pnr.Id, Consistent: true, Keys: keys}
+
+	for _, key := range keys {
+		if !key.Consistent {
+			result.Consistent = false
+		}
+	}
+
+	return result, nil
+}
+
+// VerifyPNRConsistency compares hashes of the copies of records of the PNR
+// request kept by the PIU and its counterpart.
+func (u RMTUsecase) VerifyPNRConsistency(ctx context.Context, input entities.VerifyPNRConsistencyInput, output *entities.PNRConsistency) error {
+	slog.Debug(
+		"VerifyPNRConsistency called",
+		"input", input,
+	)
+
+	pnr, err := u.rep.GetPNR(input.Id)
+
+	if err != nil {
+		slog.Error(
+			"Could not get PNR request",
+			"id", input.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	if !u.isThisPIU(pnr.RequestingPIU) && !u.isThisPIU(pnr.RespondingPIU) {
+		err := errors.New("PIU is not a party of the PNR request")
+		slog.Error(
+			err.Error(),
+			"clientId", u.piuId,
+			"id", pnr.Id,
+		)
+		return status.Wrap(err, status.PermissionDenied)
+	}
+
+	result, err := u.comparePNRCopies(pnr)
+
+	if err != nil {
+		return err
+	}
+
+	*output = result
+
+	slog.Debug(
+		"VerifyPNRConsistency finished",
+		"output", output,
+	)
+
+	return nil
+}
+
+// VerifyAllPNRConsistency lists PNR requests of the PIU whose copies differ.
+// Drafts of requests and responses are not shared yet, so they are left out.
+func (u RMTUsecase) VerifyAllPNRConsistency(ctx context.Context, input entities.VerifyAllPNRConsistencyInput, output *[]entities.PNRConsistency) error {
+	slog.Debug(
+		"VerifyAllPNRConsistency called",
+		"input", input,
+	)
+
+	pnrs, err := u.rep.GetPNRs(entities.PNRFilter{})
+
+	if err != nil {
+		slog.Error(
+			"Failed to get PNRs from the repository",
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	entities.SortPNRs(pnrs, "", false)
+
+	result := []entities.PNRConsistency{}
+
+	for _, pnr := range pnrs {
+		if pnr.State == entities.RequestStateDraft || pnr.State == entities.RequestStateAckDraft {
+			continue
+		}
+
+		comparison, err := u.comparePNRCopies(pnr)
+
+		if err != nil {
+			return err
+		}
+
+		if !comparison.Consistent {
+			result = append(result, comparison)
+		}
+	}
+
+	*output = result
+
+	slog.Debug(
+		"VerifyAllPNRConsistency finished",
+		"output", output,
+	)
+
+	return nil
+}
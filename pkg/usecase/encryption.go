@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"log/slog"
+	"regexp"
+
+	"github.com/swaggest/usecase/status"
+	"github.com/tidwall/gjson"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+)
+
+const gcmNonceSize = 12
+const gcmTagSize = 16
+
+var sha256HexRegexp = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// encryptionKeyId identifies a PEM encoded public key by the hash of its DER
+// encoding.
+func encryptionKeyId(data string) string {
+	block, _ := pem.Decode([]byte(data))
+
+	if block == nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(block.Bytes)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// decodeEncryptedPayload decodes the data if they are an encrypted payload,
+// plaintext data are reported as not encrypted.
+func decodeEncryptedPayload(data string) (entities.EncryptedPayload, bool, error) {
+	var payload entities.EncryptedPayload
+
+	if !gjson.Valid(data) || gjson.Get(data, "format").String() != entities.EncryptedPayloadFormat {
+		return payload, false, nil
+	}
+
+	err := json.Unmarshal([]byte(data), &payload)
+
+	return payload, true, err
+}
+
+func checkEncryptedPayloadKey(payload entities.EncryptedPayload, key any) error {
+	if len(payload.Nonce) != gcmNonceSize {
+		return errors.New("Invalid nonce size")
+	}
+
+	if len(payload.Ciphertext) < gcmTagSize {
+		return errors.New("Ciphertext is too short")
+	}
+
+	if ecdsaKey, ok := key.(*ecdsa.PublicKey); ok {
+		ecdhKey, err := ecdsaKey.ECDH()
+
+		if err != nil {
+			return err
+		}
+
+		key = ecdhKey
+	}
+
+	switch key := key.(type) {
+	case *ecdh.PublicKey:
+		if payload.Algorithm != entities.EncryptionAlgorithmECDH || len(payload.EncryptedKey) > 0 {
+			return errors.New("Payload must use ECDH-ES with the encryption key of the recipient")
+		}
+
+		if _, err := key.Curve().NewPublicKey(payload.EphemeralKey); err != nil {
+			return errors.New("Invalid ephemeral key: " + err.Error())
+		}
+	case *rsa.PublicKey:
+		if payload.Algorithm != entities.EncryptionAlgorithmRSAOAEP || len(payload.EphemeralKey) > 0 {
+			return errors.New("Payload must use RSA-OAEP with the encryption key of the recipient")
+		}
+
+		if len(payload.EncryptedKey) != key.Size() {
+			return errors.New("Invalid size of encrypted key")
+		}
+	default:
+		return errors.New("Unsupported encryption key type")
+	}
+
+	return nil
+}
+
+// checkEncryptedPayload verifies that encrypted data are encrypted to the
+// current encryption key of the recipient and returns id of the key, which is
+// empty for plaintext data. The chaincode cannot decrypt the data, so only the
+// format of the payload is checked.
+func (u RMTUsecase) checkEncryptedPayload(data string, recipient string) (string, error) {
+	payload, encrypted, err := decodeEncryptedPayload(data)
+
+	if err != nil {
+		slog.Error(
+			"Could not decode encrypted payload",
+			"error", err,
+		)
+		return "", status.Wrap(err, status.InvalidArgument)
+	}
+
+	if !encrypted {
+		return "", nil
+	}
+
+	return u.checkPayloadRecipient(payload, recipient)
+}
+
+// checkPayloadRecipient verifies that the payload is encrypted to the current
+// encryption key of the recipient and returns id of the key.
+func (u RMTUsecase) checkPayloadRecipient(payload entities.EncryptedPayload, recipient string) (string, error) {
+	piu, err := u.rep.GetPIU(recipient)
+
+	if err != nil {
+		slog.Error(
+			"Could not get information about PIU",
+			"id", recipient,
+			"error", err,
+		)
+		return "", status.Wrap(err, status.InvalidArgument)
+	}
+
+	if piu.EncryptionKey == "" {
+		err := errors.New("Recipient has no encryption key")
+		slog.Error(
+			err.Error(),
+			"recipient", recipient,
+		)
+		return "", status.Wrap(err, status.FailedPrecondition)
+	}
+
+	keyId := encryptionKeyId(piu.EncryptionKey)
+
+	if payload.KeyId != keyId {
+		err := errors.New("Payload is not encrypted to the current encryption key of the recipient")
+		slog.Error(
+			err.Error(),
+			"recipient", recipient,
+			"keyId", payload.KeyId,
+			"expected", keyId,
+		)
+		return "", status.Wrap(err, status.InvalidArgument)
+	}
+
+	key, err := parsePublicKeyPEM(piu.EncryptionKey)
+
+	if err != nil {
+		slog.Error(
+			"Could not parse encryption key of PIU",
+			"id", recipient,
+			"error", err,
+		)
+		return "", status.Wrap(err, status.Internal)
+	}
+
+	err = checkEncryptedPayloadKey(payload, key)
+
+	if err != nil {
+		slog.Error(
+			"Invalid encrypted payload",
+			"recipient", recipient,
+			"error", err,
+		)
+		return "", status.Wrap(err, status.InvalidArgument)
+	}
+
+	for _, hash := range payload.PNRHashes {
+		if !sha256HexRegexp.MatchString(hash) {
+			err := errors.New("PNR hashes must be hex encoded SHA-256 hashes")
+			slog.Error(
+				err.Error(),
+				"hash", hash,
+			)
+			return "", status.Wrap(err, status.InvalidArgument)
+		}
+	}
+
+	return keyId, nil
+}
@@ -0,0 +1,297 @@
+package usecase
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/swaggest/usecase/status"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+)
+
+// defaultExportPageSize bounds the number of records in a page of an export
+// when the page size is not given.
+const defaultExportPageSize = 500
+
+// exportCSVHeader lists columns of CSV exports, which follow the JSON names of
+// entities.PNRMetadata fields.
+var exportCSVHeader = []string{
+	"id",
+	"requestingPIU",
+	"respondingPIU",
+	"requestTimestamp",
+	"responseTimestamp",
+	"state",
+	"pnrHashes",
+	"parentId",
+	"allowOnBehalfForwarding",
+	"priority",
+	"deadline",
+	"payloadProfile",
+	"purpose",
+	"agreementId",
+	"draftedBy",
+}
+
+// exportBookmark is the position of the next page together with the
+// transaction which exported the first page and the hash of the whole export,
+// which has to stay the same for the following pages.
+type exportBookmark struct {
+	Offset int    `json:"offset"`
+	TxId   string `json:"txId"`
+	SHA256 string `json:"sha256"`
+}
+
+func decodeExportBookmark(bookmark string) (exportBookmark, error) {
+	var position exportBookmark
+
+	if bookmark == "" {
+		return position, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(bookmark)
+
+	if err != nil {
+		return position, err
+	}
+
+	err = json.Unmarshal(data, &position)
+
+	if err != nil {
+		return position, err
+	}
+
+	if position.Offset <= 0 || position.SHA256 == "" {
+		return position, errors.New("incomplete export bookmark")
+	}
+
+	return position, nil
+}
+
+func encodeExportBookmark(position exportBookmark) (string, error) {
+	data, err := json.Marshal(position)
+
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+func formatExportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func exportCSV(records []entities.PNRMetadata, header bool) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	w := csv.NewWriter(&buffer)
+
+	if header {
+		w.Write(exportCSVHeader)
+	}
+
+	for _, r := range records {
+		w.Write([]string{
+			r.Id,
+			r.RequestingPIU,
+			r.RespondingPIU,
+			formatExportTime(r.RequestTimestamp),
+			formatExportTime(r.ResponseTimestamp),
+			string(r.State),
+			strings.Join(r.PNRHashes, ";"),
+			r.ParentId,
+			strconv.FormatBool(r.AllowOnBehalfForwarding),
+			string(r.Priority),
+			formatExportTime(r.Deadline),
+			r.PayloadProfile,
+			r.Purpose,
+			r.AgreementId,
+			r.DraftedBy,
+		})
+	}
+
+	w.Flush()
+
+	return buffer.Bytes(), w.Error()
+}
+
+func exportJSONLines(records []entities.PNRMetadata) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	encoder := json.NewEncoder(&buffer)
+
+	for _, r := range records {
+		err := encoder.Encode(r)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func exportRecords(format entities.ExportFormat, records []entities.PNRMetadata, header bool) ([]byte, error) {
+	if format == entities.ExportFormatCSV {
+		return exportCSV(records, header)
+	}
+
+	return exportJSONLines(records)
+}
+
+// ExportPNRMetadata exports metadata of PNR requests matching the filter, in
+// pages which concatenated form the whole export. The manifest of every page
+// holds the number of exported records and the hash over all pages, following
+// pages are refused once the exported records change.
+func (u RMTUsecase) ExportPNRMetadata(ctx context.Context, input entities.ExportPNRMetadataInput, output *entities.ExportPNRMetadataOutput) error {
+	slog.Debug(
+		"ExportPNRMetadata called",
+		"input", input,
+	)
+
+	format := cmp.Or(input.Format, entities.ExportFormatJSONLines)
+
+	if format != entities.ExportFormatJSONLines && format != entities.ExportFormatCSV {
+		err := errors.New("Invalid export format")
+		slog.Error(
+			err.Error(),
+			"format", input.Format,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	position, err := decodeExportBookmark(input.Bookmark)
+
+	if err != nil {
+		slog.Error(
+			"Invalid export bookmark",
+			"bookmark", input.Bookmark,
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	var pnrs []entities.PNR
+
+	err = u.GetPNRs(ctx, input.Filter, &pnrs)
+
+	if err != nil {
+		return err
+	}
+
+	// Pages have to be taken from the same order of records, which is by id
+	// unless the filter sorts them.
+	if input.Filter.SortBy == "" {
+		entities.SortPNRs(pnrs, "", input.Filter.SortDescending)
+	}
+
+	records := make([]entities.PNRMetadata, 0, len(pnrs))
+
+	for _, pnr := range pnrs {
+		records = append(records, entities.ToPNRMetadata(pnr))
+	}
+
+	// The hash is computed over the whole export in every call, so that it
+	// does not depend on anything held by the client.
+	export, err := exportRecords(format, records, true)
+
+	if err != nil {
+		slog.Error(
+			"Could not encode exported records",
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	sum := sha256.Sum256(export)
+	digest := hex.EncodeToString(sum[:])
+
+	if input.Bookmark == "" {
+		position.TxId = u.txId
+	} else if position.SHA256 != digest || position.Offset > len(records) {
+		err := errors.New("Exported records changed since the first page")
+		slog.Error(
+			err.Error(),
+			"txId", position.TxId,
+			"offset", position.Offset,
+			"totalCount", len(records),
+		)
+		return status.Wrap(err, status.FailedPrecondition)
+	}
+
+	pageSize := input.PageSize
+
+	if pageSize <= 0 {
+		pageSize = defaultExportPageSize
+	}
+
+	offset := position.Offset
+	end := min(offset+pageSize, len(records))
+
+	data, err := exportRecords(format, records[offset:end], offset == 0)
+
+	if err != nil {
+		slog.Error(
+			"Could not encode exported records",
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	now, err := u.now()
+
+	if err != nil {
+		return err
+	}
+
+	*output = entities.ExportPNRMetadataOutput{
+		Data: string(data),
+		Manifest: entities.ExportManifest{
+			PIU:         u.piuId,
+			TxId:        position.TxId,
+			Format:      format,
+			Filter:      input.Filter,
+			Timestamp:   now,
+			Offset:      offset,
+			RecordCount: end - offset,
+			TotalCount:  len(records),
+			SHA256:      digest,
+			Complete:    end == len(records),
+		},
+	}
+
+	if end < len(records) {
+		output.Bookmark, err = encodeExportBookmark(exportBookmark{Offset: end, TxId: position.TxId, SHA256: digest})
+
+		if err != nil {
+			slog.Error(
+				"Could not encode export bookmark",
+				"error", err,
+			)
+			return status.Wrap(err, status.Internal)
+		}
+	}
+
+	slog.Debug(
+		"ExportPNRMetadata finished",
+		"manifest", output.Manifest,
+	)
+
+	return nil
+}
@@ -0,0 +1,278 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/swaggest/usecase/status"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+)
+
+func (u RMTUsecase) ForwardPNRRequest(ctx context.Context, input entities.ForwardPNRRequestInput, output *entities.ForwardPNRRequestOutput) error {
+	slog.Debug(
+		"ForwardPNRRequest called",
+		"input", input,
+	)
+
+	pnr, err := u.rep.GetPNR(input.Id)
+	if err != nil {
+		slog.Error(
+			"Could not get PNR request",
+			"id", input.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	if !u.isThisPIU(pnr.RespondingPIU) {
+		err := errors.New("Only responding PIU can forward the request")
+		slog.Error(
+			err.Error(),
+			"clientId", u.piuId,
+			"respondingPIU", pnr.RespondingPIU,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	if pnr.State != entities.RequestStatePendingConfirmed {
+		err := errors.New("PNR request must be in PendingConfirmed state")
+		slog.Error(
+			err.Error(),
+			"id", input.Id,
+			"state", pnr.State,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	if input.NewId == "" || input.NewId == input.Id {
+		err := errors.New("Invalid id of the new PNR request")
+		slog.Error(
+			err.Error(),
+			"id", input.Id,
+			"newId", input.NewId,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	if input.RespondingPIU == pnr.RequestingPIU || input.RespondingPIU == pnr.RespondingPIU {
+		err := errors.New("Request must be forwarded to a third PIU")
+		slog.Error(
+			err.Error(),
+			"id", input.Id,
+			"respondingPIU", input.RespondingPIU,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	err = u.checkActivePIU(input.RespondingPIU)
+
+	if err != nil {
+		return err
+	}
+
+	err = u.checkSupportedProfile(input.RespondingPIU, pnr.PayloadProfile)
+
+	if err != nil {
+		return err
+	}
+
+	// Forwarder owning the new request gives its own consent to forwarding
+	// on its behalf, consent of the requester applies only to its requests.
+	requestingPIU := u.piuId
+	allowOnBehalfForwarding := input.AllowOnBehalfForwarding
+
+	if input.OnBehalfOfRequester {
+		if !pnr.AllowOnBehalfForwarding {
+			err := errors.New("Requester does not allow forwarding on its behalf")
+			slog.Error(
+				err.Error(),
+				"id", input.Id,
+				"requestingPIU", pnr.RequestingPIU,
+			)
+			return status.Wrap(err, status.InvalidArgument)
+		}
+
+		requestingPIU = pnr.RequestingPIU
+		allowOnBehalfForwarding = pnr.AllowOnBehalfForwarding
+	}
+
+	now, err := u.now()
+
+	if err != nil {
+		return err
+	}
+
+	agreement, err := u.findAgreement(requestingPIU, input.RespondingPIU, pnr.Purpose, now)
+
+	if err != nil {
+		return err
+	}
+
+	// Forwarded request counts against the quota of its requester towards
+	// the new responder, so forwarding cannot bypass the limits.
+	err = u.checkQuota(requestingPIU, input.RespondingPIU, now)
+
+	if err != nil {
+		return err
+	}
+
+	forwarded := entities.PNR{
+		Id:                      input.NewId,
+		RequestingPIU:           requestingPIU,
+		RespondingPIU:           input.RespondingPIU,
+		RequestTimestamp:        input.ForwardTimestamp,
+		State:                   entities.RequestStatePending,
+		RequestData:             pnr.RequestData,
+		PNRHashes:               []string{},
+		ParentId:                pnr.Id,
+		AllowOnBehalfForwarding: allowOnBehalfForwarding,
+		Priority:                entities.GetPriority(pnr.Priority),
+		Deadline:                u.config.GetDeadline(pnr.Priority, input.ForwardTimestamp),
+		PayloadProfile:          pnr.PayloadProfile,
+		Purpose:                 pnr.Purpose,
+		AgreementId:             agreement.Id,
+	}
+
+	if pnr.RequestKeyId != "" {
+		if input.RequestData == nil {
+			err := errors.New("Encrypted request data must be encrypted to the PIU the request is forwarded to")
+			slog.Error(
+				err.Error(),
+				"id", input.Id,
+				"respondingPIU", input.RespondingPIU,
+			)
+			return status.Wrap(err, status.InvalidArgument)
+		}
+
+		forwarded.RequestData = entities.OptionalMessage(input.RequestData)
+		forwarded.RequestKeyId, err = u.checkEncryptedPayload(forwarded.RequestData, input.RespondingPIU)
+
+		if err != nil {
+			return err
+		}
+
+		if forwarded.RequestKeyId == "" {
+			err := errors.New("Forwarded request data must stay encrypted")
+			slog.Error(
+				err.Error(),
+				"id", input.Id,
+			)
+			return status.Wrap(err, status.InvalidArgument)
+		}
+	} else if input.RequestData != nil {
+		err := errors.New("Request data can only be replaced when forwarding encrypted request")
+		slog.Error(
+			err.Error(),
+			"id", input.Id,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	} else {
+		// Request data encrypted at rest are sealed again for the parties of
+		// the forwarded request with the same data key.
+		forwarded.RequestData, err = openPNRData(pnr, input.DataKey, requestDataField, pnr.RequestData)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	err = u.sealPNRRequest(&forwarded, input.DataKey)
+
+	if err != nil {
+		return err
+	}
+
+	gc := entities.GCMetadata{
+		Id:                forwarded.Id,
+		CreationTimestamp: forwarded.RequestTimestamp,
+		PurgeDeadline:     u.config.GetPurgeDeadline(forwarded.RequestTimestamp),
+	}
+
+	err = u.rep.InsertForwardedPNR(forwarded, gc)
+
+	if err != nil {
+		slog.Error(
+			"Could not insert forwarded PNR",
+			"id", forwarded.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	err = u.consumeQuota(requestingPIU, input.RespondingPIU, now)
+
+	if err != nil {
+		return err
+	}
+
+	err = u.consumeAgreement(agreement)
+
+	if err != nil {
+		return err
+	}
+
+	pnr.State = entities.RequestStateForwarded
+
+	err = u.rep.UpdatePNR(pnr.Id, pnr)
+
+	if err != nil {
+		slog.Error(
+			"Could not update PNR request",
+			"id", pnr.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	err = u.rep.PurgePNRData(pnr.Id)
+
+	if err != nil {
+		slog.Error(
+			"Could not purge PNR data",
+			"id", pnr.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	err = u.rep.PurgePNRClarifications(pnr.Id)
+
+	if err != nil {
+		slog.Error(
+			"Could not purge PNR clarifications",
+			"id", pnr.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	notification := entities.Notification{
+		Recipients: []string{pnr.RequestingPIU, forwarded.RespondingPIU},
+		Id:         forwarded.Id,
+		ParentId:   pnr.Id,
+		State:      forwarded.State,
+		Sender:     u.piuId,
+	}
+
+	err = u.notifier.Notify(entities.PNRForwardedEventName, notification)
+
+	if err != nil {
+		slog.Error(
+			"Could not notify about forwarded PNR",
+			"id", forwarded.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	*output = entities.ForwardPNRRequestOutput{Id: forwarded.Id}
+
+	slog.Debug(
+		"ForwardPNRRequest finished",
+		"output", output,
+	)
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+
+	"github.com/swaggest/usecase/status"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+)
+
+func (u RMTUsecase) isAdmin() bool {
+	return slices.Contains(u.config.AdminMSPs, u.mspId)
+}
+
+// checkActivePIU fails unless the PIU exists and is allowed to take part in
+// new PNR exchanges.
+func (u RMTUsecase) checkActivePIU(id string) error {
+	piu, err := u.rep.GetPIU(id)
+
+	if err != nil {
+		slog.Error(
+			"Could not get information about PIU",
+			"id", id,
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	if entities.GetPIUStatus(piu.Status) != entities.PIUStatusActive {
+		err := errors.New("PIU is not active")
+		slog.Error(
+			err.Error(),
+			"id", id,
+			"status", piu.Status,
+		)
+		return status.Wrap(err, status.FailedPrecondition)
+	}
+
+	return nil
+}
+
+func (u RMTUsecase) changePIUStatus(from []entities.PIUStatus, to entities.PIUStatus, ctx context.Context, input entities.ChangePIUStatusInput, output *entities.ChangePIUStatusOutput) error {
+	if !u.isAdmin() {
+		err := errors.New("Only consortium administrator can change PIU status")
+		slog.Error(
+			err.Error(),
+			"clientId", u.piuId,
+		)
+		return status.Wrap(err, status.PermissionDenied)
+	}
+
+	piu, err := u.rep.GetPIU(input.Id)
+
+	if err != nil {
+		slog.Error(
+			"Could not get information about PIU",
+			"id", input.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	if !slices.Contains(from, entities.GetPIUStatus(piu.Status)) {
+		err := errors.New("PIU status cannot be changed to " + string(to))
+		slog.Error(
+			err.Error(),
+			"id", input.Id,
+			"status", piu.Status,
+		)
+		return status.Wrap(err, status.FailedPrecondition)
+	}
+
+	piu.Status = to
+	piu.Version++
+
+	err = u.rep.UpdatePIU(piu.Id, piu)
+
+	if err != nil {
+		slog.Error(
+			"Failed writing PIU information to repository",
+			"id", input.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	*output = entities.ChangePIUStatusOutput{}
+
+	return nil
+}
+
+// ApprovePIU activates a newly registered PIU or lifts its suspension.
+func (u RMTUsecase) ApprovePIU(ctx context.Context, input entities.ChangePIUStatusInput, output *entities.ChangePIUStatusOutput) error {
+	slog.Debug(
+		"ApprovePIU called",
+		"input", input,
+	)
+
+	from := []entities.PIUStatus{entities.PIUStatusPending, entities.PIUStatusSuspended}
+
+	err := u.changePIUStatus(from, entities.PIUStatusActive, ctx, input, output)
+
+	slog.Debug(
+		"ApprovePIU finished",
+		"output", output,
+	)
+
+	return err
+}
+
+func (u RMTUsecase) SuspendPIU(ctx context.Context, input entities.ChangePIUStatusInput, output *entities.ChangePIUStatusOutput) error {
+	slog.Debug(
+		"SuspendPIU called",
+		"input", input,
+	)
+
+	from := []entities.PIUStatus{entities.PIUStatusActive}
+
+	err := u.changePIUStatus(from, entities.PIUStatusSuspended, ctx, input, output)
+
+	slog.Debug(
+		"SuspendPIU finished",
+		"output", output,
+	)
+
+	return err
+}
+
+// RetirePIU offboards a PIU. The PIU is kept in the repository so that PNR
+// requests it took part in can still be audited.
+func (u RMTUsecase) RetirePIU(ctx context.Context, input entities.ChangePIUStatusInput, output *entities.ChangePIUStatusOutput) error {
+	slog.Debug(
+		"RetirePIU called",
+		"input", input,
+	)
+
+	from := []entities.PIUStatus{entities.PIUStatusPending, entities.PIUStatusActive, entities.PIUStatusSuspended}
+
+	err := u.changePIUStatus(from, entities.PIUStatusRetired, ctx, input, output)
+
+	slog.Debug(
+		"RetirePIU finished",
+		"output", output,
+	)
+
+	return err
+}
@@ -8,16 +8,59 @@ import (
 )
 
 type Clock interface {
-	Now() time.Time
+	Now() (time.Time, error)
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() (time.Time, error) {
+	return time.Now(), nil
+}
+
+type Notifier interface {
+	Notify(name string, notification entities.Notification) error
+}
+
+type noopNotifier struct{}
+
+func (noopNotifier) Notify(name string, notification entities.Notification) error {
+	return nil
 }
 
 type PNRExchangeUsecase interface {
-	SetPIUInfo(ctx context.Context, input entities.PIUInfo, output *entities.SetPIUInfoOutput) error
+	SetPIUInfo(ctx context.Context, input entities.SetPIUInfoInput, output *entities.SetPIUInfoOutput) error
+	GetPIUHistory(ctx context.Context, input entities.GetPIUHistoryInput, output *[]entities.PIUHistoryEntry) error
 	GetPIUs(ctx context.Context, input entities.GetPIUsInput, output *[]entities.PIU) error
+	ApprovePIU(ctx context.Context, input entities.ChangePIUStatusInput, output *entities.ChangePIUStatusOutput) error
+	SuspendPIU(ctx context.Context, input entities.ChangePIUStatusInput, output *entities.ChangePIUStatusOutput) error
+	RetirePIU(ctx context.Context, input entities.ChangePIUStatusInput, output *entities.ChangePIUStatusOutput) error
+	ProposeAgreement(ctx context.Context, input entities.ProposeAgreementInput, output *entities.ProposeAgreementOutput) error
+	SignAgreement(ctx context.Context, input entities.SignAgreementInput, output *entities.SignAgreementOutput) error
+	RevokeAgreement(ctx context.Context, input entities.RevokeAgreementInput, output *entities.RevokeAgreementOutput) error
+	GetAgreements(ctx context.Context, input entities.GetAgreementsInput, output *[]entities.Agreement) error
+	GetQuotaStatus(ctx context.Context, input entities.GetQuotaStatusInput, output *entities.QuotaStatus) error
+	InitLedger(ctx context.Context, input entities.UpdateConfigInput, output *entities.UpdateConfigOutput) error
+	UpdateConfig(ctx context.Context, input entities.UpdateConfigInput, output *entities.UpdateConfigOutput) error
+	GetConfig(ctx context.Context, input entities.GetConfigInput, output *entities.ConsortiumConfig) error
+	MigrateData(ctx context.Context, input entities.MigrateDataInput, output *entities.MigrateDataOutput) error
+	BackupPrivateData(ctx context.Context, input entities.BackupPrivateDataInput, output *entities.PrivateDataArchive) error
+	RestorePrivateData(ctx context.Context, input entities.RestorePrivateDataInput, output *entities.RestorePrivateDataOutput) error
+	ReconcilePrivateData(ctx context.Context, input entities.ReconcilePrivateDataInput, output *entities.RestorePrivateDataOutput) error
+	VerifyPNRConsistency(ctx context.Context, input entities.VerifyPNRConsistencyInput, output *entities.PNRConsistency) error
+	VerifyPNRResponseSignature(ctx context.Context, input entities.VerifyPNRResponseSignatureInput, output *entities.PNRResponseSignature) error
+	VerifyAllPNRConsistency(ctx context.Context, input entities.VerifyAllPNRConsistencyInput, output *[]entities.PNRConsistency) error
 	GetPNRs(ctx context.Context, input entities.PNRFilter, output *[]entities.PNR) error
+	ExportPNRMetadata(ctx context.Context, input entities.ExportPNRMetadataInput, output *entities.ExportPNRMetadataOutput) error
 	NewPNRRequest(ctx context.Context, input entities.NewPNRRequestInput, output *entities.NewPNRRequestOutput) error
 	SubmitPNRResponseAck(ctx context.Context, input entities.SubmitPNRResponseInput, output *entities.SubmitPNRResponseOutput) error
 	SubmitPNRResponseNack(ctx context.Context, input entities.SubmitPNRResponseInput, output *entities.SubmitPNRResponseOutput) error
+	ApprovePNRRequest(ctx context.Context, input entities.ApprovePNRDraftInput, output *entities.ApprovePNRDraftOutput) error
+	ApprovePNRResponse(ctx context.Context, input entities.ApprovePNRDraftInput, output *entities.ApprovePNRDraftOutput) error
 	ConfirmPNR(ctx context.Context, input entities.ConfirmPNRInput, output *entities.ConfirmPNROutput) error
 	TerminatePNRRequest(ctx context.Context, input entities.TerminatePNRRequestInput, output *entities.TerminatePNRRequestOutput) error
+	RequestClarification(ctx context.Context, input entities.ClarificationInput, output *entities.ClarificationOutput) error
+	ProvideClarification(ctx context.Context, input entities.ClarificationInput, output *entities.ClarificationOutput) error
+	GetPNRClarifications(ctx context.Context, input entities.GetPNRClarificationsInput, output *[]entities.ClarificationMessage) error
+	ForwardPNRRequest(ctx context.Context, input entities.ForwardPNRRequestInput, output *entities.ForwardPNRRequestOutput) error
+	GetPNRThread(ctx context.Context, input entities.GetPNRThreadInput, output *[]entities.PNR) error
 }
@@ -2,14 +2,34 @@ package usecase_test
 
 import (
 	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gowebpki/jcs"
 	"github.com/samber/lo"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/swaggest/usecase/status"
 
 	"github.com/nesfit/tenacity-chaincode/pkg/entities"
 	"github.com/nesfit/tenacity-chaincode/pkg/repository"
@@ -20,15 +40,35 @@ import (
 
 var testPIUId string = testdata.PIUs[0].Id
 
+// newTestingClock returns a clock fixed at the time of testing PNR requests.
+func newTestingClock() *fixedClock {
+	return &fixedClock{now: testdata.MiddleTimestamp}
+}
+
 func newTestingUsecase() (repository.Repository, usecase.PNRExchangeUsecase) {
 	r := inmemory.NewInMemoryRepository()
-	return r, usecase.NewRMTUsecase(testPIUId, r)
+	return r, usecase.NewRMTUsecase(testPIUId, r, usecase.WithClock(newTestingClock()))
 }
 
+// setupPIUs inserts testing PIUs together with agreements which allow them
+// to exchange PNR requests for terrorism purpose.
 func setupPIUs(r repository.Repository) {
 	for _, piu := range testdata.PIUs {
 		r.InsertPIU(piu.Id, piu)
 	}
+
+	for _, agreement := range testdata.Agreements {
+		r.InsertAgreement(agreement.Id, agreement)
+	}
+}
+
+func piuInfoPatch(info entities.PIUInfo) entities.SetPIUInfoInput {
+	return rawPIUInfoPatch(string(lo.Must(json.Marshal(info))), 0)
+}
+
+func rawPIUInfoPatch(patch string, expectedVersion int) entities.SetPIUInfoInput {
+	message := json.RawMessage(patch)
+	return entities.SetPIUInfoInput{Patch: &message, ExpectedVersion: expectedVersion}
 }
 
 func TestSetPIUInfoCreate(t *testing.T) {
@@ -41,6 +81,8 @@ func TestSetPIUInfoCreate(t *testing.T) {
 			Id:         testPIUId,
 			Name:       "testing PIU",
 			AdminEmail: "admin@testingPIU.org",
+			Status:     entities.PIUStatusPending,
+			Version:    1,
 		},
 	}
 
@@ -51,7 +93,7 @@ func TestSetPIUInfoCreate(t *testing.T) {
 
 	var output entities.SetPIUInfoOutput
 
-	err := u.SetPIUInfo(context.TODO(), input, &output)
+	err := u.SetPIUInfo(context.TODO(), piuInfoPatch(input), &output)
 	assert.NoError(err)
 
 	actual, _ := r.GetPIUs()
@@ -68,6 +110,7 @@ func TestSetPIUInfoUpdate(t *testing.T) {
 			Id:         testPIUId,
 			Name:       "testing PIU",
 			AdminEmail: "admin@testingPIU.org",
+			Version:    1,
 		},
 	}
 
@@ -84,13 +127,179 @@ func TestSetPIUInfoUpdate(t *testing.T) {
 
 	var output entities.SetPIUInfoOutput
 
-	err := u.SetPIUInfo(context.TODO(), input, &output)
+	err := u.SetPIUInfo(context.TODO(), piuInfoPatch(input), &output)
 	assert.NoError(err)
 
 	actual, _ := r.GetPIUs()
 	assert.ElementsMatch(expected, actual)
 }
 
+func TestSetPIUInfoMergePatch(t *testing.T) {
+	assert := assert.New(t)
+
+	r, u := newTestingUsecase()
+
+	var output entities.SetPIUInfoOutput
+
+	err := u.SetPIUInfo(context.TODO(), rawPIUInfoPatch(`{"name": "testing PIU", "adminEmail": "admin@testingPIU.org", "country": "CZ"}`, 0), &output)
+	assert.NoError(err)
+	assert.Equal(1, output.Version)
+
+	err = u.SetPIUInfo(context.TODO(), rawPIUInfoPatch(`{"adminEmail": null, "name": "renamed PIU"}`, 0), &output)
+	assert.NoError(err)
+	assert.Equal(2, output.Version)
+
+	expected := entities.PIU{
+		Id:      testPIUId,
+		Name:    "renamed PIU",
+		Country: "CZ",
+		Status:  entities.PIUStatusPending,
+		Version: 2,
+	}
+
+	actual, _ := r.GetPIU(testPIUId)
+	assert.Equal(expected, actual)
+
+	for name, patch := range map[string]string{
+		"notObject":    `["name"]`,
+		"unknownField": `{"status": "Active"}`,
+		"invalidJSON":  `{"name": `,
+		"wrongType":    `{"name": 42}`,
+	} {
+		err = u.SetPIUInfo(context.TODO(), rawPIUInfoPatch(patch, 0), &output)
+		assert.Error(err, name)
+	}
+
+	actual, _ = r.GetPIU(testPIUId)
+	assert.Equal(expected, actual)
+}
+
+func TestSetPIUInfoExpectedVersion(t *testing.T) {
+	assert := assert.New(t)
+
+	r, u := newTestingUsecase()
+
+	var output entities.SetPIUInfoOutput
+
+	err := u.SetPIUInfo(context.TODO(), rawPIUInfoPatch(`{"name": "testing PIU"}`, 1), &output)
+	assert.Error(err)
+
+	err = u.SetPIUInfo(context.TODO(), rawPIUInfoPatch(`{"name": "testing PIU"}`, 0), &output)
+	assert.NoError(err)
+
+	err = u.SetPIUInfo(context.TODO(), rawPIUInfoPatch(`{"name": "first"}`, 1), &output)
+	assert.NoError(err)
+
+	err = u.SetPIUInfo(context.TODO(), rawPIUInfoPatch(`{"name": "second"}`, 1), &output)
+	assert.Error(err)
+
+	actual, _ := r.GetPIU(testPIUId)
+	assert.Equal("first", actual.Name)
+	assert.Equal(2, actual.Version)
+}
+
+func TestGetPIUHistory(t *testing.T) {
+	assert := assert.New(t)
+
+	r, u := newTestingUsecase()
+	admin := newTestingAdminUsecase(r)
+
+	u.SetPIUInfo(context.TODO(), rawPIUInfoPatch(`{"name": "testing PIU"}`, 0), &entities.SetPIUInfoOutput{})
+	admin.ApprovePIU(context.TODO(), entities.ChangePIUStatusInput{Id: testPIUId}, &entities.ChangePIUStatusOutput{})
+	u.SetPIUInfo(context.TODO(), rawPIUInfoPatch(`{"name": "renamed PIU"}`, 0), &entities.SetPIUInfoOutput{})
+
+	var history []entities.PIUHistoryEntry
+
+	err := u.GetPIUHistory(context.TODO(), entities.GetPIUHistoryInput{Id: testPIUId}, &history)
+	assert.NoError(err)
+
+	pius := lo.Map(history, func(v entities.PIUHistoryEntry, i int) entities.PIU { return v.PIU })
+	assert.Equal([]entities.PIU{
+		{Id: testPIUId, Name: "testing PIU", Status: entities.PIUStatusPending, Version: 1},
+		{Id: testPIUId, Name: "testing PIU", Status: entities.PIUStatusActive, Version: 2},
+		{Id: testPIUId, Name: "renamed PIU", Status: entities.PIUStatusActive, Version: 3},
+	}, pius)
+}
+
+func publicKeyPEM(key any) string {
+	der := lo.Must(x509.MarshalPKIXPublicKey(key))
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestSetPIUInfoProfile(t *testing.T) {
+	assert := assert.New(t)
+
+	r, u := newTestingUsecase()
+
+	encryptionKey := lo.Must(ecdsa.GenerateKey(elliptic.P256(), rand.Reader))
+	signingKey, _ := lo.Must2(ed25519.GenerateKey(rand.Reader))
+
+	input := entities.PIUInfo{
+		Name:              "testing PIU",
+		Country:           "CZ",
+		Contacts:          []entities.PIUContact{{Name: "Duty officer", Role: "operations", Phone: "+420123456789"}},
+		SupportedProfiles: []string{"PNRGOV/21.1"},
+		EncryptionKey:     publicKeyPEM(&encryptionKey.PublicKey),
+		SigningKey:        publicKeyPEM(signingKey),
+	}
+
+	var output entities.SetPIUInfoOutput
+
+	err := u.SetPIUInfo(context.TODO(), piuInfoPatch(input), &output)
+	assert.NoError(err)
+
+	expected := entities.NewPIUFromPIUInfo(testPIUId, input)
+
+	actual, _ := r.GetPIU(testPIUId)
+	assert.Equal(expected, actual)
+
+	err = u.SetPIUInfo(context.TODO(), rawPIUInfoPatch(`{"supportedProfiles": ["PNRGOV/21.1", "PNRGOV/13.1"]}`, 0), &output)
+	assert.NoError(err)
+
+	expected.SupportedProfiles = []string{"PNRGOV/21.1", "PNRGOV/13.1"}
+	expected.Version = 2
+
+	actual, _ = r.GetPIU(testPIUId)
+	assert.Equal(expected, actual)
+}
+
+func TestSetPIUInfoInvalidProfile(t *testing.T) {
+	shortRSAKey := lo.Must(rsa.GenerateKey(rand.Reader, 1024))
+	privateKey := lo.Must(ecdsa.GenerateKey(elliptic.P256(), rand.Reader))
+	x25519Key := lo.Must(ecdh.X25519().GenerateKey(rand.Reader))
+
+	testCases := map[string]entities.PIUInfo{
+		"lowercaseCountry": {Country: "cz"},
+		"alpha3Country":    {Country: "CZE"},
+		"emptyContact":     {Contacts: []entities.PIUContact{{Name: "nobody"}}},
+		"emptyProfile":     {SupportedProfiles: []string{""}},
+		"notPEM":           {EncryptionKey: "not a key"},
+		"privateKey": {
+			EncryptionKey: string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: lo.Must(x509.MarshalECPrivateKey(privateKey))})),
+		},
+		"shortRSAKey":      {SigningKey: publicKeyPEM(&shortRSAKey.PublicKey)},
+		"x25519SigningKey": {SigningKey: publicKeyPEM(x25519Key.PublicKey())},
+		"multipleKeys":     {SigningKey: publicKeyPEM(&privateKey.PublicKey) + publicKeyPEM(&privateKey.PublicKey)},
+		"keyAsCertificate": {SigningCertificate: publicKeyPEM(&privateKey.PublicKey)},
+	}
+
+	for name, input := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			r, u := newTestingUsecase()
+
+			var output entities.SetPIUInfoOutput
+
+			err := u.SetPIUInfo(context.TODO(), piuInfoPatch(input), &output)
+			assert.Error(err)
+
+			exists, _ := r.PIUExists(testPIUId)
+			assert.False(exists)
+		})
+	}
+}
+
 func TestGetPIUsEmpty(t *testing.T) {
 	assert := assert.New(t)
 
@@ -127,6 +336,92 @@ func TestGetPIUsNonEmpty(t *testing.T) {
 	assert.ElementsMatch(expected, actual)
 }
 
+func newTestingAdminUsecase(r repository.Repository) usecase.PNRExchangeUsecase {
+	config := entities.DefaultConsortiumConfig()
+	config.AdminMSPs = []string{"adminMSP"}
+	return usecase.NewRMTUsecase("adminMSP", r, usecase.WithConfig(config))
+}
+
+func TestGetPIUsRetired(t *testing.T) {
+	assert := assert.New(t)
+
+	r, u := newTestingUsecase()
+	setupPIUs(r)
+
+	retired := testdata.PIUs[2]
+	retired.Status = entities.PIUStatusRetired
+	r.UpdatePIU(retired.Id, retired)
+
+	var actual []entities.PIU
+
+	err := u.GetPIUs(context.TODO(), entities.GetPIUsInput{}, &actual)
+	assert.NoError(err)
+	assert.ElementsMatch(testdata.PIUs[:2], actual)
+
+	err = u.GetPIUs(context.TODO(), entities.GetPIUsInput{IncludeRetired: true}, &actual)
+	assert.NoError(err)
+	assert.ElementsMatch(append([]entities.PIU{retired}, testdata.PIUs[:2]...), actual)
+}
+
+func TestChangePIUStatus(t *testing.T) {
+	assert := assert.New(t)
+
+	r, u := newTestingUsecase()
+	admin := newTestingAdminUsecase(r)
+
+	var output entities.ChangePIUStatusOutput
+
+	err := u.SetPIUInfo(context.TODO(), piuInfoPatch(entities.PIUInfo{Name: "testing PIU"}), &entities.SetPIUInfoOutput{})
+	assert.NoError(err)
+
+	input := entities.ChangePIUStatusInput{Id: testPIUId}
+
+	steps := []struct {
+		Change   func(context.Context, entities.ChangePIUStatusInput, *entities.ChangePIUStatusOutput) error
+		Expected entities.PIUStatus
+		Error    bool
+	}{
+		{Change: admin.SuspendPIU, Expected: entities.PIUStatusPending, Error: true},
+		{Change: admin.ApprovePIU, Expected: entities.PIUStatusActive},
+		{Change: admin.ApprovePIU, Expected: entities.PIUStatusActive, Error: true},
+		{Change: admin.SuspendPIU, Expected: entities.PIUStatusSuspended},
+		{Change: admin.ApprovePIU, Expected: entities.PIUStatusActive},
+		{Change: admin.RetirePIU, Expected: entities.PIUStatusRetired},
+		{Change: admin.ApprovePIU, Expected: entities.PIUStatusRetired, Error: true},
+	}
+
+	for _, step := range steps {
+		err := step.Change(context.TODO(), input, &output)
+		if step.Error {
+			assert.Error(err)
+		} else {
+			assert.NoError(err)
+		}
+
+		actual, _ := r.GetPIU(testPIUId)
+		assert.Equal(step.Expected, actual.Status)
+	}
+
+	err = u.SetPIUInfo(context.TODO(), piuInfoPatch(entities.PIUInfo{Name: "renamed PIU"}), &entities.SetPIUInfoOutput{})
+	assert.Error(err)
+}
+
+func TestChangePIUStatusNotAdmin(t *testing.T) {
+	assert := assert.New(t)
+
+	r, u := newTestingUsecase()
+
+	r.InsertPIU(testdata.PIUs[1].Id, entities.NewPIUFromPIUInfo(testdata.PIUs[1].Id, entities.PIUInfo{}))
+
+	var output entities.ChangePIUStatusOutput
+
+	err := u.ApprovePIU(context.TODO(), entities.ChangePIUStatusInput{Id: testdata.PIUs[1].Id}, &output)
+	assert.Error(err)
+
+	actual, _ := r.GetPIU(testdata.PIUs[1].Id)
+	assert.Equal(entities.PIUStatusPending, actual.Status)
+}
+
 func TestGetPNRsEmpty(t *testing.T) {
 	assert := assert.New(t)
 
@@ -224,6 +519,22 @@ func TestGetPNRsFilter(t *testing.T) {
 				return v.RespondingPIU == testdata.PNRs[1].RespondingPIU
 			}),
 		},
+		"priority": {
+			Filter: entities.PNRFilter{
+				Priority: entities.RequestPriorityUrgent,
+			},
+			Expected: lo.Filter(testdata.PNRs, func(v entities.PNR, i int) bool {
+				return v.Priority == entities.RequestPriorityUrgent
+			}),
+		},
+		"routinePriority": {
+			Filter: entities.PNRFilter{
+				Priority: entities.RequestPriorityRoutine,
+			},
+			Expected: lo.Filter(testdata.PNRs, func(v entities.PNR, i int) bool {
+				return v.Priority == "" || v.Priority == entities.RequestPriorityRoutine
+			}),
+		},
 		"exact": {
 			Filter: entities.PNRFilter{
 				Start:         testdata.PNRs[1].RequestTimestamp.Add(-1 * time.Microsecond),
@@ -265,6 +576,63 @@ func TestGetPNRsFilter(t *testing.T) {
 	}
 }
 
+func TestGetPNRsSort(t *testing.T) {
+	ids := func(pnrs []entities.PNR) []string {
+		return lo.Map(pnrs, func(v entities.PNR, i int) string { return v.Id })
+	}
+
+	testCases := map[string]struct {
+		Filter   entities.PNRFilter
+		Expected []string
+	}{
+		"requestTimestamp": {
+			Filter:   entities.PNRFilter{SortBy: entities.PNRSortByRequestTimestamp},
+			Expected: []string{"pnr1", "pnr2", "pnr3", "pnr4", "pnr5"},
+		},
+		"requestTimestampDescending": {
+			Filter:   entities.PNRFilter{SortBy: entities.PNRSortByRequestTimestamp, SortDescending: true},
+			Expected: []string{"pnr5", "pnr4", "pnr3", "pnr2", "pnr1"},
+		},
+		"priorityDescending": {
+			Filter:   entities.PNRFilter{SortBy: entities.PNRSortByPriority, SortDescending: true},
+			Expected: []string{"pnr4", "pnr2", "pnr5", "pnr3", "pnr1"},
+		},
+		"deadlineUrgent": {
+			Filter:   entities.PNRFilter{SortBy: entities.PNRSortByDeadline, Priority: entities.RequestPriorityUrgent},
+			Expected: []string{"pnr2"},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			r, u := newTestingUsecase()
+
+			for _, pnr := range testdata.PNRs {
+				r.InsertPNR(pnr.Id, pnr)
+			}
+
+			var actual []entities.PNR
+
+			err := u.GetPNRs(context.TODO(), testCase.Filter, &actual)
+			assert.NoError(err)
+			assert.Equal(testCase.Expected, ids(actual))
+		})
+	}
+}
+
+func TestGetPNRsInvalidSort(t *testing.T) {
+	assert := assert.New(t)
+
+	_, u := newTestingUsecase()
+
+	var actual []entities.PNR
+
+	err := u.GetPNRs(context.TODO(), entities.PNRFilter{SortBy: "name"}, &actual)
+	assert.Error(err)
+}
+
 func TestNewPNRRequest(t *testing.T) {
 	assert := assert.New(t)
 
@@ -277,6 +645,7 @@ func TestNewPNRRequest(t *testing.T) {
 		RespondingPIU:    testdata.PIUs[1].Id,
 		RequestTimestamp: testdata.MiddleTimestamp,
 		RequestData:      &requestData,
+		Purpose:          "terrorism",
 	}
 
 	var output entities.NewPNRRequestOutput
@@ -292,34 +661,91 @@ func TestNewPNRRequest(t *testing.T) {
 		State:            entities.RequestStatePending,
 		RequestData:      string(*input.RequestData),
 		PNRHashes:        []string{},
+		Priority:         entities.RequestPriorityRoutine,
+		Deadline:         testdata.MiddleTimestamp.Add(7 * 24 * time.Hour),
+		Purpose:          "terrorism",
+		AgreementId:      "agreement12",
 	}
 
 	actual, _ := r.GetPNR(output.Id)
 	assert.Equal(expected, actual)
 }
 
-func TestNewPNRRequestMissingRequestingPIU(t *testing.T) {
+func TestNewPNRRequestPriority(t *testing.T) {
 	assert := assert.New(t)
 
-	_, u := newTestingUsecase()
+	r := inmemory.NewInMemoryRepository()
+	u := usecase.NewRMTUsecase(testPIUId, r, usecase.WithConfig(entities.ConsortiumConfig{
+		PriorityDeadlines: map[entities.RequestPriority]time.Duration{
+			entities.RequestPriorityImmediate: time.Hour,
+		},
+	}))
+	setupPIUs(r)
 
-	input := entities.NewPNRRequestInput{
-		RespondingPIU: testdata.PIUs[1].Id,
-	}
+	var requestData json.RawMessage = lo.Must(json.Marshal("test request data"))
 
 	var output entities.NewPNRRequestOutput
 
-	err := u.NewPNRRequest(context.TODO(), input, &output)
+	err := u.NewPNRRequest(context.TODO(), entities.NewPNRRequestInput{
+		Id:               "immediate",
+		RespondingPIU:    testdata.PIUs[1].Id,
+		RequestTimestamp: testdata.MiddleTimestamp,
+		RequestData:      &requestData,
+		Priority:         entities.RequestPriorityImmediate,
+		Purpose:          "terrorism",
+	}, &output)
+	assert.NoError(err)
+
+	actual, _ := r.GetPNR("immediate")
+	assert.Equal(entities.RequestPriorityImmediate, actual.Priority)
+	assert.Equal(testdata.MiddleTimestamp.Add(time.Hour), actual.Deadline)
+
+	err = u.NewPNRRequest(context.TODO(), entities.NewPNRRequestInput{
+		Id:               "routine",
+		RespondingPIU:    testdata.PIUs[1].Id,
+		RequestTimestamp: testdata.MiddleTimestamp,
+		RequestData:      &requestData,
+		Purpose:          "terrorism",
+	}, &output)
+	assert.NoError(err)
+
+	actual, _ = r.GetPNR("routine")
+	assert.Equal(entities.RequestPriorityRoutine, actual.Priority)
+	assert.Equal(time.Time{}, actual.Deadline)
+}
+
+func TestNewPNRRequestInvalidPriority(t *testing.T) {
+	assert := assert.New(t)
+
+	r, u := newTestingUsecase()
+	setupPIUs(r)
+
+	var requestData json.RawMessage = lo.Must(json.Marshal("test request data"))
+
+	var output entities.NewPNRRequestOutput
+
+	err := u.NewPNRRequest(context.TODO(), entities.NewPNRRequestInput{
+		Id:               "someId",
+		RespondingPIU:    testdata.PIUs[1].Id,
+		RequestTimestamp: testdata.MiddleTimestamp,
+		RequestData:      &requestData,
+		Priority:         "Whenever",
+		Purpose:          "terrorism",
+	}, &output)
 	assert.Error(err)
+
+	exists, _ := r.PNRExists("someId")
+	assert.False(exists)
 }
 
-func TestNewPNRRequestMissingRespondingPIU(t *testing.T) {
+func TestNewPNRRequestMissingRequestingPIU(t *testing.T) {
 	assert := assert.New(t)
 
 	_, u := newTestingUsecase()
 
 	input := entities.NewPNRRequestInput{
-		RespondingPIU: "missingPIU",
+		RespondingPIU: testdata.PIUs[1].Id,
+		Purpose:       "terrorism",
 	}
 
 	var output entities.NewPNRRequestOutput
@@ -328,13 +754,14 @@ func TestNewPNRRequestMissingRespondingPIU(t *testing.T) {
 	assert.Error(err)
 }
 
-func TestNewPNRRequestFromSelf(t *testing.T) {
+func TestNewPNRRequestMissingRespondingPIU(t *testing.T) {
 	assert := assert.New(t)
 
 	_, u := newTestingUsecase()
 
 	input := entities.NewPNRRequestInput{
-		RespondingPIU: testPIUId,
+		RespondingPIU: "missingPIU",
+		Purpose:       "terrorism",
 	}
 
 	var output entities.NewPNRRequestOutput
@@ -343,36 +770,141 @@ func TestNewPNRRequestFromSelf(t *testing.T) {
 	assert.Error(err)
 }
 
-func TestSubmitPNRResponse(t *testing.T) {
-	testCases := []entities.RequestState{
-		entities.RequestStateAck,
-		entities.RequestStateNack,
+func TestNewPNRRequestInactivePIU(t *testing.T) {
+	testCases := map[string]struct {
+		PIUId  string
+		Status entities.PIUStatus
+	}{
+		"pendingRequester":   {PIUId: testPIUId, Status: entities.PIUStatusPending},
+		"suspendedResponder": {PIUId: testdata.PIUs[1].Id, Status: entities.PIUStatusSuspended},
+		"retiredResponder":   {PIUId: testdata.PIUs[1].Id, Status: entities.PIUStatusRetired},
 	}
 
-	for _, state := range testCases {
-		t.Run(string(state), func(t *testing.T) {
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
 			assert := assert.New(t)
 
 			r, u := newTestingUsecase()
 			setupPIUs(r)
 
+			piu, _ := r.GetPIU(testCase.PIUId)
+			piu.Status = testCase.Status
+			r.UpdatePIU(piu.Id, piu)
+
 			var requestData json.RawMessage = lo.Must(json.Marshal("test request data"))
-			var responseData json.RawMessage = lo.Must(json.Marshal("test response data"))
 
-			originalRequest := entities.PNR{
+			var output entities.NewPNRRequestOutput
+
+			err := u.NewPNRRequest(context.TODO(), entities.NewPNRRequestInput{
 				Id:               "someId",
-				RequestingPIU:    testdata.PIUs[1].Id,
-				RespondingPIU:    testPIUId,
+				RespondingPIU:    testdata.PIUs[1].Id,
 				RequestTimestamp: testdata.MiddleTimestamp,
-				State:            entities.RequestStatePendingConfirmed,
-				RequestData:      string(requestData),
-				PNRHashes:        []string{},
-			}
+				RequestData:      &requestData,
+				Purpose:          "terrorism",
+			}, &output)
+			assert.Error(err)
 
-			r.InsertPNR(originalRequest.Id, originalRequest)
-			r.InsertGCMetadata(originalRequest, entities.GCMetadata{Id: originalRequest.Id, CreationTimestamp: originalRequest.RequestTimestamp})
+			exists, _ := r.PNRExists("someId")
+			assert.False(exists)
+		})
+	}
+}
 
-			input := entities.SubmitPNRResponseInput{
+func TestNewPNRRequestPayloadProfile(t *testing.T) {
+	testCases := map[string]struct {
+		Profile string
+		Valid   bool
+	}{
+		"supported":   {Profile: "PNRGOV/21.1", Valid: true},
+		"unspecified": {Profile: "", Valid: true},
+		"unsupported": {Profile: "PNRGOV/13.1", Valid: false},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			r, u := newTestingUsecase()
+			setupPIUs(r)
+
+			responder := testdata.PIUs[1]
+			responder.SupportedProfiles = []string{"PNRGOV/21.1"}
+			r.UpdatePIU(responder.Id, responder)
+
+			var requestData json.RawMessage = lo.Must(json.Marshal("test request data"))
+
+			var output entities.NewPNRRequestOutput
+
+			err := u.NewPNRRequest(context.TODO(), entities.NewPNRRequestInput{
+				Id:               "someId",
+				RespondingPIU:    responder.Id,
+				RequestTimestamp: testdata.MiddleTimestamp,
+				RequestData:      &requestData,
+				PayloadProfile:   testCase.Profile,
+				Purpose:          "terrorism",
+			}, &output)
+
+			if testCase.Valid {
+				assert.NoError(err)
+
+				actual, _ := r.GetPNR("someId")
+				assert.Equal(testCase.Profile, actual.PayloadProfile)
+			} else {
+				assert.Error(err)
+
+				exists, _ := r.PNRExists("someId")
+				assert.False(exists)
+			}
+		})
+	}
+}
+
+func TestNewPNRRequestFromSelf(t *testing.T) {
+	assert := assert.New(t)
+
+	_, u := newTestingUsecase()
+
+	input := entities.NewPNRRequestInput{
+		RespondingPIU: testPIUId,
+		Purpose:       "terrorism",
+	}
+
+	var output entities.NewPNRRequestOutput
+
+	err := u.NewPNRRequest(context.TODO(), input, &output)
+	assert.Error(err)
+}
+
+func TestSubmitPNRResponse(t *testing.T) {
+	testCases := []entities.RequestState{
+		entities.RequestStateAck,
+		entities.RequestStateNack,
+	}
+
+	for _, state := range testCases {
+		t.Run(string(state), func(t *testing.T) {
+			assert := assert.New(t)
+
+			r, u := newTestingUsecase()
+			setupPIUs(r)
+
+			var requestData json.RawMessage = lo.Must(json.Marshal("test request data"))
+			var responseData json.RawMessage = lo.Must(json.Marshal("test response data"))
+
+			originalRequest := entities.PNR{
+				Id:               "someId",
+				RequestingPIU:    testdata.PIUs[1].Id,
+				RespondingPIU:    testPIUId,
+				RequestTimestamp: testdata.MiddleTimestamp,
+				State:            entities.RequestStatePendingConfirmed,
+				RequestData:      string(requestData),
+				PNRHashes:        []string{},
+			}
+
+			r.InsertPNR(originalRequest.Id, originalRequest)
+			r.InsertGCMetadata(originalRequest, entities.GCMetadata{Id: originalRequest.Id, CreationTimestamp: originalRequest.RequestTimestamp})
+
+			input := entities.SubmitPNRResponseInput{
 				Id:                originalRequest.Id,
 				ResponseTimestamp: testdata.LatestTimestamp,
 				ResponseData:      &responseData,
@@ -977,3 +1509,2135 @@ func TestTerminatePNRRequestUnrelatedPIU(t *testing.T) {
 	err := u.TerminatePNRRequest(context.TODO(), input, &output)
 	assert.Error(err)
 }
+
+func TestNewPNRRequestWithParent(t *testing.T) {
+	assert := assert.New(t)
+
+	r, u := newTestingUsecase()
+	setupPIUs(r)
+
+	parent := testdata.PNRs[1]
+	r.InsertPNR(parent.Id, parent)
+
+	var requestData json.RawMessage = lo.Must(json.Marshal("test request data"))
+
+	input := entities.NewPNRRequestInput{
+		Id:               "followUp",
+		RespondingPIU:    parent.RequestingPIU,
+		RequestTimestamp: testdata.LatestTimestamp,
+		RequestData:      &requestData,
+		ParentId:         parent.Id,
+		Purpose:          "terrorism",
+	}
+
+	var output entities.NewPNRRequestOutput
+
+	err := u.NewPNRRequest(context.TODO(), input, &output)
+	assert.NoError(err)
+
+	actual, _ := r.GetPNR(output.Id)
+	assert.Equal(parent.Id, actual.ParentId)
+}
+
+func TestNewPNRRequestMissingParent(t *testing.T) {
+	assert := assert.New(t)
+
+	r, u := newTestingUsecase()
+	setupPIUs(r)
+
+	var requestData json.RawMessage = lo.Must(json.Marshal("test request data"))
+
+	input := entities.NewPNRRequestInput{
+		Id:               "followUp",
+		RespondingPIU:    testdata.PIUs[1].Id,
+		RequestTimestamp: testdata.LatestTimestamp,
+		RequestData:      &requestData,
+		ParentId:         "missing",
+		Purpose:          "terrorism",
+	}
+
+	var output entities.NewPNRRequestOutput
+
+	err := u.NewPNRRequest(context.TODO(), input, &output)
+	assert.Error(err)
+}
+
+func TestNewPNRRequestParentWithDifferentPIUs(t *testing.T) {
+	assert := assert.New(t)
+
+	r, u := newTestingUsecase()
+	setupPIUs(r)
+
+	parent := testdata.PNRs[1]
+	r.InsertPNR(parent.Id, parent)
+
+	var requestData json.RawMessage = lo.Must(json.Marshal("test request data"))
+
+	input := entities.NewPNRRequestInput{
+		Id:               "followUp",
+		RespondingPIU:    testdata.PIUs[2].Id,
+		RequestTimestamp: testdata.LatestTimestamp,
+		RequestData:      &requestData,
+		ParentId:         parent.Id,
+		Purpose:          "terrorism",
+	}
+
+	var output entities.NewPNRRequestOutput
+
+	err := u.NewPNRRequest(context.TODO(), input, &output)
+	assert.Error(err)
+
+	exists, _ := r.PNRExists(input.Id)
+	assert.False(exists)
+}
+
+func TestGetPNRThread(t *testing.T) {
+	root := entities.PNR{
+		Id:               "root",
+		RequestingPIU:    testPIUId,
+		RespondingPIU:    testdata.PIUs[1].Id,
+		RequestTimestamp: testdata.EarliestTimestamp,
+		State:            entities.RequestStateNackConfirmed,
+		PNRHashes:        []string{},
+	}
+	child := entities.PNR{
+		Id:               "child",
+		RequestingPIU:    testPIUId,
+		RespondingPIU:    testdata.PIUs[1].Id,
+		RequestTimestamp: testdata.MiddleTimestamp,
+		State:            entities.RequestStatePending,
+		RequestData:      "\"requestData\"",
+		PNRHashes:        []string{},
+		ParentId:         root.Id,
+	}
+	grandchild := entities.PNR{
+		Id:               "grandchild",
+		RequestingPIU:    testdata.PIUs[1].Id,
+		RespondingPIU:    testPIUId,
+		RequestTimestamp: testdata.LatestTimestamp,
+		State:            entities.RequestStatePending,
+		RequestData:      "\"requestData\"",
+		PNRHashes:        []string{},
+		ParentId:         child.Id,
+	}
+	unrelated := testdata.PNRs[0]
+
+	expected := []entities.PNR{
+		entities.WithoutData(root),
+		entities.WithoutData(child),
+		entities.WithoutData(grandchild),
+	}
+
+	for _, start := range []entities.PNR{root, child, grandchild} {
+		t.Run(start.Id, func(t *testing.T) {
+			assert := assert.New(t)
+
+			r, u := newTestingUsecase()
+			setupPIUs(r)
+
+			for _, pnr := range []entities.PNR{grandchild, unrelated, child, root} {
+				r.InsertPNR(pnr.Id, pnr)
+			}
+
+			var actual []entities.PNR
+
+			err := u.GetPNRThread(context.TODO(), entities.GetPNRThreadInput{Id: start.Id}, &actual)
+			assert.NoError(err)
+			assert.Equal(expected, actual)
+		})
+	}
+}
+
+func TestGetPNRThreadMissingPNR(t *testing.T) {
+	assert := assert.New(t)
+
+	_, u := newTestingUsecase()
+
+	var actual []entities.PNR
+
+	err := u.GetPNRThread(context.TODO(), entities.GetPNRThreadInput{Id: "missing"}, &actual)
+	assert.Error(err)
+}
+
+func TestRequestAndProvideClarification(t *testing.T) {
+	assert := assert.New(t)
+
+	r, u := newTestingUsecase()
+	setupPIUs(r)
+
+	var question json.RawMessage = lo.Must(json.Marshal("which flight?"))
+	var answer json.RawMessage = lo.Must(json.Marshal("OK123"))
+
+	originalRequest := entities.PNR{
+		Id:               "someId",
+		RequestingPIU:    testdata.PIUs[1].Id,
+		RespondingPIU:    testPIUId,
+		RequestTimestamp: testdata.EarliestTimestamp,
+		State:            entities.RequestStatePendingConfirmed,
+		RequestData:      "\"requestData\"",
+		PNRHashes:        []string{},
+	}
+
+	r.InsertPNR(originalRequest.Id, originalRequest)
+
+	var output entities.ClarificationOutput
+
+	err := u.RequestClarification(context.TODO(), entities.ClarificationInput{
+		Id:        originalRequest.Id,
+		Timestamp: testdata.MiddleTimestamp,
+		Message:   &question,
+	}, &output)
+	assert.NoError(err)
+
+	actual, _ := r.GetPNR(originalRequest.Id)
+	assert.Equal(entities.RequestStateClarificationRequested, actual.State)
+
+	requester := usecase.NewRMTUsecase(testdata.PIUs[1].Id, r)
+
+	err = requester.ProvideClarification(context.TODO(), entities.ClarificationInput{
+		Id:        originalRequest.Id,
+		Timestamp: testdata.LatestTimestamp,
+		Message:   &answer,
+	}, &output)
+	assert.NoError(err)
+
+	actual, _ = r.GetPNR(originalRequest.Id)
+	assert.Equal(entities.RequestStatePendingConfirmed, actual.State)
+
+	expected := []entities.ClarificationMessage{
+		{Id: originalRequest.Id, Sequence: 1, Author: testPIUId, Timestamp: testdata.MiddleTimestamp, Message: string(question)},
+		{Id: originalRequest.Id, Sequence: 2, Author: testdata.PIUs[1].Id, Timestamp: testdata.LatestTimestamp, Message: string(answer)},
+	}
+
+	var msgs []entities.ClarificationMessage
+
+	err = u.GetPNRClarifications(context.TODO(), entities.GetPNRClarificationsInput{Id: originalRequest.Id}, &msgs)
+	assert.NoError(err)
+	assert.Equal(expected, msgs)
+}
+
+func TestRequestClarificationWrongPIUOrState(t *testing.T) {
+	testCases := map[string]struct {
+		RequestingPIU string
+		RespondingPIU string
+		State         entities.RequestState
+	}{
+		"requester": {
+			RequestingPIU: testPIUId,
+			RespondingPIU: testdata.PIUs[1].Id,
+			State:         entities.RequestStatePendingConfirmed,
+		},
+		"pending": {
+			RequestingPIU: testdata.PIUs[1].Id,
+			RespondingPIU: testPIUId,
+			State:         entities.RequestStatePending,
+		},
+		"alreadyRequested": {
+			RequestingPIU: testdata.PIUs[1].Id,
+			RespondingPIU: testPIUId,
+			State:         entities.RequestStateClarificationRequested,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			r, u := newTestingUsecase()
+			setupPIUs(r)
+
+			var question json.RawMessage = lo.Must(json.Marshal("which flight?"))
+
+			originalRequest := entities.PNR{
+				Id:               "someId",
+				RequestingPIU:    testCase.RequestingPIU,
+				RespondingPIU:    testCase.RespondingPIU,
+				RequestTimestamp: testdata.EarliestTimestamp,
+				State:            testCase.State,
+				RequestData:      "\"requestData\"",
+				PNRHashes:        []string{},
+			}
+
+			r.InsertPNR(originalRequest.Id, originalRequest)
+
+			var output entities.ClarificationOutput
+
+			err := u.RequestClarification(context.TODO(), entities.ClarificationInput{
+				Id:        originalRequest.Id,
+				Timestamp: testdata.MiddleTimestamp,
+				Message:   &question,
+			}, &output)
+			assert.Error(err)
+
+			msgs, _ := r.GetPNRClarifications(originalRequest.Id)
+			assert.Empty(msgs)
+		})
+	}
+}
+
+func TestProvideClarificationByResponder(t *testing.T) {
+	assert := assert.New(t)
+
+	r, u := newTestingUsecase()
+	setupPIUs(r)
+
+	var answer json.RawMessage = lo.Must(json.Marshal("OK123"))
+
+	originalRequest := entities.PNR{
+		Id:               "someId",
+		RequestingPIU:    testdata.PIUs[1].Id,
+		RespondingPIU:    testPIUId,
+		RequestTimestamp: testdata.EarliestTimestamp,
+		State:            entities.RequestStateClarificationRequested,
+		RequestData:      "\"requestData\"",
+		PNRHashes:        []string{},
+	}
+
+	r.InsertPNR(originalRequest.Id, originalRequest)
+
+	var output entities.ClarificationOutput
+
+	err := u.ProvideClarification(context.TODO(), entities.ClarificationInput{
+		Id:        originalRequest.Id,
+		Timestamp: testdata.LatestTimestamp,
+		Message:   &answer,
+	}, &output)
+	assert.Error(err)
+}
+
+func TestClarificationsPurged(t *testing.T) {
+	testCases := map[string]func(u usecase.PNRExchangeUsecase, id string) error{
+		"confirm": func(u usecase.PNRExchangeUsecase, id string) error {
+			return u.ConfirmPNR(context.TODO(), entities.ConfirmPNRInput{Id: id}, &entities.ConfirmPNROutput{})
+		},
+		"terminate": func(u usecase.PNRExchangeUsecase, id string) error {
+			return u.TerminatePNRRequest(context.TODO(), entities.TerminatePNRRequestInput{Id: id}, &entities.TerminatePNRRequestOutput{})
+		},
+	}
+
+	for name, finish := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			r, u := newTestingUsecase()
+			setupPIUs(r)
+
+			originalRequest := entities.PNR{
+				Id:                "someId",
+				RequestingPIU:     testPIUId,
+				RespondingPIU:     testdata.PIUs[1].Id,
+				RequestTimestamp:  testdata.MiddleTimestamp,
+				ResponseTimestamp: testdata.LatestTimestamp,
+				State:             entities.RequestStateAck,
+				RequestData:       "\"requestData\"",
+				ResponseData:      "\"responseData\"",
+				PNRHashes:         []string{},
+			}
+
+			r.InsertPNR(originalRequest.Id, originalRequest)
+			r.InsertGCMetadata(originalRequest, entities.GCMetadata{Id: originalRequest.Id, CreationTimestamp: originalRequest.RequestTimestamp})
+			r.InsertPNRClarification(originalRequest, entities.ClarificationMessage{Id: originalRequest.Id, Sequence: 1, Author: testdata.PIUs[1].Id, Message: "\"question\""})
+
+			err := finish(u, originalRequest.Id)
+			assert.NoError(err)
+
+			msgs, _ := r.GetPNRClarifications(originalRequest.Id)
+			assert.Empty(msgs)
+		})
+	}
+}
+
+type recordingNotifier struct {
+	names         []string
+	notifications []entities.Notification
+}
+
+func (n *recordingNotifier) Notify(name string, notification entities.Notification) error {
+	n.names = append(n.names, name)
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+func TestForwardPNRRequest(t *testing.T) {
+	testCases := map[string]struct {
+		OnBehalfOfRequester     bool
+		AllowOnBehalfForwarding bool
+		ExpectedRequester       string
+		ExpectedAgreement       string
+		ExpectedAllowOnBehalf   bool
+	}{
+		"fromResponder": {
+			OnBehalfOfRequester: false,
+			ExpectedRequester:   testPIUId,
+			ExpectedAgreement:   "agreement13",
+		},
+		"fromResponderAllowOnBehalf": {
+			OnBehalfOfRequester:     false,
+			AllowOnBehalfForwarding: true,
+			ExpectedRequester:       testPIUId,
+			ExpectedAgreement:       "agreement13",
+			ExpectedAllowOnBehalf:   true,
+		},
+		"onBehalfOfRequester": {
+			OnBehalfOfRequester:   true,
+			ExpectedRequester:     testdata.PIUs[1].Id,
+			ExpectedAgreement:     "agreement23",
+			ExpectedAllowOnBehalf: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			r := inmemory.NewInMemoryRepository()
+			n := &recordingNotifier{}
+			u := usecase.NewRMTUsecase(testPIUId, r, usecase.WithNotifier(n), usecase.WithClock(newTestingClock()))
+			setupPIUs(r)
+
+			originalRequest := entities.PNR{
+				Id:                      "someId",
+				RequestingPIU:           testdata.PIUs[1].Id,
+				RespondingPIU:           testPIUId,
+				RequestTimestamp:        testdata.EarliestTimestamp,
+				State:                   entities.RequestStatePendingConfirmed,
+				RequestData:             "\"requestData\"",
+				PNRHashes:               []string{},
+				AllowOnBehalfForwarding: true,
+				Purpose:                 "terrorism",
+			}
+
+			r.InsertPNR(originalRequest.Id, originalRequest)
+			r.InsertGCMetadata(originalRequest, entities.GCMetadata{Id: originalRequest.Id, CreationTimestamp: originalRequest.RequestTimestamp})
+
+			var output entities.ForwardPNRRequestOutput
+
+			err := u.ForwardPNRRequest(context.TODO(), entities.ForwardPNRRequestInput{
+				Id:                      originalRequest.Id,
+				NewId:                   "newId",
+				RespondingPIU:           testdata.PIUs[2].Id,
+				ForwardTimestamp:        testdata.MiddleTimestamp,
+				OnBehalfOfRequester:     testCase.OnBehalfOfRequester,
+				AllowOnBehalfForwarding: testCase.AllowOnBehalfForwarding,
+			}, &output)
+			assert.NoError(err)
+			assert.Equal("newId", output.Id)
+
+			original, _ := r.GetPNR(originalRequest.Id)
+			assert.Equal(entities.RequestStateForwarded, original.State)
+			assert.Empty(original.RequestData)
+
+			forwarded, err := r.GetPNR("newId")
+			assert.NoError(err)
+			assert.Equal(testCase.ExpectedRequester, forwarded.RequestingPIU)
+			assert.Equal(testdata.PIUs[2].Id, forwarded.RespondingPIU)
+			assert.Equal(entities.RequestStatePending, forwarded.State)
+			assert.Equal(originalRequest.RequestData, forwarded.RequestData)
+			assert.Equal(originalRequest.Id, forwarded.ParentId)
+			assert.Equal(testCase.ExpectedAgreement, forwarded.AgreementId)
+			assert.Equal(testCase.ExpectedAllowOnBehalf, forwarded.AllowOnBehalfForwarding)
+
+			gc, err := r.GetGCMetadata("newId")
+			assert.NoError(err)
+			assert.Equal(testdata.MiddleTimestamp, gc.CreationTimestamp)
+
+			assert.Equal([]string{entities.PNRForwardedEventName}, n.names)
+			assert.Equal([]entities.Notification{{
+				Recipients: []string{testdata.PIUs[1].Id, testdata.PIUs[2].Id},
+				Id:         "newId",
+				ParentId:   originalRequest.Id,
+				State:      entities.RequestStatePending,
+				Sender:     testPIUId,
+			}}, n.notifications)
+		})
+	}
+}
+
+func TestForwardPNRRequestInvalid(t *testing.T) {
+	testCases := map[string]struct {
+		RequestingPIU           string
+		RespondingPIU           string
+		State                   entities.RequestState
+		AllowOnBehalfForwarding bool
+		Input                   entities.ForwardPNRRequestInput
+	}{
+		"requester": {
+			RequestingPIU: testPIUId,
+			RespondingPIU: testdata.PIUs[1].Id,
+			State:         entities.RequestStatePendingConfirmed,
+			Input:         entities.ForwardPNRRequestInput{Id: "someId", NewId: "newId", RespondingPIU: testdata.PIUs[2].Id},
+		},
+		"pending": {
+			RequestingPIU: testdata.PIUs[1].Id,
+			RespondingPIU: testPIUId,
+			State:         entities.RequestStatePending,
+			Input:         entities.ForwardPNRRequestInput{Id: "someId", NewId: "newId", RespondingPIU: testdata.PIUs[2].Id},
+		},
+		"toRequester": {
+			RequestingPIU: testdata.PIUs[1].Id,
+			RespondingPIU: testPIUId,
+			State:         entities.RequestStatePendingConfirmed,
+			Input:         entities.ForwardPNRRequestInput{Id: "someId", NewId: "newId", RespondingPIU: testdata.PIUs[1].Id},
+		},
+		"unknownPIU": {
+			RequestingPIU: testdata.PIUs[1].Id,
+			RespondingPIU: testPIUId,
+			State:         entities.RequestStatePendingConfirmed,
+			Input:         entities.ForwardPNRRequestInput{Id: "someId", NewId: "newId", RespondingPIU: "unknown"},
+		},
+		"onBehalfNotAllowed": {
+			RequestingPIU: testdata.PIUs[1].Id,
+			RespondingPIU: testPIUId,
+			State:         entities.RequestStatePendingConfirmed,
+			Input:         entities.ForwardPNRRequestInput{Id: "someId", NewId: "newId", RespondingPIU: testdata.PIUs[2].Id, OnBehalfOfRequester: true},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			r, u := newTestingUsecase()
+			setupPIUs(r)
+
+			originalRequest := entities.PNR{
+				Id:                      "someId",
+				RequestingPIU:           testCase.RequestingPIU,
+				RespondingPIU:           testCase.RespondingPIU,
+				RequestTimestamp:        testdata.EarliestTimestamp,
+				State:                   testCase.State,
+				RequestData:             "\"requestData\"",
+				PNRHashes:               []string{},
+				AllowOnBehalfForwarding: testCase.AllowOnBehalfForwarding,
+			}
+
+			r.InsertPNR(originalRequest.Id, originalRequest)
+
+			var output entities.ForwardPNRRequestOutput
+
+			err := u.ForwardPNRRequest(context.TODO(), testCase.Input, &output)
+			assert.Error(err)
+
+			actual, _ := r.GetPNR(originalRequest.Id)
+			assert.Equal(originalRequest, actual)
+
+			exists, _ := r.PNRExists("newId")
+			assert.False(exists)
+		})
+	}
+}
+
+func TestAgreementLifecycle(t *testing.T) {
+	assert := assert.New(t)
+
+	r := inmemory.NewInMemoryRepository()
+	for _, piu := range testdata.PIUs {
+		r.InsertPIU(piu.Id, piu)
+	}
+
+	proposer := usecase.NewRMTUsecase(testPIUId, r)
+	counterparty := usecase.NewRMTUsecase(testdata.PIUs[1].Id, r)
+
+	var requestData json.RawMessage = lo.Must(json.Marshal("test request data"))
+
+	newRequest := func(id string, purpose string) error {
+		return proposer.NewPNRRequest(context.TODO(), entities.NewPNRRequestInput{
+			Id:               id,
+			RespondingPIU:    testdata.PIUs[1].Id,
+			RequestTimestamp: testdata.MiddleTimestamp,
+			RequestData:      &requestData,
+			Purpose:          purpose,
+		}, &entities.NewPNRRequestOutput{})
+	}
+
+	var proposeOutput entities.ProposeAgreementOutput
+
+	err := proposer.ProposeAgreement(context.TODO(), entities.ProposeAgreementInput{
+		Id:                "agreement",
+		CounterpartyPIU:   testdata.PIUs[1].Id,
+		Purposes:          []string{"terrorism"},
+		ValidFrom:         testdata.EarliestTimestamp,
+		ProposalTimestamp: testdata.EarliestTimestamp,
+	}, &proposeOutput)
+	assert.NoError(err)
+	assert.Equal("agreement", proposeOutput.Id)
+
+	assert.Error(newRequest("beforeSigning", "terrorism"))
+
+	err = proposer.SignAgreement(context.TODO(), entities.SignAgreementInput{Id: "agreement"}, &entities.SignAgreementOutput{})
+	assert.Error(err)
+
+	err = usecase.NewRMTUsecase(testdata.PIUs[2].Id, r).SignAgreement(context.TODO(), entities.SignAgreementInput{Id: "agreement"}, &entities.SignAgreementOutput{})
+	assert.Error(err)
+
+	err = counterparty.SignAgreement(context.TODO(), entities.SignAgreementInput{Id: "agreement"}, &entities.SignAgreementOutput{})
+	assert.NoError(err)
+
+	agreement, _ := r.GetAgreement("agreement")
+	assert.Equal(entities.AgreementStateActive, agreement.State)
+	assert.Equal([]string{testPIUId, testdata.PIUs[1].Id}, agreement.SignedBy)
+
+	assert.NoError(newRequest("covered", "terrorism"))
+	assert.Error(newRequest("otherPurpose", "seriousCrime"))
+
+	var agreements []entities.Agreement
+
+	err = usecase.NewRMTUsecase(testdata.PIUs[2].Id, r).GetAgreements(context.TODO(), entities.GetAgreementsInput{}, &agreements)
+	assert.NoError(err)
+	assert.Empty(agreements)
+
+	err = counterparty.GetAgreements(context.TODO(), entities.GetAgreementsInput{}, &agreements)
+	assert.NoError(err)
+	assert.Equal([]entities.Agreement{agreement}, agreements)
+
+	err = counterparty.RevokeAgreement(context.TODO(), entities.RevokeAgreementInput{Id: "agreement"}, &entities.RevokeAgreementOutput{})
+	assert.NoError(err)
+
+	assert.Error(newRequest("afterRevocation", "terrorism"))
+}
+
+func TestNewPNRRequestNotCoveredByAgreement(t *testing.T) {
+	testCases := map[string]struct {
+		Agreement entities.Agreement
+		Purpose   string
+	}{
+		"purpose": {
+			Agreement: entities.Agreement{Purposes: []string{"seriousCrime"}},
+			Purpose:   "terrorism",
+		},
+		"notYetValid": {
+			Agreement: entities.Agreement{Purposes: []string{"terrorism"}, ValidFrom: testdata.LatestTimestamp},
+			Purpose:   "terrorism",
+		},
+		"expired": {
+			Agreement: entities.Agreement{Purposes: []string{"terrorism"}, ValidTo: testdata.EarliestTimestamp},
+			Purpose:   "terrorism",
+		},
+		"proposed": {
+			Agreement: entities.Agreement{Purposes: []string{"terrorism"}, State: entities.AgreementStateProposed},
+			Purpose:   "terrorism",
+		},
+		"volume": {
+			Agreement: entities.Agreement{Purposes: []string{"terrorism"}, MaxRequests: 1},
+			Purpose:   "terrorism",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			r, u := newTestingUsecase()
+			for _, piu := range testdata.PIUs {
+				r.InsertPIU(piu.Id, piu)
+			}
+
+			agreement := testCase.Agreement
+			agreement.Id = "agreement"
+			agreement.ProposingPIU = testPIUId
+			agreement.CounterpartyPIU = testdata.PIUs[1].Id
+			agreement.SignedBy = []string{agreement.ProposingPIU, agreement.CounterpartyPIU}
+			if agreement.State == "" {
+				agreement.State = entities.AgreementStateActive
+			}
+			r.InsertAgreement(agreement.Id, agreement)
+			r.PutAgreementUsage(entities.AgreementUsage{AgreementId: agreement.Id, Count: 1})
+
+			var requestData json.RawMessage = lo.Must(json.Marshal("test request data"))
+
+			err := u.NewPNRRequest(context.TODO(), entities.NewPNRRequestInput{
+				Id:               "someId",
+				RespondingPIU:    testdata.PIUs[1].Id,
+				RequestTimestamp: testdata.MiddleTimestamp,
+				RequestData:      &requestData,
+				Purpose:          testCase.Purpose,
+			}, &entities.NewPNRRequestOutput{})
+			assert.Error(err)
+
+			exists, _ := r.PNRExists("someId")
+			assert.False(exists)
+		})
+	}
+}
+
+func TestNewPNRRequestBackdated(t *testing.T) {
+	assert := assert.New(t)
+
+	r := inmemory.NewInMemoryRepository()
+	u := usecase.NewRMTUsecase(testPIUId, r, usecase.WithClock(&fixedClock{now: testdata.LatestTimestamp}))
+	for _, piu := range testdata.PIUs {
+		r.InsertPIU(piu.Id, piu)
+	}
+
+	r.InsertAgreement("agreement", entities.Agreement{
+		Id:              "agreement",
+		ProposingPIU:    testPIUId,
+		CounterpartyPIU: testdata.PIUs[1].Id,
+		Purposes:        []string{"terrorism"},
+		ValidFrom:       testdata.EarliestTimestamp,
+		ValidTo:         testdata.MiddleTimestamp,
+		State:           entities.AgreementStateActive,
+		SignedBy:        []string{testPIUId, testdata.PIUs[1].Id},
+	})
+
+	var requestData json.RawMessage = lo.Must(json.Marshal("test request data"))
+
+	err := u.NewPNRRequest(context.TODO(), entities.NewPNRRequestInput{
+		Id:               "someId",
+		RespondingPIU:    testdata.PIUs[1].Id,
+		RequestTimestamp: testdata.MiddleTimestamp,
+		RequestData:      &requestData,
+		Purpose:          "terrorism",
+	}, &entities.NewPNRRequestOutput{})
+	assert.ErrorIs(err, status.PermissionDenied)
+}
+
+func TestApprovePNRRequestAgreementVolume(t *testing.T) {
+	assert := assert.New(t)
+
+	r := inmemory.NewInMemoryRepository()
+	analyst := usecase.NewRMTUsecase(testPIUId, r, usecase.WithClientId("analyst"))
+	supervisor := usecase.NewRMTUsecase(testPIUId, r, usecase.WithClientId("supervisor"))
+	for _, piu := range testdata.PIUs {
+		r.InsertPIU(piu.Id, piu)
+	}
+	setupApproval(r)
+
+	agreement := testdata.Agreements[0]
+	agreement.MaxRequests = 1
+	r.InsertAgreement(agreement.Id, agreement)
+
+	var requestData json.RawMessage = lo.Must(json.Marshal("test request data"))
+
+	newRequest := func(id string) error {
+		return analyst.NewPNRRequest(context.TODO(), entities.NewPNRRequestInput{
+			Id:               id,
+			RespondingPIU:    testdata.PIUs[1].Id,
+			RequestTimestamp: testdata.MiddleTimestamp,
+			RequestData:      &requestData,
+			Purpose:          "terrorism",
+		}, &entities.NewPNRRequestOutput{})
+	}
+
+	assert.NoError(newRequest("first"))
+	assert.NoError(newRequest("second"))
+
+	usage, _ := r.GetAgreementUsage(agreement.Id)
+	assert.Zero(usage.Count)
+
+	err := supervisor.ApprovePNRRequest(context.TODO(), entities.ApprovePNRDraftInput{Id: "first"}, &entities.ApprovePNRDraftOutput{})
+	assert.NoError(err)
+
+	usage, _ = r.GetAgreementUsage(agreement.Id)
+	assert.Equal(1, usage.Count)
+
+	err = supervisor.ApprovePNRRequest(context.TODO(), entities.ApprovePNRDraftInput{Id: "second"}, &entities.ApprovePNRDraftOutput{})
+	assert.ErrorIs(err, status.PermissionDenied)
+
+	assert.ErrorIs(newRequest("third"), status.PermissionDenied)
+}
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c *fixedClock) Now() (time.Time, error) {
+	return c.now, nil
+}
+
+type failingClock struct{}
+
+func (failingClock) Now() (time.Time, error) {
+	return time.Time{}, errors.New("timestamp not available")
+}
+
+func TestNewPNRRequestClockFailure(t *testing.T) {
+	assert := assert.New(t)
+
+	r := inmemory.NewInMemoryRepository()
+	u := usecase.NewRMTUsecase(testPIUId, r, usecase.WithClock(failingClock{}))
+	setupPIUs(r)
+
+	var requestData json.RawMessage = lo.Must(json.Marshal("test request data"))
+
+	err := u.NewPNRRequest(context.TODO(), entities.NewPNRRequestInput{
+		Id:               "someId",
+		RespondingPIU:    testdata.PIUs[1].Id,
+		RequestTimestamp: testdata.MiddleTimestamp,
+		RequestData:      &requestData,
+		Purpose:          "terrorism",
+	}, &entities.NewPNRRequestOutput{})
+	assert.ErrorIs(err, status.Internal)
+
+	exists, _ := r.PNRExists("someId")
+	assert.False(exists)
+}
+
+func TestNewPNRRequestQuota(t *testing.T) {
+	assert := assert.New(t)
+
+	config := entities.DefaultConsortiumConfig()
+	config.Quota.PerPIU = 2
+	config.Quota.PerPair = 1
+
+	clock := &fixedClock{now: testdata.MiddleTimestamp}
+
+	r := inmemory.NewInMemoryRepository()
+	u := usecase.NewRMTUsecase(testPIUId, r, usecase.WithConfig(config), usecase.WithClock(clock))
+	setupPIUs(r)
+
+	var requestData json.RawMessage = lo.Must(json.Marshal("test request data"))
+
+	request := func(id string, respondingPIU string) error {
+		return u.NewPNRRequest(context.TODO(), entities.NewPNRRequestInput{
+			Id:               id,
+			RespondingPIU:    respondingPIU,
+			RequestTimestamp: testdata.MiddleTimestamp,
+			RequestData:      &requestData,
+			Purpose:          "terrorism",
+		}, &entities.NewPNRRequestOutput{})
+	}
+
+	assert.NoError(request("first", testdata.PIUs[1].Id))
+
+	err := request("second", testdata.PIUs[1].Id)
+	assert.ErrorIs(err, status.ResourceExhausted)
+
+	clock.now = clock.now.Add(time.Hour)
+	assert.NoError(request("third", testdata.PIUs[2].Id))
+
+	var quota entities.QuotaStatus
+	err = u.GetQuotaStatus(context.TODO(), entities.GetQuotaStatusInput{}, &quota)
+	assert.NoError(err)
+	assert.Equal(entities.QuotaStatus{
+		Window: 24 * time.Hour,
+		Total:  entities.QuotaUsage{Limited: true, Limit: 2, Used: 2, Remaining: 0},
+		Pairs: []entities.QuotaUsage{
+			{Counterpart: testdata.PIUs[1].Id, Limited: true, Limit: 1, Used: 1, Remaining: 0},
+			{Counterpart: testdata.PIUs[2].Id, Limited: true, Limit: 1, Used: 1, Remaining: 0},
+		},
+	}, quota)
+
+	clock.now = clock.now.Add(22 * time.Hour)
+	err = request("fourth", testdata.PIUs[1].Id)
+	assert.ErrorIs(err, status.ResourceExhausted)
+
+	clock.now = clock.now.Add(time.Hour)
+	assert.NoError(request("fifth", testdata.PIUs[1].Id))
+
+	for id, exists := range map[string]bool{"first": true, "second": false, "third": true, "fourth": false, "fifth": true} {
+		actual, _ := r.PNRExists(id)
+		assert.Equal(exists, actual, id)
+	}
+}
+
+func TestForwardPNRRequestQuota(t *testing.T) {
+	assert := assert.New(t)
+
+	config := entities.DefaultConsortiumConfig()
+	config.Quota.PerPair = 1
+
+	r := inmemory.NewInMemoryRepository()
+	u := usecase.NewRMTUsecase(testPIUId, r, usecase.WithConfig(config), usecase.WithClock(newTestingClock()))
+	setupPIUs(r)
+
+	for _, id := range []string{"first", "second"} {
+		request := entities.PNR{
+			Id:               id,
+			RequestingPIU:    testdata.PIUs[1].Id,
+			RespondingPIU:    testPIUId,
+			RequestTimestamp: testdata.EarliestTimestamp,
+			State:            entities.RequestStatePendingConfirmed,
+			RequestData:      "\"requestData\"",
+			PNRHashes:        []string{},
+			Purpose:          "terrorism",
+		}
+
+		r.InsertPNR(request.Id, request)
+		r.InsertGCMetadata(request, entities.GCMetadata{Id: request.Id, CreationTimestamp: request.RequestTimestamp})
+	}
+
+	forward := func(id string) error {
+		return u.ForwardPNRRequest(context.TODO(), entities.ForwardPNRRequestInput{
+			Id:               id,
+			NewId:            id + "Forwarded",
+			RespondingPIU:    testdata.PIUs[2].Id,
+			ForwardTimestamp: testdata.MiddleTimestamp,
+		}, &entities.ForwardPNRRequestOutput{})
+	}
+
+	assert.NoError(forward("first"))
+	assert.ErrorIs(forward("second"), status.ResourceExhausted)
+
+	// Forwarder becomes the requester, so the request counts against its quota.
+	counter, _ := r.GetQuotaCounter(testPIUId, testdata.PIUs[2].Id, testdata.MiddleTimestamp)
+	assert.Equal(1, counter.Count)
+
+	counter, _ = r.GetQuotaCounter(testdata.PIUs[1].Id, testdata.PIUs[2].Id, testdata.MiddleTimestamp)
+	assert.Equal(0, counter.Count)
+
+	actual, _ := r.GetPNR("second")
+	assert.Equal(entities.RequestStatePendingConfirmed, actual.State)
+}
+
+func TestGetQuotaStatusOverrides(t *testing.T) {
+	assert := assert.New(t)
+
+	config := entities.DefaultConsortiumConfig()
+	config.Quota.PerPair = 5
+	config.Quota.PIULimits = map[string]int{testPIUId: 10}
+	config.Quota.PairLimits = map[string]map[string]int{testPIUId: {testdata.PIUs[2].Id: 0}}
+
+	r := inmemory.NewInMemoryRepository()
+	u := usecase.NewRMTUsecase(testPIUId, r, usecase.WithConfig(config))
+	setupPIUs(r)
+
+	var quota entities.QuotaStatus
+	err := u.GetQuotaStatus(context.TODO(), entities.GetQuotaStatusInput{}, &quota)
+	assert.NoError(err)
+	assert.Equal(entities.QuotaUsage{Limited: true, Limit: 10, Remaining: 10}, quota.Total)
+	assert.Equal([]entities.QuotaUsage{
+		{Counterpart: testdata.PIUs[1].Id, Limited: true, Limit: 5, Remaining: 5},
+		{Counterpart: testdata.PIUs[2].Id},
+	}, quota.Pairs)
+}
+
+// setupApproval requires approval of outgoing PNR requests and responses of
+// the testing PIU.
+func setupApproval(r repository.Repository) {
+	piu, _ := r.GetPIU(testPIUId)
+	piu.RequestApproval = true
+	piu.ResponseApproval = true
+	r.UpdatePIU(piu.Id, piu)
+}
+
+func TestNewPNRRequestApproval(t *testing.T) {
+	assert := assert.New(t)
+
+	r := inmemory.NewInMemoryRepository()
+	analyst := usecase.NewRMTUsecase(testPIUId, r, usecase.WithClientId("analyst"))
+	supervisor := usecase.NewRMTUsecase(testPIUId, r, usecase.WithClientId("supervisor"))
+	setupPIUs(r)
+	setupApproval(r)
+
+	var requestData json.RawMessage = lo.Must(json.Marshal("test request data"))
+
+	err := analyst.NewPNRRequest(context.TODO(), entities.NewPNRRequestInput{
+		Id:               "someId",
+		RespondingPIU:    testdata.PIUs[1].Id,
+		RequestTimestamp: testdata.MiddleTimestamp,
+		RequestData:      &requestData,
+		Purpose:          "terrorism",
+	}, &entities.NewPNRRequestOutput{})
+	assert.NoError(err)
+
+	draft, _ := r.GetPNR("someId")
+	assert.Equal(entities.RequestStateDraft, draft.State)
+	assert.Equal("analyst", draft.DraftedBy)
+
+	exists, _ := r.GCMetadataExists("someId")
+	assert.False(exists)
+
+	err = analyst.ApprovePNRRequest(context.TODO(), entities.ApprovePNRDraftInput{Id: "someId"}, &entities.ApprovePNRDraftOutput{})
+	assert.ErrorIs(err, status.PermissionDenied)
+
+	err = supervisor.ConfirmPNR(context.TODO(), entities.ConfirmPNRInput{Id: "someId"}, &entities.ConfirmPNROutput{})
+	assert.Error(err)
+
+	err = supervisor.ApprovePNRRequest(context.TODO(), entities.ApprovePNRDraftInput{Id: "someId"}, &entities.ApprovePNRDraftOutput{})
+	assert.NoError(err)
+
+	expected := draft
+	expected.State = entities.RequestStatePending
+	expected.DraftedBy = ""
+
+	actual, _ := r.GetPNR("someId")
+	assert.Equal(expected, actual)
+
+	exists, _ = r.GCMetadataExists("someId")
+	assert.True(exists)
+
+	err = supervisor.ApprovePNRRequest(context.TODO(), entities.ApprovePNRDraftInput{Id: "someId"}, &entities.ApprovePNRDraftOutput{})
+	assert.ErrorIs(err, status.FailedPrecondition)
+}
+
+func TestSubmitPNRResponseApproval(t *testing.T) {
+	assert := assert.New(t)
+
+	r := inmemory.NewInMemoryRepository()
+	analyst := usecase.NewRMTUsecase(testPIUId, r, usecase.WithClientId("analyst"))
+	supervisor := usecase.NewRMTUsecase(testPIUId, r, usecase.WithClientId("supervisor"))
+	setupPIUs(r)
+	setupApproval(r)
+
+	request := entities.PNR{
+		Id:               "someId",
+		RequestingPIU:    testdata.PIUs[1].Id,
+		RespondingPIU:    testPIUId,
+		RequestTimestamp: testdata.MiddleTimestamp,
+		State:            entities.RequestStatePendingConfirmed,
+		RequestData:      "test request data",
+		PNRHashes:        []string{},
+	}
+
+	r.InsertPNR(request.Id, request)
+	r.InsertGCMetadata(request, entities.GCMetadata{Id: request.Id, CreationTimestamp: request.RequestTimestamp})
+
+	var responseData json.RawMessage = lo.Must(json.Marshal("test response data"))
+
+	input := entities.SubmitPNRResponseInput{
+		Id:                request.Id,
+		ResponseTimestamp: testdata.LatestTimestamp,
+		ResponseData:      &responseData,
+	}
+
+	err := analyst.SubmitPNRResponseAck(context.TODO(), input, &entities.SubmitPNRResponseOutput{})
+	assert.NoError(err)
+
+	expected := request
+	expected.ResponseTimestamp = input.ResponseTimestamp
+	expected.ResponseData = string(responseData)
+	expected.State = entities.RequestStateAckDraft
+	expected.DraftedBy = "analyst"
+
+	actual, _ := r.GetPNR(request.Id)
+	assert.Equal(expected, actual)
+
+	approval := entities.ApprovePNRDraftInput{Id: request.Id, ResponseDigest: hex.EncodeToString(responseDigest(responseData))}
+
+	err = analyst.ApprovePNRResponse(context.TODO(), approval, &entities.ApprovePNRDraftOutput{})
+	assert.ErrorIs(err, status.PermissionDenied)
+
+	err = supervisor.ApprovePNRResponse(context.TODO(), entities.ApprovePNRDraftInput{Id: request.Id}, &entities.ApprovePNRDraftOutput{})
+	assert.ErrorIs(err, status.InvalidArgument)
+
+	err = supervisor.ApprovePNRResponse(context.TODO(), approval, &entities.ApprovePNRDraftOutput{})
+	assert.NoError(err)
+
+	expected.State = entities.RequestStateAck
+	expected.DraftedBy = ""
+
+	actual, _ = r.GetPNR(request.Id)
+	assert.Equal(expected, actual)
+}
+
+func TestSubmitPNRResponseChangedDraft(t *testing.T) {
+	assert := assert.New(t)
+
+	r := inmemory.NewInMemoryRepository()
+	analyst := usecase.NewRMTUsecase(testPIUId, r, usecase.WithClientId("analyst"))
+	officer := usecase.NewRMTUsecase(testPIUId, r, usecase.WithClientId("officer"))
+	supervisor := usecase.NewRMTUsecase(testPIUId, r, usecase.WithClientId("supervisor"))
+	setupPIUs(r)
+	setupApproval(r)
+
+	request := entities.PNR{
+		Id:               "someId",
+		RequestingPIU:    testdata.PIUs[1].Id,
+		RespondingPIU:    testPIUId,
+		RequestTimestamp: testdata.MiddleTimestamp,
+		State:            entities.RequestStatePendingConfirmed,
+		RequestData:      "test request data",
+		PNRHashes:        []string{},
+	}
+
+	r.InsertPNR(request.Id, request)
+	r.InsertGCMetadata(request, entities.GCMetadata{Id: request.Id, CreationTimestamp: request.RequestTimestamp})
+
+	submit := func(u usecase.PNRExchangeUsecase, data string) error {
+		var responseData json.RawMessage = lo.Must(json.Marshal(data))
+
+		return u.SubmitPNRResponseAck(context.TODO(), entities.SubmitPNRResponseInput{
+			Id:                request.Id,
+			ResponseTimestamp: testdata.LatestTimestamp,
+			ResponseData:      &responseData,
+		}, &entities.SubmitPNRResponseOutput{})
+	}
+
+	assert.NoError(submit(analyst, "reviewed response data"))
+
+	reviewed := hex.EncodeToString(responseDigest(lo.Must(json.Marshal("reviewed response data"))))
+
+	assert.ErrorIs(submit(officer, "other response data"), status.PermissionDenied)
+	assert.ErrorIs(submit(supervisor, "other response data"), status.PermissionDenied)
+
+	assert.NoError(submit(analyst, "changed response data"))
+
+	actual, _ := r.GetPNR(request.Id)
+	assert.Equal(entities.RequestStateAckDraft, actual.State)
+	assert.Equal("analyst", actual.DraftedBy)
+
+	err := supervisor.ApprovePNRResponse(context.TODO(), entities.ApprovePNRDraftInput{Id: request.Id, ResponseDigest: reviewed}, &entities.ApprovePNRDraftOutput{})
+	assert.ErrorIs(err, status.FailedPrecondition)
+
+	actual, _ = r.GetPNR(request.Id)
+	assert.Equal(entities.RequestStateAckDraft, actual.State)
+
+	changed := hex.EncodeToString(responseDigest(lo.Must(json.Marshal("changed response data"))))
+
+	err = supervisor.ApprovePNRResponse(context.TODO(), entities.ApprovePNRDraftInput{Id: request.Id, ResponseDigest: changed}, &entities.ApprovePNRDraftOutput{})
+	assert.NoError(err)
+
+	actual, _ = r.GetPNR(request.Id)
+	assert.Equal(entities.RequestStateAck, actual.State)
+	assert.Equal(string(lo.Must(json.Marshal("changed response data"))), actual.ResponseData)
+}
+
+func TestSubmitPNRResponseNackWithoutApproval(t *testing.T) {
+	assert := assert.New(t)
+
+	r := inmemory.NewInMemoryRepository()
+	u := usecase.NewRMTUsecase(testPIUId, r, usecase.WithClientId("analyst"))
+	setupPIUs(r)
+	setupApproval(r)
+
+	request := entities.PNR{
+		Id:            "someId",
+		RequestingPIU: testdata.PIUs[1].Id,
+		RespondingPIU: testPIUId,
+		State:         entities.RequestStatePendingConfirmed,
+		PNRHashes:     []string{},
+	}
+
+	r.InsertPNR(request.Id, request)
+	r.InsertGCMetadata(request, entities.GCMetadata{Id: request.Id})
+
+	err := u.SubmitPNRResponseNack(context.TODO(), entities.SubmitPNRResponseInput{Id: request.Id}, &entities.SubmitPNRResponseOutput{})
+	assert.NoError(err)
+
+	actual, _ := r.GetPNR(request.Id)
+	assert.Equal(entities.RequestStateNack, actual.State)
+}
+
+func TestChangePIUStatusAdminMSP(t *testing.T) {
+	assert := assert.New(t)
+
+	r := inmemory.NewInMemoryRepository()
+	setupPIUs(r)
+
+	config := entities.DefaultConsortiumConfig()
+	config.AdminMSPs = []string{"adminMSP"}
+
+	unit := usecase.NewRMTUsecase("adminMSP_unit", r, usecase.WithConfig(config), usecase.WithMSPId("adminMSP"))
+	impostor := usecase.NewRMTUsecase("adminMSP", r, usecase.WithConfig(config), usecase.WithMSPId("otherMSP"))
+
+	input := entities.ChangePIUStatusInput{Id: testdata.PIUs[1].Id}
+
+	err := impostor.SuspendPIU(context.TODO(), input, &entities.ChangePIUStatusOutput{})
+	assert.ErrorIs(err, status.PermissionDenied)
+
+	err = unit.SuspendPIU(context.TODO(), input, &entities.ChangePIUStatusOutput{})
+	assert.NoError(err)
+}
+
+func configInput(config string, expectedVersion int) entities.UpdateConfigInput {
+	document := json.RawMessage(config)
+	return entities.UpdateConfigInput{Config: &document, ExpectedVersion: expectedVersion}
+}
+
+func TestInitLedger(t *testing.T) {
+	assert := assert.New(t)
+
+	r := inmemory.NewInMemoryRepository()
+	u := newTestingAdminUsecase(r)
+
+	var output entities.UpdateConfigOutput
+
+	err := u.UpdateConfig(context.TODO(), configInput(`{"adminMSPs": ["adminMSP"]}`, 0), &output)
+	assert.ErrorIs(err, status.FailedPrecondition)
+
+	err = u.InitLedger(context.TODO(), configInput(`{"adminMSPs": ["adminMSP"], "payloadProfiles": ["PNRGOV/21.1"], "retention": 3600000000000}`, 0), &output)
+	assert.NoError(err)
+	assert.Equal(1, output.Version)
+
+	err = u.InitLedger(context.TODO(), configInput(`{"adminMSPs": ["adminMSP"]}`, 0), &output)
+	assert.ErrorIs(err, status.FailedPrecondition)
+
+	var config entities.ConsortiumConfig
+
+	err = u.GetConfig(context.TODO(), entities.GetConfigInput{}, &config)
+	assert.NoError(err)
+	assert.Equal(1, config.Version)
+	assert.Equal([]string{"PNRGOV/21.1"}, config.PayloadProfiles)
+	assert.Equal(time.Hour, config.Retention)
+	assert.Equal(entities.DefaultConsortiumConfig().PriorityDeadlines, config.PriorityDeadlines)
+}
+
+func TestInitLedgerNotAdmin(t *testing.T) {
+	_, u := newTestingUsecase()
+
+	err := u.InitLedger(context.TODO(), configInput(`{"adminMSPs": ["adminMSP"]}`, 0), &entities.UpdateConfigOutput{})
+	assert.ErrorIs(t, err, status.PermissionDenied)
+}
+
+func TestUpdateConfig(t *testing.T) {
+	testCases := map[string]struct {
+		Config          string
+		ExpectedVersion int
+		Status          status.Code
+	}{
+		"valid":            {Config: `{"adminMSPs": ["adminMSP", "otherMSP"]}`, Status: status.OK},
+		"expectedVersion":  {Config: `{"adminMSPs": ["adminMSP"]}`, ExpectedVersion: 1, Status: status.OK},
+		"staleVersion":     {Config: `{"adminMSPs": ["adminMSP"]}`, ExpectedVersion: 2, Status: status.FailedPrecondition},
+		"invalidJSON":      {Config: `{"adminMSPs": `, Status: status.InvalidArgument},
+		"missingAdmins":    {Config: `{}`, Status: status.InvalidArgument},
+		"unknownProperty":  {Config: `{"adminMSPs": ["adminMSP"], "other": 1}`, Status: status.InvalidArgument},
+		"negativeDeadline": {Config: `{"adminMSPs": ["adminMSP"], "priorityDeadlines": {"Urgent": -1}}`, Status: status.InvalidArgument},
+		"removedSelf":      {Config: `{"adminMSPs": ["otherMSP"]}`, Status: status.InvalidArgument},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			r := inmemory.NewInMemoryRepository()
+			u := newTestingAdminUsecase(r)
+
+			err := u.InitLedger(context.TODO(), configInput(`{"adminMSPs": ["adminMSP"]}`, 0), &entities.UpdateConfigOutput{})
+			assert.NoError(err)
+
+			var output entities.UpdateConfigOutput
+
+			err = u.UpdateConfig(context.TODO(), configInput(testCase.Config, testCase.ExpectedVersion), &output)
+
+			config, _ := r.GetConfig()
+
+			if testCase.Status == status.OK {
+				assert.NoError(err)
+				assert.Equal(2, output.Version)
+				assert.Equal(2, config.Version)
+			} else {
+				assert.ErrorIs(err, testCase.Status)
+				assert.Equal(1, config.Version)
+			}
+		})
+	}
+}
+
+// TestUpdateConfigDefault checks that the schema covers every field of the
+// configuration, so that the current configuration can be stored again.
+func TestUpdateConfigDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	r := inmemory.NewInMemoryRepository()
+	u := newTestingAdminUsecase(r)
+
+	config := entities.DefaultConsortiumConfig()
+	config.AdminMSPs = []string{"adminMSP"}
+	config.AtRestEncryption = true
+
+	err := u.InitLedger(context.TODO(), configInput(string(lo.Must(json.Marshal(config))), 0), &entities.UpdateConfigOutput{})
+	assert.NoError(err)
+
+	stored, _ := r.GetConfig()
+	assert.True(stored.AtRestEncryption)
+}
+
+func TestNewPNRRequestConsortiumPayloadProfile(t *testing.T) {
+	assert := assert.New(t)
+
+	r := inmemory.NewInMemoryRepository()
+	setupPIUs(r)
+
+	config := entities.DefaultConsortiumConfig()
+	config.PayloadProfiles = []string{"PNRGOV/21.1"}
+
+	u := usecase.NewRMTUsecase(testPIUId, r, usecase.WithConfig(config))
+
+	var requestData json.RawMessage = lo.Must(json.Marshal("test request data"))
+
+	err := u.NewPNRRequest(context.TODO(), entities.NewPNRRequestInput{
+		Id:               "someId",
+		RespondingPIU:    testdata.PIUs[1].Id,
+		RequestTimestamp: testdata.MiddleTimestamp,
+		RequestData:      &requestData,
+		PayloadProfile:   "PNRGOV/13.1",
+		Purpose:          "terrorism",
+	}, &entities.NewPNRRequestOutput{})
+	assert.ErrorIs(err, status.InvalidArgument)
+}
+
+func TestExportPNRMetadata(t *testing.T) {
+	testCases := map[string]struct {
+		Format entities.ExportFormat
+		Filter entities.PNRFilter
+		Lines  int
+	}{
+		"jsonl":  {Format: "", Lines: len(testdata.PNRs)},
+		"csv":    {Format: entities.ExportFormatCSV, Lines: len(testdata.PNRs) + 1},
+		"sorted": {Format: entities.ExportFormatJSONLines, Filter: entities.PNRFilter{SortBy: entities.PNRSortByRequestTimestamp}, Lines: len(testdata.PNRs)},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			r, u := newTestingUsecase()
+
+			for _, pnr := range testdata.PNRs {
+				r.InsertPNR(pnr.Id, pnr)
+			}
+
+			input := entities.ExportPNRMetadataInput{Format: testCase.Format, Filter: testCase.Filter, PageSize: 5}
+
+			var export strings.Builder
+			var output entities.ExportPNRMetadataOutput
+			var digests []string
+			pages := 0
+
+			for {
+				err := u.ExportPNRMetadata(context.TODO(), input, &output)
+				assert.NoError(err)
+
+				export.WriteString(output.Data)
+				digests = append(digests, output.Manifest.SHA256)
+				pages++
+
+				if output.Bookmark == "" || pages > len(testdata.PNRs) {
+					break
+				}
+
+				input.Bookmark = output.Bookmark
+			}
+
+			sum := sha256.Sum256([]byte(export.String()))
+
+			assert.Equal((len(testdata.PNRs)+4)/5, pages)
+			assert.True(output.Manifest.Complete)
+			assert.Equal(len(testdata.PNRs), output.Manifest.TotalCount)
+			assert.Equal(len(testdata.PNRs), output.Manifest.Offset+output.Manifest.RecordCount)
+			assert.Equal(hex.EncodeToString(sum[:]), output.Manifest.SHA256)
+			assert.Equal([]string{output.Manifest.SHA256}, lo.Uniq(digests))
+			assert.Equal(testCase.Lines, strings.Count(export.String(), "\n"))
+			assert.NotContains(export.String(), "requestData")
+		})
+	}
+}
+
+func TestExportPNRMetadataChanged(t *testing.T) {
+	assert := assert.New(t)
+
+	r := inmemory.NewInMemoryRepository()
+	first := usecase.NewRMTUsecase(testPIUId, r, usecase.WithClock(newTestingClock()), usecase.WithTxId("firstTx"))
+	next := usecase.NewRMTUsecase(testPIUId, r, usecase.WithClock(newTestingClock()), usecase.WithTxId("nextTx"))
+
+	for _, pnr := range testdata.PNRs {
+		r.InsertPNR(pnr.Id, pnr)
+	}
+
+	input := entities.ExportPNRMetadataInput{PageSize: 1}
+
+	var output entities.ExportPNRMetadataOutput
+
+	err := first.ExportPNRMetadata(context.TODO(), input, &output)
+	assert.NoError(err)
+	assert.Equal("firstTx", output.Manifest.TxId)
+
+	input.Bookmark = output.Bookmark
+
+	err = next.ExportPNRMetadata(context.TODO(), input, &output)
+	assert.NoError(err)
+	assert.Equal("firstTx", output.Manifest.TxId)
+
+	forged := input
+	forged.Bookmark = base64.StdEncoding.EncodeToString(lo.Must(json.Marshal(map[string]any{
+		"offset": 1,
+		"txId":   "firstTx",
+		"sha256": strings.Repeat("00", 32),
+	})))
+
+	err = next.ExportPNRMetadata(context.TODO(), forged, &output)
+	assert.ErrorIs(err, status.FailedPrecondition)
+
+	pnr := testdata.PNRs[0]
+	pnr.Id = "addedId"
+	r.InsertPNR(pnr.Id, pnr)
+
+	err = next.ExportPNRMetadata(context.TODO(), input, &output)
+	assert.ErrorIs(err, status.FailedPrecondition)
+}
+
+func TestExportPNRMetadataInvalid(t *testing.T) {
+	testCases := map[string]entities.ExportPNRMetadataInput{
+		"format":   {Format: "xml"},
+		"bookmark": {Bookmark: "invalid"},
+		"sortBy":   {Filter: entities.PNRFilter{SortBy: "id"}},
+	}
+
+	for name, input := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, u := newTestingUsecase()
+
+			err := u.ExportPNRMetadata(context.TODO(), input, &entities.ExportPNRMetadataOutput{})
+			assert.ErrorIs(t, err, status.InvalidArgument)
+		})
+	}
+}
+
+func TestRestorePrivateData(t *testing.T) {
+	publicKey, privateKey := lo.Must2(ed25519.GenerateKey(rand.Reader))
+	otherKey := lo.Must(ecdsa.GenerateKey(elliptic.P256(), rand.Reader))
+
+	testCases := map[string]struct {
+		PIU        string
+		SigningKey string
+		Sign       func(digest []byte) []byte
+		Status     status.Code
+	}{
+		"valid": {
+			PIU:        testPIUId,
+			SigningKey: publicKeyPEM(publicKey),
+			Sign:       func(digest []byte) []byte { return ed25519.Sign(privateKey, digest) },
+			Status:     status.OK,
+		},
+		"otherKey": {
+			PIU:        testPIUId,
+			SigningKey: publicKeyPEM(publicKey),
+			Sign:       func(digest []byte) []byte { return lo.Must(ecdsa.SignASN1(rand.Reader, otherKey, digest)) },
+			Status:     status.PermissionDenied,
+		},
+		"otherPIU": {
+			PIU:        testdata.PIUs[1].Id,
+			SigningKey: publicKeyPEM(publicKey),
+			Sign:       func(digest []byte) []byte { return ed25519.Sign(privateKey, digest) },
+			Status:     status.PermissionDenied,
+		},
+		"missingSigningKey": {
+			PIU:    testPIUId,
+			Sign:   func(digest []byte) []byte { return ed25519.Sign(privateKey, digest) },
+			Status: status.FailedPrecondition,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			r, u := newTestingUsecase()
+			setupPIUs(r)
+
+			piu, _ := r.GetPIU(testPIUId)
+			piu.SigningKey = testCase.SigningKey
+			r.UpdatePIU(piu.Id, piu)
+
+			var archive entities.PrivateDataArchive
+
+			err := u.BackupPrivateData(context.TODO(), entities.BackupPrivateDataInput{}, &archive)
+			assert.NoError(err)
+
+			archive.PIU = testCase.PIU
+			archive.Signature = testCase.Sign(lo.Must(hex.DecodeString(archive.SHA256)))
+
+			data := json.RawMessage(lo.Must(json.Marshal(archive)))
+
+			err = u.RestorePrivateData(context.TODO(), entities.RestorePrivateDataInput{Archive: &data}, &entities.RestorePrivateDataOutput{})
+
+			if testCase.Status == status.OK {
+				assert.NoError(err)
+			} else {
+				assert.ErrorIs(err, testCase.Status)
+			}
+		})
+	}
+}
+
+func TestReconcilePrivateDataInvalid(t *testing.T) {
+	testCases := map[string]struct {
+		PIU    string
+		Status status.Code
+	}{
+		"self":    {PIU: testPIUId, Status: status.InvalidArgument},
+		"unknown": {PIU: "unknownPIU", Status: status.NotFound},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r, u := newTestingUsecase()
+			setupPIUs(r)
+
+			err := u.ReconcilePrivateData(context.TODO(), entities.ReconcilePrivateDataInput{PIU: testCase.PIU}, &entities.RestorePrivateDataOutput{})
+			assert.ErrorIs(t, err, testCase.Status)
+		})
+	}
+}
+
+func TestVerifyPNRConsistency(t *testing.T) {
+	assert := assert.New(t)
+
+	r, u := newTestingUsecase()
+	setupPIUs(r)
+
+	pnr := testdata.PNRs[0]
+	unrelated := testdata.PNRs[0]
+	unrelated.Id = "unrelated"
+	unrelated.RequestingPIU = testdata.PIUs[1].Id
+	unrelated.RespondingPIU = testdata.PIUs[2].Id
+
+	r.InsertPNR(pnr.Id, pnr)
+	r.InsertPNR(unrelated.Id, unrelated)
+
+	var output entities.PNRConsistency
+
+	err := u.VerifyPNRConsistency(context.TODO(), entities.VerifyPNRConsistencyInput{Id: pnr.Id}, &output)
+	assert.NoError(err)
+	assert.True(output.Consistent)
+
+	err = u.VerifyPNRConsistency(context.TODO(), entities.VerifyPNRConsistencyInput{Id: unrelated.Id}, &output)
+	assert.ErrorIs(err, status.PermissionDenied)
+
+	err = u.VerifyPNRConsistency(context.TODO(), entities.VerifyPNRConsistencyInput{Id: "missing"}, &output)
+	assert.ErrorIs(err, status.InvalidArgument)
+
+	var all []entities.PNRConsistency
+
+	err = u.VerifyAllPNRConsistency(context.TODO(), entities.VerifyAllPNRConsistencyInput{}, &all)
+	assert.NoError(err)
+	assert.Empty(all)
+}
+
+// divergentRepository reports copies of every PNR request as differing.
+type divergentRepository struct {
+	repository.Repository
+}
+
+func (divergentRepository) ComparePNRCopies(pnr entities.PNR) ([]entities.KeyConsistency, error) {
+	return []entities.KeyConsistency{{Key: pnr.Id, Consistent: false}}, nil
+}
+
+func TestVerifyAllPNRConsistencyDrafts(t *testing.T) {
+	assert := assert.New(t)
+
+	r := inmemory.NewInMemoryRepository()
+	u := usecase.NewRMTUsecase(testPIUId, divergentRepository{r}, usecase.WithClock(newTestingClock()))
+	setupPIUs(r)
+
+	for _, state := range []entities.RequestState{entities.RequestStateDraft, entities.RequestStateAckDraft, entities.RequestStateAck} {
+		pnr := testdata.PNRs[0]
+		pnr.Id = string(state)
+		pnr.State = state
+		r.InsertPNR(pnr.Id, pnr)
+	}
+
+	var all []entities.PNRConsistency
+
+	err := u.VerifyAllPNRConsistency(context.TODO(), entities.VerifyAllPNRConsistencyInput{}, &all)
+	assert.NoError(err)
+	assert.Equal([]string{string(entities.RequestStateAck)}, lo.Map(all, func(c entities.PNRConsistency, _ int) string {
+		return c.Id
+	}))
+}
+
+// newTestingCertificate issues a certificate valid around the testing
+// timestamps, it is self-signed when parent is nil.
+func newTestingCertificate(name string, key crypto.Signer, parent *x509.Certificate, parentKey crypto.Signer) (*x509.Certificate, string) {
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             testdata.EarliestTimestamp.Add(-time.Hour),
+		NotAfter:              testdata.LatestTimestamp.Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der := lo.Must(x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey))
+
+	return lo.Must(x509.ParseCertificate(der)), string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func responseDigest(data json.RawMessage) []byte {
+	sum := sha256.Sum256(lo.Must(jcs.Transform(data)))
+	return sum[:]
+}
+
+func TestSubmitPNRResponseSignature(t *testing.T) {
+	caKey := lo.Must(ecdsa.GenerateKey(elliptic.P256(), rand.Reader))
+	ca, caPEM := newTestingCertificate("testing CA", caKey, nil, nil)
+
+	leafKey := lo.Must(ecdsa.GenerateKey(elliptic.P256(), rand.Reader))
+	_, leafPEM := newTestingCertificate("testing responder", leafKey, ca, caKey)
+
+	_, selfKey := lo.Must2(ed25519.GenerateKey(rand.Reader))
+	_, selfPEM := newTestingCertificate("self-signed responder", selfKey, nil, nil)
+
+	otherKey := lo.Must(ecdsa.GenerateKey(elliptic.P256(), rand.Reader))
+	other, _ := newTestingCertificate("other CA", otherKey, nil, nil)
+	_, otherLeafPEM := newTestingCertificate("other responder", leafKey, other, otherKey)
+
+	var responseData json.RawMessage = []byte(`{"pnrs": [{"id": "pnr", "name": "Novák"}]}`)
+	var otherData json.RawMessage = []byte(`{"pnrs": []}`)
+
+	signECDSA := func(data json.RawMessage) []byte {
+		return lo.Must(ecdsa.SignASN1(rand.Reader, leafKey, responseDigest(data)))
+	}
+
+	testCases := map[string]struct {
+		Registered  string
+		Certificate string
+		Signature   []byte
+		Signer      string
+		Status      status.Code
+	}{
+		"unsigned": {
+			Status: status.OK,
+		},
+		"issuedByCA": {
+			Registered:  caPEM,
+			Certificate: leafPEM,
+			Signature:   signECDSA(responseData),
+			Signer:      "CN=testing responder",
+			Status:      status.OK,
+		},
+		"selfSigned": {
+			Registered:  selfPEM,
+			Certificate: selfPEM,
+			Signature:   ed25519.Sign(selfKey, responseDigest(responseData)),
+			Signer:      "CN=self-signed responder",
+			Status:      status.OK,
+		},
+		"missingSignature": {
+			Registered: caPEM,
+			Status:     status.InvalidArgument,
+		},
+		"notRegistered": {
+			Certificate: leafPEM,
+			Signature:   signECDSA(responseData),
+			Status:      status.FailedPrecondition,
+		},
+		"untrustedCertificate": {
+			Registered:  caPEM,
+			Certificate: otherLeafPEM,
+			Signature:   signECDSA(responseData),
+			Status:      status.InvalidArgument,
+		},
+		"otherData": {
+			Registered:  caPEM,
+			Certificate: leafPEM,
+			Signature:   signECDSA(otherData),
+			Status:      status.InvalidArgument,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			r := inmemory.NewInMemoryRepository()
+			u := usecase.NewRMTUsecase(testPIUId, r, usecase.WithClock(&fixedClock{now: testdata.LatestTimestamp}))
+			setupPIUs(r)
+
+			piu, _ := r.GetPIU(testPIUId)
+			piu.SigningCertificate = testCase.Registered
+			r.UpdatePIU(piu.Id, piu)
+
+			request := entities.PNR{
+				Id:            "someId",
+				RequestingPIU: testdata.PIUs[1].Id,
+				RespondingPIU: testPIUId,
+				State:         entities.RequestStatePendingConfirmed,
+				PNRHashes:     []string{},
+			}
+
+			r.InsertPNR(request.Id, request)
+			r.InsertGCMetadata(request, entities.GCMetadata{Id: request.Id})
+
+			input := entities.SubmitPNRResponseInput{
+				Id:                  request.Id,
+				ResponseTimestamp:   testdata.LatestTimestamp,
+				ResponseData:        &responseData,
+				ResponseSignature:   testCase.Signature,
+				ResponseCertificate: testCase.Certificate,
+			}
+
+			err := u.SubmitPNRResponseAck(context.TODO(), input, &entities.SubmitPNRResponseOutput{})
+
+			if testCase.Status != status.OK {
+				assert.ErrorIs(err, testCase.Status)
+
+				actual, _ := r.GetPNR(request.Id)
+				assert.Equal(entities.RequestStatePendingConfirmed, actual.State)
+				return
+			}
+
+			assert.NoError(err)
+
+			var output entities.PNRResponseSignature
+
+			err = u.VerifyPNRResponseSignature(context.TODO(), entities.VerifyPNRResponseSignatureInput{Id: request.Id}, &output)
+			assert.NoError(err)
+
+			signed := testCase.Signature != nil
+
+			if signed {
+				actual, _ := r.GetPNR(request.Id)
+				assert.Equal(testdata.LatestTimestamp, actual.ResponseSignedAt)
+			}
+
+			assert.Equal(request.Id, output.Id)
+			assert.Equal(signed, output.Signed)
+			assert.Equal(signed, output.Valid)
+			assert.Equal(signed, output.DataVerified)
+			assert.Equal(testCase.Signer, output.Signer)
+		})
+	}
+}
+
+func TestVerifyPNRResponseSignature(t *testing.T) {
+	assert := assert.New(t)
+
+	key := lo.Must(ecdsa.GenerateKey(elliptic.P256(), rand.Reader))
+	_, certificate := newTestingCertificate("testing responder", key, nil, nil)
+
+	otherKey := lo.Must(ecdsa.GenerateKey(elliptic.P256(), rand.Reader))
+	_, otherCertificate := newTestingCertificate("rotated responder", otherKey, nil, nil)
+
+	var responseData json.RawMessage = []byte(`{"pnrs": []}`)
+
+	r := inmemory.NewInMemoryRepository()
+	u := usecase.NewRMTUsecase(testPIUId, r, usecase.WithClock(&fixedClock{now: testdata.LatestTimestamp}))
+	setupPIUs(r)
+
+	piu, _ := r.GetPIU(testdata.PIUs[1].Id)
+	piu.SigningCertificate = certificate
+	r.UpdatePIU(piu.Id, piu)
+
+	// The certificate is verified at the time of submission, not at the
+	// response timestamp chosen by the client, which is past its validity.
+	response := entities.PNR{
+		Id:                  "someId",
+		RequestingPIU:       testPIUId,
+		RespondingPIU:       piu.Id,
+		ResponseTimestamp:   testdata.LatestTimestamp.Add(24 * time.Hour),
+		State:               entities.RequestStateAckConfirmed,
+		PNRHashes:           []string{},
+		ResponseDigest:      hex.EncodeToString(responseDigest(responseData)),
+		ResponseSignature:   lo.Must(ecdsa.SignASN1(rand.Reader, key, responseDigest(responseData))),
+		ResponseCertificate: certificate,
+		ResponseSignedAt:    testdata.LatestTimestamp,
+	}
+
+	r.InsertPNR(response.Id, response)
+
+	input := entities.VerifyPNRResponseSignatureInput{Id: response.Id}
+
+	var output entities.PNRResponseSignature
+
+	err := u.VerifyPNRResponseSignature(context.TODO(), input, &output)
+	assert.NoError(err)
+	assert.True(output.Valid)
+	assert.False(output.DataVerified)
+
+	piu.SigningCertificate = otherCertificate
+	r.UpdatePIU(piu.Id, piu)
+
+	err = u.VerifyPNRResponseSignature(context.TODO(), input, &output)
+	assert.NoError(err)
+	assert.True(output.Signed)
+	assert.False(output.Valid)
+	assert.NotEmpty(output.Reason)
+
+	err = usecase.NewRMTUsecase(testdata.PIUs[2].Id, r).VerifyPNRResponseSignature(context.TODO(), input, &output)
+	assert.ErrorIs(err, status.PermissionDenied)
+}
+
+func encryptionKeyId(key any) string {
+	sum := sha256.Sum256(lo.Must(x509.MarshalPKIXPublicKey(key)))
+	return hex.EncodeToString(sum[:])
+}
+
+// encryptedPayload returns a well-formed payload encrypted to the key, the
+// chaincode checks only its format so the ciphertext is random.
+func encryptedPayload(key any) entities.EncryptedPayload {
+	payload := entities.EncryptedPayload{
+		Format:     entities.EncryptedPayloadFormat,
+		KeyId:      encryptionKeyId(key),
+		Nonce:      make([]byte, 12),
+		Ciphertext: make([]byte, 64),
+	}
+
+	rand.Read(payload.Ciphertext)
+
+	switch key := key.(type) {
+	case *ecdh.PublicKey:
+		payload.Algorithm = entities.EncryptionAlgorithmECDH
+		payload.EphemeralKey = lo.Must(key.Curve().GenerateKey(rand.Reader)).PublicKey().Bytes()
+	case *rsa.PublicKey:
+		payload.Algorithm = entities.EncryptionAlgorithmRSAOAEP
+		payload.EncryptedKey = make([]byte, key.Size())
+	}
+
+	return payload
+}
+
+func TestNewPNRRequestEncrypted(t *testing.T) {
+	x25519Key := lo.Must(ecdh.X25519().GenerateKey(rand.Reader)).PublicKey()
+	rsaKey := &lo.Must(rsa.GenerateKey(rand.Reader, 2048)).PublicKey
+	otherKey := lo.Must(ecdh.X25519().GenerateKey(rand.Reader)).PublicKey()
+
+	testCases := map[string]struct {
+		EncryptionKey any
+		Payload       func() entities.EncryptedPayload
+		Status        status.Code
+	}{
+		"x25519": {
+			EncryptionKey: x25519Key,
+			Payload:       func() entities.EncryptedPayload { return encryptedPayload(x25519Key) },
+			Status:        status.OK,
+		},
+		"rsa": {
+			EncryptionKey: rsaKey,
+			Payload:       func() entities.EncryptedPayload { return encryptedPayload(rsaKey) },
+			Status:        status.OK,
+		},
+		"missingEncryptionKey": {
+			Payload: func() entities.EncryptedPayload { return encryptedPayload(x25519Key) },
+			Status:  status.FailedPrecondition,
+		},
+		"otherKey": {
+			EncryptionKey: x25519Key,
+			Payload:       func() entities.EncryptedPayload { return encryptedPayload(otherKey) },
+			Status:        status.InvalidArgument,
+		},
+		"wrongAlgorithm": {
+			EncryptionKey: rsaKey,
+			Payload: func() entities.EncryptedPayload {
+				payload := encryptedPayload(rsaKey)
+				payload.Algorithm = entities.EncryptionAlgorithmECDH
+				return payload
+			},
+			Status: status.InvalidArgument,
+		},
+		"invalidEphemeralKey": {
+			EncryptionKey: x25519Key,
+			Payload: func() entities.EncryptedPayload {
+				payload := encryptedPayload(x25519Key)
+				payload.EphemeralKey = payload.EphemeralKey[1:]
+				return payload
+			},
+			Status: status.InvalidArgument,
+		},
+		"invalidNonce": {
+			EncryptionKey: x25519Key,
+			Payload: func() entities.EncryptedPayload {
+				payload := encryptedPayload(x25519Key)
+				payload.Nonce = nil
+				return payload
+			},
+			Status: status.InvalidArgument,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			r, u := newTestingUsecase()
+			setupPIUs(r)
+
+			if testCase.EncryptionKey != nil {
+				piu, _ := r.GetPIU(testdata.PIUs[1].Id)
+				piu.EncryptionKey = publicKeyPEM(testCase.EncryptionKey)
+				r.UpdatePIU(piu.Id, piu)
+			}
+
+			var requestData json.RawMessage = lo.Must(json.Marshal(testCase.Payload()))
+
+			err := u.NewPNRRequest(context.TODO(), entities.NewPNRRequestInput{
+				Id:               "someId",
+				RespondingPIU:    testdata.PIUs[1].Id,
+				RequestTimestamp: testdata.MiddleTimestamp,
+				RequestData:      &requestData,
+				Purpose:          "terrorism",
+			}, &entities.NewPNRRequestOutput{})
+
+			if testCase.Status != status.OK {
+				assert.ErrorIs(err, testCase.Status)
+				return
+			}
+
+			assert.NoError(err)
+
+			actual, _ := r.GetPNR("someId")
+			assert.Equal(string(requestData), actual.RequestData)
+			assert.Equal(encryptionKeyId(testCase.EncryptionKey), actual.RequestKeyId)
+		})
+	}
+}
+
+func TestSubmitPNRResponseEncrypted(t *testing.T) {
+	assert := assert.New(t)
+
+	key := lo.Must(ecdh.X25519().GenerateKey(rand.Reader)).PublicKey()
+
+	r, u := newTestingUsecase()
+	setupPIUs(r)
+
+	piu, _ := r.GetPIU(testdata.PIUs[1].Id)
+	piu.EncryptionKey = publicKeyPEM(key)
+	r.UpdatePIU(piu.Id, piu)
+
+	request := entities.PNR{
+		Id:            "someId",
+		RequestingPIU: piu.Id,
+		RespondingPIU: testPIUId,
+		State:         entities.RequestStatePendingConfirmed,
+		PNRHashes:     []string{},
+	}
+
+	r.InsertPNR(request.Id, request)
+	r.InsertGCMetadata(request, entities.GCMetadata{Id: request.Id, CreationTimestamp: testdata.LatestTimestamp})
+
+	payload := encryptedPayload(key)
+	payload.PNRHashes = []string{strings.Repeat("ab", 32), strings.Repeat("cd", 32)}
+	payload.CreationTimestamp = testdata.EarliestTimestamp
+
+	submit := func(payload entities.EncryptedPayload) error {
+		var responseData json.RawMessage = lo.Must(json.Marshal(payload))
+
+		return u.SubmitPNRResponseAck(context.TODO(), entities.SubmitPNRResponseInput{
+			Id:                request.Id,
+			ResponseTimestamp: testdata.LatestTimestamp,
+			ResponseData:      &responseData,
+		}, &entities.SubmitPNRResponseOutput{})
+	}
+
+	invalid := payload
+	invalid.PNRHashes = []string{"not a hash"}
+
+	err := submit(invalid)
+	assert.ErrorIs(err, status.InvalidArgument)
+
+	err = submit(payload)
+	assert.NoError(err)
+
+	actual, _ := r.GetPNR(request.Id)
+	assert.Equal(entities.RequestStateAck, actual.State)
+	assert.Equal(payload.PNRHashes, actual.PNRHashes)
+	assert.Equal(encryptionKeyId(key), actual.ResponseKeyId)
+
+	gc, _ := r.GetGCMetadata(request.Id)
+	assert.Equal(testdata.EarliestTimestamp, gc.CreationTimestamp)
+}
+
+func TestPNRRecordPurgeDeadline(t *testing.T) {
+	assert := assert.New(t)
+
+	key := lo.Must(ecdh.X25519().GenerateKey(rand.Reader)).PublicKey()
+
+	config := entities.DefaultConsortiumConfig()
+	config.Retention = time.Hour
+
+	r := inmemory.NewInMemoryRepository()
+	requester := usecase.NewRMTUsecase(testdata.PIUs[1].Id, r, usecase.WithConfig(config), usecase.WithClock(newTestingClock()))
+	responder := usecase.NewRMTUsecase(testPIUId, r, usecase.WithConfig(config), usecase.WithClock(newTestingClock()))
+	setupPIUs(r)
+
+	piu, _ := r.GetPIU(testdata.PIUs[1].Id)
+	piu.EncryptionKey = publicKeyPEM(key)
+	r.UpdatePIU(piu.Id, piu)
+
+	var requestData json.RawMessage = lo.Must(json.Marshal("test request data"))
+
+	err := requester.NewPNRRequest(context.TODO(), entities.NewPNRRequestInput{
+		Id:               "someId",
+		RespondingPIU:    testPIUId,
+		RequestTimestamp: testdata.MiddleTimestamp,
+		RequestData:      &requestData,
+		Purpose:          "terrorism",
+	}, &entities.NewPNRRequestOutput{})
+	assert.NoError(err)
+
+	gc, _ := r.GetGCMetadata("someId")
+	assert.Equal(testdata.MiddleTimestamp.Add(time.Hour), gc.PurgeDeadline)
+
+	pnr, _ := r.GetPNR("someId")
+	pnr.State = entities.RequestStatePendingConfirmed
+	r.UpdatePNR(pnr.Id, pnr)
+
+	payload := encryptedPayload(key)
+	payload.CreationTimestamp = testdata.EarliestTimestamp
+
+	var responseData json.RawMessage = lo.Must(json.Marshal(payload))
+
+	err = responder.SubmitPNRResponseAck(context.TODO(), entities.SubmitPNRResponseInput{
+		Id:                "someId",
+		ResponseTimestamp: testdata.MiddleTimestamp,
+		ResponseData:      &responseData,
+	}, &entities.SubmitPNRResponseOutput{})
+	assert.NoError(err)
+
+	gc, _ = r.GetGCMetadata("someId")
+	assert.Equal(testdata.EarliestTimestamp.Add(time.Hour), gc.PurgeDeadline)
+}
+
+func TestForwardPNRRequestEncrypted(t *testing.T) {
+	assert := assert.New(t)
+
+	key := lo.Must(ecdh.X25519().GenerateKey(rand.Reader)).PublicKey()
+	forwardKey := lo.Must(ecdh.X25519().GenerateKey(rand.Reader)).PublicKey()
+
+	r, u := newTestingUsecase()
+	setupPIUs(r)
+
+	piu, _ := r.GetPIU(testPIUId)
+	piu.EncryptionKey = publicKeyPEM(key)
+	r.UpdatePIU(piu.Id, piu)
+
+	piu, _ = r.GetPIU(testdata.PIUs[2].Id)
+	piu.EncryptionKey = publicKeyPEM(forwardKey)
+	r.UpdatePIU(piu.Id, piu)
+
+	originalRequest := entities.PNR{
+		Id:               "someId",
+		RequestingPIU:    testdata.PIUs[1].Id,
+		RespondingPIU:    testPIUId,
+		RequestTimestamp: testdata.EarliestTimestamp,
+		State:            entities.RequestStatePendingConfirmed,
+		RequestData:      string(lo.Must(json.Marshal(encryptedPayload(key)))),
+		RequestKeyId:     encryptionKeyId(key),
+		PNRHashes:        []string{},
+		Purpose:          "terrorism",
+	}
+
+	r.InsertPNR(originalRequest.Id, originalRequest)
+	r.InsertGCMetadata(originalRequest, entities.GCMetadata{Id: originalRequest.Id, CreationTimestamp: originalRequest.RequestTimestamp})
+
+	input := entities.ForwardPNRRequestInput{
+		Id:               originalRequest.Id,
+		NewId:            "newId",
+		RespondingPIU:    testdata.PIUs[2].Id,
+		ForwardTimestamp: testdata.MiddleTimestamp,
+	}
+
+	err := u.ForwardPNRRequest(context.TODO(), input, &entities.ForwardPNRRequestOutput{})
+	assert.ErrorIs(err, status.InvalidArgument)
+
+	var plaintext json.RawMessage = lo.Must(json.Marshal("test request data"))
+	input.RequestData = &plaintext
+
+	err = u.ForwardPNRRequest(context.TODO(), input, &entities.ForwardPNRRequestOutput{})
+	assert.ErrorIs(err, status.InvalidArgument)
+
+	var requestData json.RawMessage = lo.Must(json.Marshal(encryptedPayload(forwardKey)))
+	input.RequestData = &requestData
+
+	err = u.ForwardPNRRequest(context.TODO(), input, &entities.ForwardPNRRequestOutput{})
+	assert.NoError(err)
+
+	forwarded, _ := r.GetPNR("newId")
+	assert.Equal(string(requestData), forwarded.RequestData)
+	assert.Equal(encryptionKeyId(forwardKey), forwarded.RequestKeyId)
+}
+
+func openSealedData(key []byte, field string, data string) string {
+	var sealed entities.SealedData
+	lo.Must0(json.Unmarshal([]byte(data), &sealed))
+
+	aead := lo.Must(cipher.NewGCM(lo.Must(aes.NewCipher(key))))
+
+	return string(lo.Must(aead.Open(nil, sealed.Nonce, sealed.Ciphertext, []byte(field))))
+}
+
+func TestPNRDataEncryptedAtRest(t *testing.T) {
+	assert := assert.New(t)
+
+	r := inmemory.NewInMemoryRepository()
+	setupPIUs(r)
+
+	keys := map[string]*ecdh.PublicKey{}
+
+	for _, piu := range testdata.PIUs[0:2] {
+		keys[piu.Id] = lo.Must(ecdh.X25519().GenerateKey(rand.Reader)).PublicKey()
+
+		piu, _ := r.GetPIU(piu.Id)
+		piu.EncryptionKey = publicKeyPEM(keys[piu.Id])
+		r.UpdatePIU(piu.Id, piu)
+	}
+
+	config := entities.DefaultConsortiumConfig()
+	config.AtRestEncryption = true
+
+	requester := usecase.NewRMTUsecase(testPIUId, r, usecase.WithConfig(config))
+	responder := usecase.NewRMTUsecase(testdata.PIUs[1].Id, r, usecase.WithConfig(config))
+
+	dataKey := &entities.DataKey{
+		Key: make([]byte, 32),
+		WrappedKeys: map[string]entities.EncryptedPayload{
+			testPIUId: encryptedPayload(keys[testPIUId]),
+		},
+	}
+
+	rand.Read(dataKey.Key)
+
+	var requestData json.RawMessage = lo.Must(json.Marshal("test request data"))
+
+	request := entities.NewPNRRequestInput{
+		Id:               "someId",
+		RespondingPIU:    testdata.PIUs[1].Id,
+		RequestTimestamp: testdata.MiddleTimestamp,
+		RequestData:      &requestData,
+		Purpose:          "terrorism",
+	}
+
+	err := requester.NewPNRRequest(context.TODO(), request, &entities.NewPNRRequestOutput{})
+	assert.ErrorIs(err, status.InvalidArgument)
+
+	request.DataKey = dataKey
+
+	err = requester.NewPNRRequest(context.TODO(), request, &entities.NewPNRRequestOutput{})
+	assert.ErrorIs(err, status.InvalidArgument)
+
+	dataKey.WrappedKeys[testdata.PIUs[1].Id] = encryptedPayload(keys[testdata.PIUs[1].Id])
+
+	err = requester.NewPNRRequest(context.TODO(), request, &entities.NewPNRRequestOutput{})
+	assert.NoError(err)
+
+	actual, _ := r.GetPNR("someId")
+	sum := sha256.Sum256(dataKey.Key)
+	assert.Equal(hex.EncodeToString(sum[:]), actual.DataKeyId)
+	assert.Equal(dataKey.WrappedKeys, actual.DataKeys)
+	assert.NotContains(actual.RequestData, "test request data")
+	assert.Equal(string(requestData), openSealedData(dataKey.Key, "requestData", actual.RequestData))
+
+	err = responder.ConfirmPNR(context.TODO(), entities.ConfirmPNRInput{Id: "someId"}, &entities.ConfirmPNROutput{})
+	assert.NoError(err)
+
+	var responseData json.RawMessage = []byte(`{"passengerDatasets": [{"passenger_obj": {"name": "Novák"}}]}`)
+
+	response := entities.SubmitPNRResponseInput{
+		Id:                "someId",
+		ResponseTimestamp: testdata.LatestTimestamp,
+		ResponseData:      &responseData,
+	}
+
+	err = responder.SubmitPNRResponseAck(context.TODO(), response, &entities.SubmitPNRResponseOutput{})
+	assert.ErrorIs(err, status.InvalidArgument)
+
+	response.DataKey = &entities.DataKey{Key: make([]byte, 32)}
+
+	err = responder.SubmitPNRResponseAck(context.TODO(), response, &entities.SubmitPNRResponseOutput{})
+	assert.ErrorIs(err, status.InvalidArgument)
+
+	response.DataKey = &entities.DataKey{Key: dataKey.Key}
+
+	err = responder.SubmitPNRResponseAck(context.TODO(), response, &entities.SubmitPNRResponseOutput{})
+	assert.NoError(err)
+
+	actual, _ = r.GetPNR("someId")
+	assert.Len(actual.PNRHashes, 1)
+	assert.NotContains(actual.ResponseData, "Novák")
+	assert.Equal(string(responseData), openSealedData(dataKey.Key, "responseData", actual.ResponseData))
+
+	err = requester.ConfirmPNR(context.TODO(), entities.ConfirmPNRInput{Id: "someId"}, &entities.ConfirmPNROutput{})
+	assert.NoError(err)
+
+	actual, _ = r.GetPNR("someId")
+	assert.Equal(entities.RequestStateAckConfirmed, actual.State)
+	assert.NotEmpty(actual.DataKeyId)
+	assert.Empty(actual.DataKeys)
+	assert.Empty(actual.ResponseData)
+}
@@ -0,0 +1,50 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/swaggest/usecase/status"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+)
+
+// defaultMigrationPageSize bounds the number of records rewritten by a single
+// MigrateData transaction when the page size is not given.
+const defaultMigrationPageSize = 100
+
+// MigrateData rewrites a batch of records stored with an older model version
+// to the current one. Callers repeat it with the returned bookmark until it
+// is empty.
+func (u RMTUsecase) MigrateData(ctx context.Context, input entities.MigrateDataInput, output *entities.MigrateDataOutput) error {
+	slog.Debug(
+		"MigrateData called",
+		"input", input,
+	)
+
+	pageSize := input.PageSize
+
+	if pageSize <= 0 {
+		pageSize = defaultMigrationPageSize
+	}
+
+	result, err := u.rep.MigrateData(input.Bookmark, pageSize)
+
+	if err != nil {
+		slog.Error(
+			"Could not migrate stored data",
+			"bookmark", input.Bookmark,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	*output = result
+
+	slog.Debug(
+		"MigrateData finished",
+		"output", output,
+	)
+
+	return nil
+}
@@ -0,0 +1,308 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"log/slog"
+	"regexp"
+
+	"github.com/swaggest/usecase/status"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+)
+
+const minRSAKeyBits = 2048
+
+var countryCodeRegexp = regexp.MustCompile(`^[A-Z]{2}$`)
+
+func parsePublicKeyPEM(data string) (crypto.PublicKey, error) {
+	block, rest := pem.Decode([]byte(data))
+
+	if block == nil {
+		return nil, errors.New("Key is not PEM encoded")
+	}
+
+	if block.Type != "PUBLIC KEY" {
+		return nil, errors.New("PEM block must contain a public key")
+	}
+
+	if next, _ := pem.Decode(rest); next != nil {
+		return nil, errors.New("PEM must contain a single key")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if rsaKey, ok := key.(*rsa.PublicKey); ok && rsaKey.N.BitLen() < minRSAKeyBits {
+		return nil, errors.New("RSA key is too short")
+	}
+
+	return key, nil
+}
+
+func validateEncryptionKey(data string) error {
+	key, err := parsePublicKeyPEM(data)
+
+	if err != nil {
+		return err
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, *ecdh.PublicKey:
+		return nil
+	default:
+		return errors.New("Unsupported encryption key type")
+	}
+}
+
+func validateSigningKey(data string) error {
+	key, err := parsePublicKeyPEM(data)
+
+	if err != nil {
+		return err
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return nil
+	default:
+		return errors.New("Unsupported signing key type")
+	}
+}
+
+// parseCertificatesPEM parses a certificate followed by optional
+// intermediate certificates.
+func parseCertificatesPEM(data string) ([]*x509.Certificate, error) {
+	var certificates []*x509.Certificate
+
+	rest := []byte(data)
+
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			return nil, errors.New("PEM block must contain a certificate")
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+
+		if err != nil {
+			return nil, err
+		}
+
+		certificates = append(certificates, certificate)
+	}
+
+	if len(certificates) == 0 {
+		return nil, errors.New("Certificate is not PEM encoded")
+	}
+
+	return certificates, nil
+}
+
+func validateSigningCertificate(data string) error {
+	certificates, err := parseCertificatesPEM(data)
+
+	if err != nil {
+		return err
+	}
+
+	if len(certificates) > 1 {
+		return errors.New("PEM must contain a single certificate")
+	}
+
+	switch key := certificates[0].PublicKey.(type) {
+	case *rsa.PublicKey:
+		if key.N.BitLen() < minRSAKeyBits {
+			return errors.New("RSA key is too short")
+		}
+	case *ecdsa.PublicKey, ed25519.PublicKey:
+	default:
+		return errors.New("Unsupported signing key type")
+	}
+
+	return nil
+}
+
+func validatePIUInfo(info entities.PIUInfo) error {
+	if info.Country != "" && !countryCodeRegexp.MatchString(info.Country) {
+		return errors.New("Country must be an ISO 3166-1 alpha-2 code")
+	}
+
+	for _, contact := range info.Contacts {
+		if contact.Email == "" && contact.Phone == "" {
+			return errors.New("Contact point must have an email or a phone number")
+		}
+	}
+
+	for _, profile := range info.SupportedProfiles {
+		if profile == "" {
+			return errors.New("Supported profile must not be empty")
+		}
+	}
+
+	if info.EncryptionKey != "" {
+		if err := validateEncryptionKey(info.EncryptionKey); err != nil {
+			return errors.New("Invalid encryption key: " + err.Error())
+		}
+	}
+
+	if info.SigningKey != "" {
+		if err := validateSigningKey(info.SigningKey); err != nil {
+			return errors.New("Invalid signing key: " + err.Error())
+		}
+	}
+
+	if info.SigningCertificate != "" {
+		if err := validateSigningCertificate(info.SigningCertificate); err != nil {
+			return errors.New("Invalid signing certificate: " + err.Error())
+		}
+	}
+
+	return nil
+}
+
+// mergePatch applies a JSON Merge Patch as described in RFC 7396.
+func mergePatch(target any, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+		} else {
+			targetObject[key] = mergePatch(targetObject[key], value)
+		}
+	}
+
+	return targetObject
+}
+
+func applyPIUInfoPatch(info entities.PIUInfo, patch string) (entities.PIUInfo, error) {
+	var patchDocument any
+
+	err := json.Unmarshal([]byte(patch), &patchDocument)
+	if err != nil {
+		return entities.PIUInfo{}, err
+	}
+
+	if _, ok := patchDocument.(map[string]any); !ok {
+		return entities.PIUInfo{}, errors.New("Patch must be a JSON object")
+	}
+
+	target, err := json.Marshal(info)
+	if err != nil {
+		return entities.PIUInfo{}, err
+	}
+
+	var targetDocument any
+
+	err = json.Unmarshal(target, &targetDocument)
+	if err != nil {
+		return entities.PIUInfo{}, err
+	}
+
+	patched, err := json.Marshal(mergePatch(targetDocument, patchDocument))
+	if err != nil {
+		return entities.PIUInfo{}, err
+	}
+
+	var result entities.PIUInfo
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(&result)
+	if err != nil {
+		return entities.PIUInfo{}, err
+	}
+
+	return result, nil
+}
+
+func (u RMTUsecase) GetPIUHistory(ctx context.Context, input entities.GetPIUHistoryInput, output *[]entities.PIUHistoryEntry) error {
+	slog.Debug(
+		"GetPIUHistory called",
+		"input", input,
+	)
+
+	out, err := u.rep.GetPIUHistory(input.Id)
+
+	if err != nil {
+		slog.Error(
+			"Failed to get PIU history from the repository",
+			"id", input.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	*output = out
+
+	slog.Debug(
+		"GetPIUHistory finished",
+		"output", output,
+	)
+
+	return nil
+}
+
+func (u RMTUsecase) checkSupportedProfile(piuId string, profile string) error {
+	if !u.config.IsAllowedProfile(profile) {
+		err := errors.New("Payload profile is not allowed in the consortium")
+		slog.Error(
+			err.Error(),
+			"payloadProfile", profile,
+			"payloadProfiles", u.config.PayloadProfiles,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	piu, err := u.rep.GetPIU(piuId)
+
+	if err != nil {
+		slog.Error(
+			"Could not get information about PIU",
+			"id", piuId,
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	if !entities.SupportsProfile(piu, profile) {
+		err := errors.New("Payload profile is not supported by responding PIU")
+		slog.Error(
+			err.Error(),
+			"id", piuId,
+			"payloadProfile", profile,
+			"supportedProfiles", piu.SupportedProfiles,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/swaggest/usecase/status"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+)
+
+type quotaLimit struct {
+	counterpart string
+	limit       int
+}
+
+// quotaLimits returns limits applying to requests of given pair of PIUs.
+// Counters with empty counterpart count requests made to any PIU.
+func (u RMTUsecase) quotaLimits(requestingPIU string, respondingPIU string) []quotaLimit {
+	return []quotaLimit{
+		{"", u.config.Quota.GetPIULimit(requestingPIU)},
+		{respondingPIU, u.config.Quota.GetPairLimit(requestingPIU, respondingPIU)},
+	}
+}
+
+func (u RMTUsecase) getQuotaUsage(piuId string, counterpart string, limit int, now time.Time) (entities.QuotaUsage, error) {
+	usage := entities.QuotaUsage{
+		Counterpart: counterpart,
+		Limited:     limit > 0,
+		Limit:       limit,
+	}
+
+	if !usage.Limited {
+		return usage, nil
+	}
+
+	for _, bucket := range u.config.Quota.GetBuckets(now) {
+		counter, err := u.rep.GetQuotaCounter(piuId, counterpart, bucket)
+
+		if err != nil {
+			slog.Error(
+				"Failed to get quota counter from the repository",
+				"piuId", piuId,
+				"counterpart", counterpart,
+				"error", err,
+			)
+			return entities.QuotaUsage{}, status.Wrap(err, status.Internal)
+		}
+
+		usage.Used += counter.Count
+	}
+
+	usage.Remaining = max(limit-usage.Used, 0)
+
+	return usage, nil
+}
+
+// checkQuota fails with ResourceExhausted when another request of the PIU
+// would exceed any of its quotas.
+func (u RMTUsecase) checkQuota(requestingPIU string, respondingPIU string, now time.Time) error {
+	for _, quota := range u.quotaLimits(requestingPIU, respondingPIU) {
+		counterpart, limit := quota.counterpart, quota.limit
+
+		if limit <= 0 {
+			continue
+		}
+
+		usage, err := u.getQuotaUsage(requestingPIU, counterpart, limit, now)
+
+		if err != nil {
+			return err
+		}
+
+		if usage.Remaining == 0 {
+			err := errors.New("PNR request quota exceeded")
+			slog.Error(
+				err.Error(),
+				"requestingPIU", requestingPIU,
+				"counterpart", counterpart,
+				"limit", limit,
+			)
+			return status.Wrap(err, status.ResourceExhausted)
+		}
+	}
+
+	return nil
+}
+
+// consumeQuota counts a new request of the PIU. Requests are counted only
+// by limited quotas.
+func (u RMTUsecase) consumeQuota(requestingPIU string, respondingPIU string, now time.Time) error {
+	for _, quota := range u.quotaLimits(requestingPIU, respondingPIU) {
+		counterpart, limit := quota.counterpart, quota.limit
+
+		if limit <= 0 {
+			continue
+		}
+
+		counter, err := u.rep.GetQuotaCounter(requestingPIU, counterpart, now)
+
+		if err == nil {
+			counter.Count++
+			err = u.rep.PutQuotaCounter(counter)
+		}
+
+		if err != nil {
+			slog.Error(
+				"Could not update quota counter",
+				"piuId", requestingPIU,
+				"counterpart", counterpart,
+				"error", err,
+			)
+			return status.Wrap(err, status.Internal)
+		}
+	}
+
+	return nil
+}
+
+// GetQuotaStatus shows the calling PIU its remaining budget of PNR requests
+// in total and towards each PIU it can send requests to.
+func (u RMTUsecase) GetQuotaStatus(ctx context.Context, input entities.GetQuotaStatusInput, output *entities.QuotaStatus) error {
+	slog.Debug(
+		"GetQuotaStatus called",
+		"input", input,
+	)
+
+	now, err := u.now()
+
+	if err != nil {
+		return err
+	}
+
+	total, err := u.getQuotaUsage(u.piuId, "", u.config.Quota.GetPIULimit(u.piuId), now)
+
+	if err != nil {
+		return err
+	}
+
+	pius, err := u.rep.GetPIUs()
+
+	if err != nil {
+		slog.Error(
+			"Failed to get PIUs from the repository",
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	slices.SortFunc(pius, func(a entities.PIU, b entities.PIU) int {
+		return strings.Compare(a.Id, b.Id)
+	})
+
+	pairs := []entities.QuotaUsage{}
+
+	for _, piu := range pius {
+		if u.isThisPIU(piu.Id) || entities.GetPIUStatus(piu.Status) == entities.PIUStatusRetired {
+			continue
+		}
+
+		usage, err := u.getQuotaUsage(u.piuId, piu.Id, u.config.Quota.GetPairLimit(u.piuId, piu.Id), now)
+
+		if err != nil {
+			return err
+		}
+
+		pairs = append(pairs, usage)
+	}
+
+	*output = entities.QuotaStatus{
+		Window: u.config.Quota.GetWindow(),
+		Total:  total,
+		Pairs:  pairs,
+	}
+
+	slog.Debug(
+		"GetQuotaStatus finished",
+		"output", output,
+	)
+
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"log/slog"
+	"slices"
 	"time"
 
 	"github.com/gowebpki/jcs"
@@ -17,22 +18,109 @@ import (
 )
 
 type RMTUsecase struct {
-	rep   repository.Repository
-	piuId string
+	rep      repository.Repository
+	piuId    string
+	notifier Notifier
+	config   entities.ConsortiumConfig
+	clock    Clock
+	clientId string
+	mspId    string
+	txId     string
 }
 
-func NewRMTUsecase(piuId string, rep repository.Repository) *RMTUsecase {
-	return &RMTUsecase{
-		rep:   rep,
-		piuId: piuId,
+type RMTUsecaseOption func(*RMTUsecase)
+
+// WithNotifier sets the notifier used to inform PIUs about changes of PNR
+// requests they are involved in.
+func WithNotifier(notifier Notifier) RMTUsecaseOption {
+	return func(u *RMTUsecase) {
+		u.notifier = notifier
+	}
+}
+
+// WithConfig sets the consortium configuration, DefaultConsortiumConfig is
+// used otherwise.
+func WithConfig(config entities.ConsortiumConfig) RMTUsecaseOption {
+	return func(u *RMTUsecase) {
+		u.config = config
+	}
+}
+
+// WithClock sets the clock used to measure request quotas, system time is
+// used otherwise.
+func WithClock(clock Clock) RMTUsecaseOption {
+	return func(u *RMTUsecase) {
+		u.clock = clock
+	}
+}
+
+// WithClientId sets the id of the officer invoking the usecase, which is
+// needed to draft and approve PNR requests and responses.
+func WithClientId(clientId string) RMTUsecaseOption {
+	return func(u *RMTUsecase) {
+		u.clientId = clientId
+	}
+}
+
+// WithMSPId sets the id of the MSP of the calling PIU, if it differs from the
+// PIU id.
+func WithMSPId(mspId string) RMTUsecaseOption {
+	return func(u *RMTUsecase) {
+		u.mspId = mspId
+	}
+}
+
+// WithTxId sets the id of the transaction invoking the usecase, which
+// identifies exports.
+func WithTxId(txId string) RMTUsecaseOption {
+	return func(u *RMTUsecase) {
+		u.txId = txId
 	}
 }
 
+func NewRMTUsecase(piuId string, rep repository.Repository, opts ...RMTUsecaseOption) *RMTUsecase {
+	u := &RMTUsecase{
+		rep:      rep,
+		piuId:    piuId,
+		notifier: noopNotifier{},
+		config:   entities.DefaultConsortiumConfig(),
+		clock:    systemClock{},
+		mspId:    piuId,
+	}
+
+	for _, opt := range opts {
+		opt(u)
+	}
+
+	return u
+}
+
 func (u RMTUsecase) isThisPIU(piuId string) bool {
 	return u.piuId == piuId
 }
 
-func (u RMTUsecase) SetPIUInfo(ctx context.Context, input entities.PIUInfo, output *entities.SetPIUInfoOutput) error {
+// now returns the current time of the clock, the transaction fails when it
+// is not available.
+func (u RMTUsecase) now() (time.Time, error) {
+	now, err := u.clock.Now()
+
+	if err != nil {
+		slog.Error(
+			"Could not get current time",
+			"error", err,
+		)
+		return time.Time{}, status.Wrap(err, status.Internal)
+	}
+
+	return now, nil
+}
+
+func isSamePIUPair(pnr entities.PNR, firstPIU string, secondPIU string) bool {
+	return (pnr.RequestingPIU == firstPIU && pnr.RespondingPIU == secondPIU) ||
+		(pnr.RequestingPIU == secondPIU && pnr.RespondingPIU == firstPIU)
+}
+
+func (u RMTUsecase) SetPIUInfo(ctx context.Context, input entities.SetPIUInfoInput, output *entities.SetPIUInfoOutput) error {
 	slog.Debug(
 		"SetPIUInfo called",
 		"input", input,
@@ -49,11 +137,10 @@ func (u RMTUsecase) SetPIUInfo(ctx context.Context, input entities.PIUInfo, outp
 		return err
 	}
 
-	if !exists {
-		entity := entities.NewPIUFromPIUInfo(u.piuId, input)
-		err = u.rep.InsertPIU(u.piuId, entity)
-	} else {
-		entity, err := u.rep.GetPIU(u.piuId)
+	entity := entities.PIU{Id: u.piuId}
+
+	if exists {
+		entity, err = u.rep.GetPIU(u.piuId)
 
 		if err != nil {
 			slog.Error(
@@ -64,7 +151,55 @@ func (u RMTUsecase) SetPIUInfo(ctx context.Context, input entities.PIUInfo, outp
 			return err
 		}
 
-		entity = entities.UpdatePIUFromPIUInfo(entity, input)
+		if entities.GetPIUStatus(entity.Status) == entities.PIUStatusRetired {
+			err := errors.New("Retired PIU cannot change its information")
+			slog.Error(
+				err.Error(),
+				"id", u.piuId,
+			)
+			return status.Wrap(err, status.FailedPrecondition)
+		}
+	}
+
+	if input.ExpectedVersion != 0 && input.ExpectedVersion != entity.Version {
+		err := errors.New("PIU information was changed concurrently")
+		slog.Error(
+			err.Error(),
+			"id", u.piuId,
+			"expectedVersion", input.ExpectedVersion,
+			"version", entity.Version,
+		)
+		return status.Wrap(err, status.FailedPrecondition)
+	}
+
+	info, err := applyPIUInfoPatch(entities.PIUInfoFromPIU(entity), entities.OptionalMessage(input.Patch))
+
+	if err != nil {
+		slog.Error(
+			"Could not apply PIU information patch",
+			"id", u.piuId,
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	err = validatePIUInfo(info)
+
+	if err != nil {
+		slog.Error(
+			"Invalid PIU information",
+			"id", u.piuId,
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	if !exists {
+		entity = entities.NewPIUFromPIUInfo(u.piuId, info)
+		err = u.rep.InsertPIU(u.piuId, entity)
+	} else {
+		entity = entities.UpdatePIUFromPIUInfo(entity, info)
+		entity.Version++
 		err = u.rep.UpdatePIU(u.piuId, entity)
 	}
 
@@ -77,7 +212,7 @@ func (u RMTUsecase) SetPIUInfo(ctx context.Context, input entities.PIUInfo, outp
 		return err
 	}
 
-	*output = entities.SetPIUInfoOutput{}
+	*output = entities.SetPIUInfoOutput{Version: entity.Version}
 
 	slog.Debug(
 		"SetPIUInfo finished",
@@ -103,6 +238,12 @@ func (u RMTUsecase) GetPIUs(ctx context.Context, input entities.GetPIUsInput, ou
 		return err
 	}
 
+	if !input.IncludeRetired {
+		out = slices.DeleteFunc(out, func(piu entities.PIU) bool {
+			return entities.GetPIUStatus(piu.Status) == entities.PIUStatusRetired
+		})
+	}
+
 	*output = out
 
 	slog.Debug(
@@ -119,6 +260,15 @@ func (u RMTUsecase) GetPNRs(ctx context.Context, input entities.PNRFilter, outpu
 		"input", input,
 	)
 
+	if !entities.IsValidPNRSortField(input.SortBy) {
+		err := errors.New("Invalid sort field")
+		slog.Error(
+			err.Error(),
+			"sortBy", input.SortBy,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
 	out, err := u.rep.GetPNRs(input)
 
 	if err != nil {
@@ -130,6 +280,10 @@ func (u RMTUsecase) GetPNRs(ctx context.Context, input entities.PNRFilter, outpu
 		return status.Wrap(err, status.InvalidArgument)
 	}
 
+	if input.SortBy != "" {
+		entities.SortPNRs(out, input.SortBy, input.SortDescending)
+	}
+
 	*output = out
 
 	slog.Debug(
@@ -156,24 +310,125 @@ func (u RMTUsecase) NewPNRRequest(ctx context.Context, input entities.NewPNRRequ
 		return status.Wrap(err, status.InvalidArgument)
 	}
 
-	_, err := u.rep.GetPIU(input.RespondingPIU)
+	err := u.checkActivePIU(u.piuId)
+
+	if err != nil {
+		return err
+	}
+
+	err = u.checkActivePIU(input.RespondingPIU)
+
+	if err != nil {
+		return err
+	}
+
+	err = u.checkSupportedProfile(input.RespondingPIU, input.PayloadProfile)
+
+	if err != nil {
+		return err
+	}
+
+	now, err := u.now()
 
 	if err != nil {
+		return err
+	}
+
+	agreement, err := u.findAgreement(u.piuId, input.RespondingPIU, input.Purpose, now)
+
+	if err != nil {
+		return err
+	}
+
+	err = u.checkQuota(u.piuId, input.RespondingPIU, now)
+
+	if err != nil {
+		return err
+	}
+
+	priority := entities.GetPriority(input.Priority)
+
+	if !entities.IsValidPriority(priority) {
+		err := errors.New("Invalid priority of PNR request")
 		slog.Error(
-			"Could not get information about responding PIU",
-			"error", err,
+			err.Error(),
+			"priority", input.Priority,
 		)
 		return status.Wrap(err, status.InvalidArgument)
 	}
 
+	if input.ParentId != "" {
+		parent, err := u.rep.GetPNR(input.ParentId)
+
+		if err != nil {
+			slog.Error(
+				"Could not get parent PNR request",
+				"parentId", input.ParentId,
+				"error", err,
+			)
+			return status.Wrap(err, status.InvalidArgument)
+		}
+
+		if !isSamePIUPair(parent, u.piuId, input.RespondingPIU) {
+			err := errors.New("Parent PNR request involves a different pair of PIUs")
+			slog.Error(
+				err.Error(),
+				"parentId", input.ParentId,
+				"requestingPIU", parent.RequestingPIU,
+				"respondingPIU", parent.RespondingPIU,
+			)
+			return status.Wrap(err, status.InvalidArgument)
+		}
+	}
+
+	requestData := entities.OptionalMessage(input.RequestData)
+
+	keyId, err := u.checkEncryptedPayload(requestData, input.RespondingPIU)
+
+	if err != nil {
+		return err
+	}
+
 	pnr := entities.PNR{
-		Id:               input.Id,
-		RequestingPIU:    u.piuId,
-		RespondingPIU:    input.RespondingPIU,
-		RequestTimestamp: input.RequestTimestamp,
-		State:            entities.RequestStatePending,
-		RequestData:      entities.OptionalMessage(input.RequestData),
-		PNRHashes:        []string{},
+		Id:                      input.Id,
+		RequestingPIU:           u.piuId,
+		RespondingPIU:           input.RespondingPIU,
+		RequestTimestamp:        input.RequestTimestamp,
+		State:                   entities.RequestStatePending,
+		RequestData:             requestData,
+		PNRHashes:               []string{},
+		RequestKeyId:            keyId,
+		ParentId:                input.ParentId,
+		AllowOnBehalfForwarding: input.AllowOnBehalfForwarding,
+		Priority:                priority,
+		Deadline:                u.config.GetDeadline(priority, input.RequestTimestamp),
+		PayloadProfile:          input.PayloadProfile,
+		Purpose:                 input.Purpose,
+		AgreementId:             agreement.Id,
+	}
+
+	err = u.sealPNRRequest(&pnr, input.DataKey)
+
+	if err != nil {
+		return err
+	}
+
+	required, err := u.isApprovalRequired(func(piu entities.PIU) bool { return piu.RequestApproval })
+
+	if err != nil {
+		return err
+	}
+
+	if required {
+		err = u.draftPNR(entities.RequestStateDraft, pnr, u.rep.InsertLocalPNR)
+
+		if err != nil {
+			return err
+		}
+
+		*output = entities.NewPNRRequestOutput{Id: input.Id}
+
+		return nil
 	}
 
 	err = u.rep.InsertPNR(input.Id, pnr)
@@ -186,7 +441,11 @@ func (u RMTUsecase) NewPNRRequest(ctx context.Context, input entities.NewPNRRequ
 		return status.Wrap(err, status.Internal)
 	}
 
-	gc := entities.GCMetadata{Id: pnr.Id, CreationTimestamp: pnr.RequestTimestamp}
+	gc := entities.GCMetadata{
+		Id:                pnr.Id,
+		CreationTimestamp: pnr.RequestTimestamp,
+		PurgeDeadline:     u.config.GetPurgeDeadline(pnr.RequestTimestamp),
+	}
 
 	err = u.rep.InsertGCMetadata(pnr, gc)
 
@@ -198,6 +457,18 @@ func (u RMTUsecase) NewPNRRequest(ctx context.Context, input entities.NewPNRRequ
 		return status.Wrap(err, status.Internal)
 	}
 
+	err = u.consumeQuota(u.piuId, input.RespondingPIU, now)
+
+	if err != nil {
+		return err
+	}
+
+	err = u.consumeAgreement(agreement)
+
+	if err != nil {
+		return err
+	}
+
 	*output = entities.NewPNRRequestOutput{Id: input.Id}
 
 	slog.Debug(
@@ -234,7 +505,7 @@ func (u RMTUsecase) submitPNRResponse(response entities.RequestState, ctx contex
 		return status.Wrap(err, status.InvalidArgument)
 	}
 
-	if pnr.State != entities.RequestStatePendingConfirmed {
+	if pnr.State != entities.RequestStatePendingConfirmed && pnr.State != entities.RequestStateAckDraft {
 		err := errors.New("PNR request must be in PendingConfirmed state")
 		slog.Error(
 			err.Error(),
@@ -244,12 +515,35 @@ func (u RMTUsecase) submitPNRResponse(response entities.RequestState, ctx contex
 		return status.Wrap(err, status.InvalidArgument)
 	}
 
+	// Only the officer who drafted the response may replace it, and the new
+	// response has to be approved again.
+	redraft := pnr.State == entities.RequestStateAckDraft
+
+	if redraft && (u.clientId == "" || u.clientId != pnr.DraftedBy) {
+		err := errors.New("Response draft can only be changed by the officer who drafted it")
+		slog.Error(
+			err.Error(),
+			"id", input.Id,
+		)
+		return status.Wrap(err, status.PermissionDenied)
+	}
+
 	pnr.ResponseTimestamp = input.ResponseTimestamp
 	pnr.State = response
 	pnr.ResponseData = entities.OptionalMessage(input.ResponseData)
-	pnr.PNRHashes = []string{}
+	pnr.DraftedBy = ""
 
-	const pnrJSONKey = "passengerDatasets.#.passenger_obj"
+	pnr.ResponseKeyId, err = u.checkEncryptedPayload(pnr.ResponseData, pnr.RequestingPIU)
+
+	if err != nil {
+		return err
+	}
+
+	err = u.signPNRResponse(&pnr, input)
+
+	if err != nil {
+		return err
+	}
 
 	gc, err := u.rep.GetGCMetadata(input.Id)
 
@@ -262,39 +556,29 @@ func (u RMTUsecase) submitPNRResponse(response entities.RequestState, ctx contex
 		return status.Wrap(err, status.Internal)
 	}
 
-	records := gjson.Get(pnr.ResponseData, pnrJSONKey)
-	for _, record := range records.Array() {
-		canonical, err := jcs.Transform([]byte(record.Raw))
-		if err != nil {
-			slog.Error(
-				"Failed to transform PNR response record to canonical form",
-				"id", input.Id,
-				"error", err,
-			)
-			return status.Wrap(err, status.InvalidArgument)
-		}
+	err = hashPNRResponse(u.config.Hashing, &pnr, &gc)
 
-		sum := sha256.Sum256(canonical)
-		pnr.PNRHashes = append(pnr.PNRHashes, hex.EncodeToString(sum[:]))
+	if err != nil {
+		return err
+	}
 
-		const creationTimeJSONKey = "pnr_obj.iata_pnrgov_notif_rq_obj.created_on"
+	gc.PurgeDeadline = u.config.GetPurgeDeadline(gc.CreationTimestamp)
 
-		creationTimeString := record.Get(creationTimeJSONKey)
+	err = u.sealPNRResponse(&pnr, input.DataKey)
 
-		if creationTimeString.Exists() {
-			creationTimestamp, err := time.Parse(time.RFC3339, creationTimeString.Str)
-			if err != nil {
-				slog.Error(
-					"Could not parse creation timestamp",
-					"input", creationTimeString.Str,
-					"error", err,
-				)
-				return status.Wrap(err, status.InvalidArgument)
-			}
+	if err != nil {
+		return err
+	}
 
-			if gc.CreationTimestamp.After(creationTimestamp) {
-				gc.CreationTimestamp = creationTimestamp
-			}
+	if response == entities.RequestStateAck {
+		required, err := u.isApprovalRequired(func(piu entities.PIU) bool { return piu.ResponseApproval })
+
+		if err != nil {
+			return err
+		}
+
+		if required || redraft {
+			return u.draftPNR(entities.RequestStateAckDraft, pnr, u.rep.UpdateLocalPNR)
 		}
 	}
 
@@ -326,6 +610,70 @@ func (u RMTUsecase) submitPNRResponse(response entities.RequestState, ctx contex
 	return nil
 }
 
+// hashPNRResponse computes hashes of PNRs included in the response and moves
+// the GC creation timestamp to the oldest of them. Encrypted responses carry
+// the hashes and the timestamp provided by the responder.
+func hashPNRResponse(settings entities.HashSettings, pnr *entities.PNR, gc *entities.GCMetadata) error {
+	pnr.PNRHashes = []string{}
+
+	payload, encrypted, err := decodeEncryptedPayload(pnr.ResponseData)
+
+	if err != nil {
+		slog.Error(
+			"Could not decode encrypted payload",
+			"id", pnr.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	if encrypted {
+		pnr.PNRHashes = append(pnr.PNRHashes, payload.PNRHashes...)
+
+		if !payload.CreationTimestamp.IsZero() && gc.CreationTimestamp.After(payload.CreationTimestamp) {
+			gc.CreationTimestamp = payload.CreationTimestamp
+		}
+
+		return nil
+	}
+
+	records := gjson.Get(pnr.ResponseData, settings.GetRecordPath())
+	for _, record := range records.Array() {
+		canonical, err := jcs.Transform([]byte(record.Raw))
+		if err != nil {
+			slog.Error(
+				"Failed to transform PNR response record to canonical form",
+				"id", pnr.Id,
+				"error", err,
+			)
+			return status.Wrap(err, status.InvalidArgument)
+		}
+
+		sum := sha256.Sum256(canonical)
+		pnr.PNRHashes = append(pnr.PNRHashes, hex.EncodeToString(sum[:]))
+
+		creationTimeString := record.Get(settings.GetCreationTimePath())
+
+		if creationTimeString.Exists() {
+			creationTimestamp, err := time.Parse(time.RFC3339, creationTimeString.Str)
+			if err != nil {
+				slog.Error(
+					"Could not parse creation timestamp",
+					"input", creationTimeString.Str,
+					"error", err,
+				)
+				return status.Wrap(err, status.InvalidArgument)
+			}
+
+			if gc.CreationTimestamp.After(creationTimestamp) {
+				gc.CreationTimestamp = creationTimestamp
+			}
+		}
+	}
+
+	return nil
+}
+
 func (u RMTUsecase) SubmitPNRResponseAck(ctx context.Context, input entities.SubmitPNRResponseInput, output *entities.SubmitPNRResponseOutput) error {
 	return u.submitPNRResponse(entities.RequestStateAck, ctx, input, output)
 }
@@ -378,6 +726,22 @@ func (u RMTUsecase) ConfirmPNR(ctx context.Context, input entities.ConfirmPNRInp
 		)
 		return status.Wrap(err, status.InvalidArgument)
 
+	case entities.RequestStateClarificationRequested:
+		err := errors.New("Cannot confirm PNR request which awaits clarification")
+		slog.Error(
+			err.Error(),
+			"id", input.Id,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+
+	case entities.RequestStateDraft, entities.RequestStateAckDraft:
+		err := errors.New("Cannot confirm PNR request which awaits approval")
+		slog.Error(
+			err.Error(),
+			"id", input.Id,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+
 	case entities.RequestStatePending:
 		if !u.isThisPIU(pnr.RespondingPIU) {
 			err := errors.New("Cannot confirm request in this state")
@@ -421,6 +785,16 @@ func (u RMTUsecase) ConfirmPNR(ctx context.Context, input entities.ConfirmPNRInp
 			)
 			return status.Wrap(err, status.Internal)
 		}
+
+		err = u.rep.PurgePNRClarifications(input.Id)
+		if err != nil {
+			slog.Error(
+				"Could not purge PNR clarifications",
+				"id", input.Id,
+				"error", err,
+			)
+			return status.Wrap(err, status.Internal)
+		}
 	}
 
 	slog.Debug(
@@ -458,6 +832,9 @@ func (u RMTUsecase) TerminatePNRRequest(ctx context.Context, input entities.Term
 		return status.Wrap(err, status.InvalidArgument)
 	}
 
+	// Drafts of PNR requests were never sent, so they have no GC metadata.
+	isDraft := pnr.State == entities.RequestStateDraft
+
 	pnr.State = entities.RequestStateTerminated
 	err = u.rep.UpdateLocalPNR(input.Id, pnr)
 
@@ -479,16 +856,28 @@ func (u RMTUsecase) TerminatePNRRequest(ctx context.Context, input entities.Term
 		return status.Wrap(err, status.Internal)
 	}
 
-	err = u.rep.DeleteLocalGCMetadata(input.Id)
+	err = u.rep.PurgeLocalPNRClarifications(input.Id)
 
 	if err != nil {
 		slog.Error(
-			"Could not delete GC metadata",
+			"Could not purge PNR clarifications",
 			"error", err,
 		)
 		return status.Wrap(err, status.Internal)
 	}
 
+	if !isDraft {
+		err = u.rep.DeleteLocalGCMetadata(input.Id)
+
+		if err != nil {
+			slog.Error(
+				"Could not delete GC metadata",
+				"error", err,
+			)
+			return status.Wrap(err, status.Internal)
+		}
+	}
+
 	*output = entities.TerminatePNRRequestOutput{}
 
 	slog.Debug(
@@ -498,3 +887,84 @@ func (u RMTUsecase) TerminatePNRRequest(ctx context.Context, input entities.Term
 
 	return nil
 }
+
+func (u RMTUsecase) GetPNRThread(ctx context.Context, input entities.GetPNRThreadInput, output *[]entities.PNR) error {
+	slog.Debug(
+		"GetPNRThread called",
+		"input", input,
+	)
+
+	root, err := u.rep.GetPNR(input.Id)
+	if err != nil {
+		slog.Error(
+			"Could not get PNR request",
+			"id", input.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	visited := map[string]bool{root.Id: true}
+
+	for root.ParentId != "" && !visited[root.ParentId] {
+		parent, err := u.rep.GetPNR(root.ParentId)
+
+		if err != nil {
+			// The parent may not be visible to this PIU, the thread starts at the
+			// oldest request it can see.
+			slog.Debug(
+				"Could not get parent PNR request",
+				"id", root.Id,
+				"parentId", root.ParentId,
+				"error", err,
+			)
+			break
+		}
+
+		visited[parent.Id] = true
+		root = parent
+	}
+
+	thread := []entities.PNR{root}
+	visited = map[string]bool{root.Id: true}
+
+	for i := 0; i < len(thread); i++ {
+		children, err := u.rep.GetPNRs(entities.PNRFilter{ParentId: thread[i].Id})
+
+		if err != nil {
+			slog.Error(
+				"Failed to get follow-up PNRs from the repository",
+				"id", thread[i].Id,
+				"error", err,
+			)
+			return status.Wrap(err, status.Internal)
+		}
+
+		slices.SortFunc(children, func(a, b entities.PNR) int {
+			return a.RequestTimestamp.Compare(b.RequestTimestamp)
+		})
+
+		for _, child := range children {
+			if !visited[child.Id] {
+				visited[child.Id] = true
+				thread = append(thread, child)
+			}
+		}
+	}
+
+	slices.SortStableFunc(thread, func(a, b entities.PNR) int {
+		return a.RequestTimestamp.Compare(b.RequestTimestamp)
+	})
+
+	*output = make([]entities.PNR, 0, len(thread))
+	for _, pnr := range thread {
+		*output = append(*output, entities.WithoutData(pnr))
+	}
+
+	slog.Debug(
+		"GetPNRThread finished",
+		"output", output,
+	)
+
+	return nil
+}
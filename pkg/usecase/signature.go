@@ -0,0 +1,259 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/gowebpki/jcs"
+	"github.com/swaggest/usecase/status"
+
+	"github.com/nesfit/tenacity-chaincode/pkg/entities"
+)
+
+// responseDigest computes the SHA-256 hash of the JCS canonical form of the
+// response data, responses without data are signed as JSON null.
+func responseDigest(responseData string) ([]byte, error) {
+	if responseData == "" {
+		responseData = "null"
+	}
+
+	canonical, err := jcs.Transform([]byte(responseData))
+
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(canonical)
+
+	return sum[:], nil
+}
+
+// verifyResponseSignature checks the signature of the digest with the given
+// certificate and that the certificate is the registered one or was issued by
+// it. Further certificates following the first one are used as intermediates.
+func verifyResponseSignature(registered string, certificate string, digest []byte, signature []byte, at time.Time) (*x509.Certificate, error) {
+	roots, err := parseCertificatesPEM(registered)
+
+	if err != nil {
+		return nil, errors.New("Invalid registered signing certificate: " + err.Error())
+	}
+
+	chain, err := parseCertificatesPEM(certificate)
+
+	if err != nil {
+		return nil, errors.New("Invalid response certificate: " + err.Error())
+	}
+
+	leaf := chain[0]
+
+	options := x509.VerifyOptions{
+		Roots:         x509.NewCertPool(),
+		Intermediates: x509.NewCertPool(),
+		CurrentTime:   at,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+
+	for _, root := range roots {
+		options.Roots.AddCert(root)
+	}
+
+	for _, intermediate := range chain[1:] {
+		options.Intermediates.AddCert(intermediate)
+	}
+
+	if _, err := leaf.Verify(options); err != nil {
+		return leaf, errors.New("Certificate is not trusted by the registered signing certificate: " + err.Error())
+	}
+
+	if err := verifySignature(leaf.PublicKey, digest, signature); err != nil {
+		return leaf, errors.New("Invalid signature: " + err.Error())
+	}
+
+	return leaf, nil
+}
+
+// signPNRResponse attaches the detached signature of the response to the PNR
+// request. The signature is required when the responding PIU registered a
+// signing certificate and rejected otherwise.
+func (u RMTUsecase) signPNRResponse(pnr *entities.PNR, input entities.SubmitPNRResponseInput) error {
+	pnr.ResponseDigest = ""
+	pnr.ResponseSignature = nil
+	pnr.ResponseCertificate = ""
+	pnr.ResponseSignedAt = time.Time{}
+
+	piu, err := u.rep.GetPIU(pnr.RespondingPIU)
+
+	if err != nil {
+		slog.Error(
+			"Could not get information about PIU",
+			"id", pnr.RespondingPIU,
+			"error", err,
+		)
+		return status.Wrap(err, status.Internal)
+	}
+
+	signed := len(input.ResponseSignature) > 0 || input.ResponseCertificate != ""
+
+	if piu.SigningCertificate == "" {
+		if signed {
+			err := errors.New("Responding PIU has no signing certificate to verify the response")
+			slog.Error(
+				err.Error(),
+				"id", pnr.Id,
+				"respondingPIU", pnr.RespondingPIU,
+			)
+			return status.Wrap(err, status.FailedPrecondition)
+		}
+
+		return nil
+	}
+
+	if len(input.ResponseSignature) == 0 || input.ResponseCertificate == "" {
+		err := errors.New("Response must be signed with the certificate of the responding PIU")
+		slog.Error(
+			err.Error(),
+			"id", pnr.Id,
+			"respondingPIU", pnr.RespondingPIU,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	digest, err := responseDigest(pnr.ResponseData)
+
+	if err != nil {
+		slog.Error(
+			"Failed to transform PNR response to canonical form",
+			"id", pnr.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	now, err := u.now()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = verifyResponseSignature(piu.SigningCertificate, input.ResponseCertificate, digest, input.ResponseSignature, now)
+
+	if err != nil {
+		slog.Error(
+			"Could not verify signature of PNR response",
+			"id", pnr.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	pnr.ResponseDigest = hex.EncodeToString(digest)
+	pnr.ResponseSignature = input.ResponseSignature
+	pnr.ResponseCertificate = input.ResponseCertificate
+	pnr.ResponseSignedAt = now
+
+	return nil
+}
+
+// VerifyPNRResponseSignature checks the signature of the PNR response against
+// the signing certificate currently registered by the responding PIU. The
+// certificate is verified at the time the signature was verified on
+// submission. The response data is compared with the signed digest while it
+// has not been purged.
+func (u RMTUsecase) VerifyPNRResponseSignature(ctx context.Context, input entities.VerifyPNRResponseSignatureInput, output *entities.PNRResponseSignature) error {
+	slog.Debug(
+		"VerifyPNRResponseSignature called",
+		"input", input,
+	)
+
+	pnr, err := u.rep.GetPNR(input.Id)
+
+	if err != nil {
+		slog.Error(
+			"Could not get PNR request",
+			"id", input.Id,
+			"error", err,
+		)
+		return status.Wrap(err, status.InvalidArgument)
+	}
+
+	if !u.isThisPIU(pnr.RequestingPIU) && !u.isThisPIU(pnr.RespondingPIU) {
+		err := errors.New("PIU is not a party of the PNR request")
+		slog.Error(
+			err.Error(),
+			"clientId", u.piuId,
+			"id", pnr.Id,
+		)
+		return status.Wrap(err, status.PermissionDenied)
+	}
+
+	result := entities.PNRResponseSignature{
+		Id:     pnr.Id,
+		Signed: len(pnr.ResponseSignature) > 0,
+	}
+
+	if result.Signed {
+		piu, err := u.rep.GetPIU(pnr.RespondingPIU)
+
+		if err != nil {
+			slog.Error(
+				"Could not get information about PIU",
+				"id", pnr.RespondingPIU,
+				"error", err,
+			)
+			return status.Wrap(err, status.NotFound)
+		}
+
+		result.Valid, result.DataVerified, result.Signer, result.Reason = checkPNRResponseSignature(pnr, piu)
+	} else {
+		result.Reason = "Response is not signed"
+	}
+
+	*output = result
+
+	slog.Debug(
+		"VerifyPNRResponseSignature finished",
+		"output", output,
+	)
+
+	return nil
+}
+
+func checkPNRResponseSignature(pnr entities.PNR, piu entities.PIU) (valid bool, dataVerified bool, signer string, reason string) {
+	if piu.SigningCertificate == "" {
+		return false, false, "", "Responding PIU has no signing certificate"
+	}
+
+	digest, err := hex.DecodeString(pnr.ResponseDigest)
+
+	if err != nil {
+		return false, false, "", "Invalid response digest"
+	}
+
+	// Response data encrypted at rest cannot be compared with the digest.
+	if pnr.ResponseData != "" && !isSealedData(pnr.ResponseData) {
+		data, err := responseDigest(pnr.ResponseData)
+
+		if err != nil || hex.EncodeToString(data) != pnr.ResponseDigest {
+			return false, false, "", "Response data does not match the signed digest"
+		}
+
+		dataVerified = true
+	}
+
+	leaf, err := verifyResponseSignature(piu.SigningCertificate, pnr.ResponseCertificate, digest, pnr.ResponseSignature, pnr.ResponseSignedAt)
+
+	if leaf != nil {
+		signer = leaf.Subject.String()
+	}
+
+	if err != nil {
+		return false, dataVerified, signer, err.Error()
+	}
+
+	return true, dataVerified, signer, ""
+}
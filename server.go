@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// shutdownTimeout bounds the graceful shutdown of the health endpoint.
+const shutdownTimeout = 5 * time.Second
+
+// defaultDrainPeriod is how long the chaincode server keeps running after
+// a shutdown signal, so that in-flight invocations can finish.
+const defaultDrainPeriod = 5 * time.Second
+
+// defaultHealthAddress is the address of the health endpoint, which must not
+// clash with the address of the chaincode server, usually :9999.
+const defaultHealthAddress = ":9443"
+
+// listenPollInterval is how often the chaincode server is probed until it
+// accepts connections.
+const listenPollInterval = 100 * time.Millisecond
+
+// getDrainPeriod returns the drain period set by CHAINCODE_DRAIN_PERIOD.
+func getDrainPeriod() (time.Duration, error) {
+	period := os.Getenv("CHAINCODE_DRAIN_PERIOD")
+
+	if period == "" {
+		return defaultDrainPeriod, nil
+	}
+
+	d, err := time.ParseDuration(period)
+	if err != nil {
+		return 0, err
+	}
+
+	if d < 0 {
+		return 0, errors.New("drain period must not be negative")
+	}
+
+	return d, nil
+}
+
+// waitListening waits until the address accepts connections, it gives up
+// and returns false once done is closed.
+func waitListening(address string, done <-chan struct{}) bool {
+	ticker := time.NewTicker(listenPollInterval)
+	defer ticker.Stop()
+
+	for {
+		conn, err := net.DialTimeout("tcp", address, listenPollInterval)
+		if err == nil {
+			conn.Close()
+			return true
+		}
+
+		select {
+		case <-done:
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// getTLSProperties loads TLS files named by CHAINCODE_TLS_CERT,
+// CHAINCODE_TLS_KEY and CHAINCODE_CLIENT_CA_CERT. TLS is disabled unless
+// both the certificate and the key are set, client certificates are verified
+// only when the client CA is set.
+func getTLSProperties() (shim.TLSProperties, error) {
+	certFile := os.Getenv("CHAINCODE_TLS_CERT")
+	keyFile := os.Getenv("CHAINCODE_TLS_KEY")
+	clientCAFile := os.Getenv("CHAINCODE_CLIENT_CA_CERT")
+
+	if certFile == "" && keyFile == "" {
+		return shim.TLSProperties{Disabled: true}, nil
+	}
+
+	if certFile == "" || keyFile == "" {
+		return shim.TLSProperties{}, errors.New("both CHAINCODE_TLS_CERT and CHAINCODE_TLS_KEY have to be set")
+	}
+
+	cert, err := os.ReadFile(certFile)
+	if err != nil {
+		return shim.TLSProperties{}, err
+	}
+
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return shim.TLSProperties{}, err
+	}
+
+	props := shim.TLSProperties{Cert: cert, Key: key}
+
+	if clientCAFile != "" {
+		props.ClientCACerts, err = os.ReadFile(clientCAFile)
+		if err != nil {
+			return shim.TLSProperties{}, err
+		}
+	}
+
+	return props, nil
+}
+
+// sameListenAddress reports whether servers listening at given addresses
+// would bind the same port.
+func sameListenAddress(a string, b string) bool {
+	hostA, portA, errA := net.SplitHostPort(a)
+	hostB, portB, errB := net.SplitHostPort(b)
+
+	if errA != nil || errB != nil {
+		return a == b
+	}
+
+	if portA != portB {
+		return false
+	}
+
+	wildcard := func(host string) bool {
+		return host == "" || host == "0.0.0.0" || host == "::"
+	}
+
+	return hostA == hostB || wildcard(hostA) || wildcard(hostB)
+}
+
+// serve runs the chaincode as an external service at given address until
+// it fails or SIGTERM or SIGINT is received. A health endpoint at
+// CHAINCODE_HEALTH_ADDRESS, :9443 by default, reports whether the chaincode
+// server accepts connections. After a signal the endpoint reports the server
+// as unavailable, while the server keeps running for CHAINCODE_DRAIN_PERIOD
+// to finish in-flight invocations.
+func serve(cc shim.Chaincode, address string) {
+	ccid := os.Getenv("CHAINCODE_ID")
+	if ccid == "" {
+		log.Panicf("CHAINCODE_ID has to be set together with CHAINCODE_SERVER_ADDRESS")
+	}
+
+	tlsProps, err := getTLSProperties()
+	if err != nil {
+		log.Panicf("Error loading TLS properties: %v", err)
+	}
+
+	drainPeriod, err := getDrainPeriod()
+	if err != nil {
+		log.Panicf("Invalid drain period: %v", err)
+	}
+
+	server := &shim.ChaincodeServer{
+		CCID:     ccid,
+		Address:  address,
+		CC:       cc,
+		TLSProps: tlsProps,
+	}
+
+	healthAddress := os.Getenv("CHAINCODE_HEALTH_ADDRESS")
+	if healthAddress == "" {
+		healthAddress = defaultHealthAddress
+	}
+
+	if sameListenAddress(healthAddress, address) {
+		log.Panicf("CHAINCODE_HEALTH_ADDRESS %s clashes with CHAINCODE_SERVER_ADDRESS %s", healthAddress, address)
+	}
+
+	var listening, stopping atomic.Bool
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if !listening.Load() || stopping.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Write([]byte("ok"))
+	})
+
+	health := &http.Server{Addr: healthAddress, Handler: mux}
+
+	go func() {
+		if err := health.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error(
+				"health endpoint failed",
+				"error", err,
+			)
+		}
+	}()
+
+	failed := make(chan error, 1)
+
+	go func() {
+		failed <- server.Start()
+	}()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		if waitListening(address, done) {
+			listening.Store(true)
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+
+	slog.Info(
+		"chaincode server started",
+		"ccid", ccid,
+		"address", address,
+		"tls", !tlsProps.Disabled,
+		"healthAddress", healthAddress,
+	)
+
+	select {
+	case err = <-failed:
+		stopping.Store(true)
+	case sig := <-signals:
+		stopping.Store(true)
+
+		slog.Info(
+			"shutting down chaincode server",
+			"signal", sig.String(),
+			"drainPeriod", drainPeriod,
+		)
+
+		select {
+		case err = <-failed:
+		case <-time.After(drainPeriod):
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	health.Shutdown(ctx)
+
+	if err != nil {
+		log.Panicf("Error running chaincode server: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestingFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestGetTLSProperties(t *testing.T) {
+	cert := writeTestingFile(t, "cert.pem", "cert")
+	key := writeTestingFile(t, "key.pem", "key")
+	clientCA := writeTestingFile(t, "ca.pem", "ca")
+	missing := filepath.Join(t.TempDir(), "missing.pem")
+
+	testCases := map[string]struct {
+		Cert     string
+		Key      string
+		ClientCA string
+		Expected shim.TLSProperties
+		Error    bool
+	}{
+		"disabled": {
+			Expected: shim.TLSProperties{Disabled: true},
+		},
+		"certOnly": {
+			Cert:  cert,
+			Error: true,
+		},
+		"keyOnly": {
+			Key:   key,
+			Error: true,
+		},
+		"enabled": {
+			Cert:     cert,
+			Key:      key,
+			Expected: shim.TLSProperties{Cert: []byte("cert"), Key: []byte("key")},
+		},
+		"clientCA": {
+			Cert:     cert,
+			Key:      key,
+			ClientCA: clientCA,
+			Expected: shim.TLSProperties{Cert: []byte("cert"), Key: []byte("key"), ClientCACerts: []byte("ca")},
+		},
+		"missingCert": {
+			Cert:  missing,
+			Key:   key,
+			Error: true,
+		},
+		"missingClientCA": {
+			Cert:     cert,
+			Key:      key,
+			ClientCA: missing,
+			Error:    true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			t.Setenv("CHAINCODE_TLS_CERT", testCase.Cert)
+			t.Setenv("CHAINCODE_TLS_KEY", testCase.Key)
+			t.Setenv("CHAINCODE_CLIENT_CA_CERT", testCase.ClientCA)
+
+			actual, err := getTLSProperties()
+			if testCase.Error {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+				assert.Equal(testCase.Expected, actual)
+			}
+		})
+	}
+}
+
+func TestGetDrainPeriod(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Setenv("CHAINCODE_DRAIN_PERIOD", "")
+	actual, err := getDrainPeriod()
+	assert.NoError(err)
+	assert.Equal(defaultDrainPeriod, actual)
+
+	t.Setenv("CHAINCODE_DRAIN_PERIOD", "30s")
+	actual, err = getDrainPeriod()
+	assert.NoError(err)
+	assert.Equal(30*time.Second, actual)
+
+	t.Setenv("CHAINCODE_DRAIN_PERIOD", "-1s")
+	_, err = getDrainPeriod()
+	assert.Error(err)
+
+	t.Setenv("CHAINCODE_DRAIN_PERIOD", "soon")
+	_, err = getDrainPeriod()
+	assert.Error(err)
+}
+
+func TestSameListenAddress(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(sameListenAddress(defaultHealthAddress, defaultHealthAddress))
+	assert.True(sameListenAddress(":9999", "0.0.0.0:9999"))
+	assert.True(sameListenAddress("127.0.0.1:9999", ":9999"))
+	assert.False(sameListenAddress(defaultHealthAddress, "0.0.0.0:9999"))
+	assert.False(sameListenAddress("127.0.0.1:9999", "10.0.0.1:9999"))
+}
+
+func TestWaitListening(t *testing.T) {
+	assert := assert.New(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address := listener.Addr().String()
+
+	assert.True(waitListening(address, make(chan struct{})))
+
+	listener.Close()
+
+	done := make(chan struct{})
+	close(done)
+
+	assert.False(waitListening(address, done))
+}